@@ -8,13 +8,16 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"solana-balance-api/internal/config"
 	"solana-balance-api/internal/models"
 	"solana-balance-api/internal/services"
+	"solana-balance-api/pkg/audit"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -27,14 +30,31 @@ func main() {
 		rollback    = flag.Bool("rollback", false, "Rollback last migration")
 		healthCheck = flag.Bool("health", false, "Run database health check")
 		all         = flag.Bool("all", false, "Run init, migrate, and seed (full setup)")
+		createKey   = flag.String("create-key", "", "Create an API key with the given name")
+		scopes      = flag.String("scopes", strings.Join(models.DefaultScopes, ","), "Comma-separated scopes for -create-key")
+		expiresIn   = flag.Duration("expires-in", 0, "Lifetime of the key created by -create-key (0 = never expires)")
+		tenant      = flag.String("tenant", "", "Organization tenant ID that owns the key created by -create-key")
+		keyWallets  = flag.Int("key-max-wallets", 0, "Maximum wallets per request for -create-key (0 = configured default)")
+		keyBodySize = flag.Int64("key-max-body-bytes", 0, "Maximum request body size for -create-key (0 = configured default)")
+		createOrg   = flag.String("create-org", "", "Create an organization with the given tenant ID")
+		orgName     = flag.String("org-name", "", "Display name for -create-org (defaults to the tenant ID)")
+		rateTier    = flag.String("tier", models.RateTierStandard, "Rate tier for -create-org (free, standard, enterprise)")
+		monthlyReqs = flag.Int64("monthly-requests", 0, "Monthly request quota for -create-org (0 = unlimited)")
+		monthlyWals = flag.Int64("monthly-wallets", 0, "Monthly wallet quota for -create-org (0 = unlimited)")
+		maxKeys     = flag.Int("max-keys", 0, "Maximum API keys for -create-org (0 = unlimited)")
+		verifyAudit = flag.Bool("verify-audit", false, "Verify the audit log hash chain for gaps or tampering")
+		configFile  = flag.String("config", os.Getenv(config.ConfigFileEnv), "Path to a YAML or JSON configuration file")
 	)
 	flag.Parse()
 
 	// Load configuration
-	cfg := config.LoadConfig()
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
 
 	// If no flags specified, show usage
-	if !*initDB && !*seedData && !*migrate && !*rollback && !*healthCheck && !*all {
+	if !*initDB && !*seedData && !*migrate && !*rollback && !*healthCheck && !*all && *createKey == "" && *createOrg == "" && !*verifyAudit {
 		fmt.Println("Database Setup Utility")
 		fmt.Println("Usage:")
 		fmt.Println("  -init      Initialize database with schema and indexes")
@@ -43,6 +63,12 @@ func main() {
 		fmt.Println("  -rollback  Rollback last migration")
 		fmt.Println("  -health    Run database health check")
 		fmt.Println("  -all       Run full setup (init + migrate + seed)")
+		fmt.Println("  -create-org TENANT [-org-name NAME] [-tier standard] [-monthly-requests N] [-monthly-wallets N] [-max-keys N]")
+		fmt.Println("             Create an organization that owns API keys")
+		fmt.Println("  -create-key NAME [-scopes balance:read,tokens:read] [-expires-in 2160h] [-tenant TENANT]")
+		fmt.Println("             Create an API key with the given scopes")
+		fmt.Printf("             Available scopes: %s\n", strings.Join(models.AllScopes, ", "))
+		fmt.Println("  -verify-audit  Verify the audit log hash chain for gaps or tampering")
 		fmt.Println()
 		fmt.Println("Environment Variables:")
 		fmt.Println("  MONGODB_URI              MongoDB connection string")
@@ -86,6 +112,39 @@ func main() {
 		}
 	}
 
+	// Create an organization
+	if *createOrg != "" {
+		org := models.Organization{
+			TenantID: *createOrg,
+			Name:     *orgName,
+			Active:   true,
+			RateTier: *rateTier,
+			Quotas: models.OrganizationQuotas{
+				MonthlyRequests: *monthlyReqs,
+				MonthlyWallets:  *monthlyWals,
+				MaxKeys:         *maxKeys,
+			},
+		}
+		if err := createOrganization(&cfg.MongoDB, org); err != nil {
+			log.Fatalf("Organization creation failed: %v", err)
+		}
+	}
+
+	// Create a single API key
+	if *createKey != "" {
+		limits := models.RequestLimits{MaxWallets: *keyWallets, MaxBodyBytes: *keyBodySize}
+		if err := createAPIKey(&cfg.MongoDB, *createKey, *scopes, *expiresIn, *tenant, limits); err != nil {
+			log.Fatalf("API key creation failed: %v", err)
+		}
+	}
+
+	// Verify the audit log
+	if *verifyAudit {
+		if err := verifyAuditLog(&cfg.MongoDB); err != nil {
+			log.Fatalf("Audit log verification failed: %v", err)
+		}
+	}
+
 	log.Println("Database setup completed successfully!")
 }
 
@@ -141,6 +200,10 @@ func runMigrations(cfg *config.MongoDBConfig) error {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
+	if err := initializer.BackfillDefaultScopes(); err != nil {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+
 	log.Println("Database migrations completed successfully!")
 	return nil
 }
@@ -191,6 +254,128 @@ func seedTestData(cfg *config.MongoDBConfig) error {
 	return nil
 }
 
+// createOrganization creates a new organization
+func createOrganization(cfg *config.MongoDBConfig, org models.Organization) error {
+	switch org.RateTier {
+	case models.RateTierFree, models.RateTierStandard, models.RateTierEnterprise:
+	default:
+		return fmt.Errorf("unknown rate tier %q", org.RateTier)
+	}
+	if org.Name == "" {
+		org.Name = org.TenantID
+	}
+
+	initializer, err := NewDatabaseInitializer(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create database initializer: %w", err)
+	}
+	defer initializer.Close()
+
+	if err := initializer.CreateOrganization(&org); err != nil {
+		return err
+	}
+
+	log.Printf("Created organization %s (%s) with rate tier %s", org.TenantID, org.Name, org.RateTier)
+	return nil
+}
+
+// createAPIKey creates a new active API key with the given comma-separated scopes,
+// optional lifetime and optional owning organization
+func createAPIKey(cfg *config.MongoDBConfig, name, scopeList string, expiresIn time.Duration, tenantID string, limits models.RequestLimits) error {
+	scopes, err := parseScopes(scopeList)
+	if err != nil {
+		return err
+	}
+
+	initializer, err := NewDatabaseInitializer(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create database initializer: %w", err)
+	}
+	defer initializer.Close()
+
+	if tenantID != "" {
+		if err := initializer.CheckOrganizationKeyQuota(tenantID); err != nil {
+			return err
+		}
+	}
+
+	apiKey, err := initializer.CreateAPIKey(name, scopes, expiresIn, tenantID, limits)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Created API key %s (%s) with scopes %v", apiKey.Key, apiKey.Name, apiKey.Scopes)
+	if apiKey.ExpiresAt != nil {
+		log.Printf("  expires at %s", apiKey.ExpiresAt.Format(time.RFC3339))
+	}
+
+	err = initializer.AppendAuditEvent(audit.Event{
+		Type:     audit.EventKeyCreated,
+		Outcome:  audit.OutcomeSuccess,
+		TenantID: apiKey.TenantID,
+		Target:   apiKey.ID.Hex(),
+		Details: map[string]string{
+			"name":   apiKey.Name,
+			"scopes": strings.Join(apiKey.Scopes, " "),
+			"source": "dbsetup",
+		},
+	})
+	if err != nil {
+		log.Printf("Warning: failed to audit API key creation: %v", err)
+	}
+	return nil
+}
+
+// verifyAuditLog walks the audit log hash chain and reports every issue found
+func verifyAuditLog(cfg *config.MongoDBConfig) error {
+	log.Println("Verifying audit log...")
+
+	initializer, err := NewDatabaseInitializer(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create database initializer: %w", err)
+	}
+	defer initializer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	result, err := audit.Verify(ctx, initializer.db.Collection(cfg.AuditCollection))
+	if err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	log.Printf("Checked %d audit records (last sequence %d)", result.Records, result.LastSequence)
+	if result.Valid() {
+		log.Printf("Audit log chain is intact (head hash %s)", result.LastHash)
+		return nil
+	}
+
+	for _, issue := range result.Issues {
+		log.Printf("  sequence %d: %s: %s", issue.Sequence, issue.Kind, issue.Message)
+	}
+	return fmt.Errorf("found %d issues in the audit log", len(result.Issues))
+}
+
+// parseScopes splits and validates a comma-separated scope list
+func parseScopes(scopeList string) ([]string, error) {
+	var scopes []string
+	for _, scope := range strings.Split(scopeList, ",") {
+		scope = strings.TrimSpace(scope)
+		if scope == "" {
+			continue
+		}
+		if !models.IsValidScope(scope) {
+			return nil, fmt.Errorf("unknown scope %q (available: %s)", scope, strings.Join(models.AllScopes, ", "))
+		}
+		scopes = append(scopes, scope)
+	}
+
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+	return scopes, nil
+}
+
 // DatabaseInitializer handles database setup and seeding
 type DatabaseInitializer struct {
 	client *mongo.Client
@@ -270,6 +455,103 @@ func (di *DatabaseInitializer) InitializeDatabase() error {
 		return fmt.Errorf("failed to create compound index: %w", err)
 	}
 
+	// Create sparse index on previous_key for rotated secret lookups
+	previousKeyIndexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "previous_key", Value: 1}},
+		Options: options.Index().SetSparse(true),
+	}
+
+	_, err = collection.Indexes().CreateOne(ctx, previousKeyIndexModel)
+	if err != nil {
+		return fmt.Errorf("failed to create index on previous_key field: %w", err)
+	}
+
+	// Create compound index on active and expires_at for the expiry sweeper
+	expiryIndexModel := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "active", Value: 1},
+			{Key: "expires_at", Value: 1},
+		},
+	}
+
+	_, err = collection.Indexes().CreateOne(ctx, expiryIndexModel)
+	if err != nil {
+		return fmt.Errorf("failed to create expiry index: %w", err)
+	}
+
+	// Create sparse index on tenant_id for listing an organization's keys
+	tenantIndexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "tenant_id", Value: 1}},
+		Options: options.Index().SetSparse(true),
+	}
+
+	_, err = collection.Indexes().CreateOne(ctx, tenantIndexModel)
+	if err != nil {
+		return fmt.Errorf("failed to create index on tenant_id field: %w", err)
+	}
+
+	// Create unique index on organization tenant_id
+	_, err = di.db.Collection(di.config.OrganizationCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "tenant_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create index on organization tenant_id: %w", err)
+	}
+
+	// Create unique index on tenant_id and period for usage rollups
+	_, err = di.db.Collection(di.config.OrganizationUsageCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "tenant_id", Value: 1},
+			{Key: "period", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create organization usage index: %w", err)
+	}
+
+	// Create unique index on sequence so concurrent writers cannot fork the audit chain
+	_, err = di.db.Collection(di.config.AuditCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "sequence", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create audit log sequence index: %w", err)
+	}
+
+	// Create indexes for audit queries by event type and by actor
+	_, err = di.db.Collection(di.config.AuditCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "type", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "actor_key_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create audit log query indexes: %w", err)
+	}
+
+	// Create unique index on key_id and bucket for hourly per-key usage
+	_, err = di.db.Collection(di.config.UsageCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "key_id", Value: 1},
+			{Key: "bucket", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create API key usage index: %w", err)
+	}
+
+	// Create index on tenant_id and bucket for organization usage reports
+	_, err = di.db.Collection(di.config.UsageCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "tenant_id", Value: 1},
+			{Key: "bucket", Value: 1},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create API key usage tenant index: %w", err)
+	}
+
 	log.Println("Database schema setup completed successfully")
 	return nil
 }
@@ -279,6 +561,10 @@ func (di *DatabaseInitializer) SeedTestData() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	if err := di.seedOrganizations(ctx); err != nil {
+		return err
+	}
+
 	log.Println("Creating test API keys...")
 
 	collection := di.db.Collection(di.config.APIKeyCollection)
@@ -301,18 +587,53 @@ func (di *DatabaseInitializer) SeedTestData() error {
 			Name:      "Test API Key 1",
 			Active:    true,
 			CreatedAt: time.Now(),
+			Scopes:    []string{models.ScopeBalanceRead, models.ScopeTokensRead, models.ScopeHistoryRead},
+			TenantID:  "team-alpha",
 		},
 		{
 			Key:       "test-api-key-2",
 			Name:      "Test API Key 2",
 			Active:    true,
 			CreatedAt: time.Now(),
+			Scopes:    models.DefaultScopes,
+			TenantID:  "team-beta",
+		},
+		{
+			Key:       "admin-test-key",
+			Name:      "Admin Test Key",
+			Active:    true,
+			CreatedAt: time.Now(),
+			Scopes:    []string{models.ScopeAdmin},
+		},
+		{
+			Key:       "webhooks-only-test-key",
+			Name:      "Webhooks Only Test Key (no balance access)",
+			Active:    true,
+			CreatedAt: time.Now(),
+			Scopes:    []string{models.ScopeWebhooksWrite},
+		},
+		{
+			Key:       "expiring-test-key",
+			Name:      "Expiring Test Key (expires in 7 days)",
+			Active:    true,
+			CreatedAt: time.Now(),
+			Scopes:    models.DefaultScopes,
+			ExpiresAt: timePtr(time.Now().Add(7 * 24 * time.Hour)),
+		},
+		{
+			Key:          "restricted-test-key",
+			Name:         "Restricted Test Key (localhost only)",
+			Active:       true,
+			CreatedAt:    time.Now(),
+			Scopes:       models.DefaultScopes,
+			AllowedCIDRs: []string{"127.0.0.1/32", "::1/128"},
 		},
 		{
 			Key:       "inactive-test-key",
 			Name:      "Inactive Test Key",
 			Active:    false,
 			CreatedAt: time.Now(),
+			Scopes:    models.DefaultScopes,
 		},
 	}
 
@@ -328,6 +649,7 @@ func (di *DatabaseInitializer) SeedTestData() error {
 			Name:      fmt.Sprintf("Generated Test Key %d", i+1),
 			Active:    true,
 			CreatedAt: time.Now(),
+			Scopes:    models.DefaultScopes,
 		})
 	}
 
@@ -351,9 +673,184 @@ func (di *DatabaseInitializer) SeedTestData() error {
 		if !apiKey.Active {
 			status = "inactive"
 		}
-		log.Printf("  - %s (%s) [%s]", apiKey.Key, apiKey.Name, status)
+		log.Printf("  - %s (%s) [%s] scopes=%v tenant=%q", apiKey.Key, apiKey.Name, status, apiKey.Scopes, apiKey.TenantID)
+	}
+
+	return nil
+}
+
+// seedOrganizations creates sample organizations if none exist
+func (di *DatabaseInitializer) seedOrganizations(ctx context.Context) error {
+	collection := di.db.Collection(di.config.OrganizationCollection)
+
+	count, err := collection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return fmt.Errorf("failed to count existing organizations: %w", err)
+	}
+
+	if count > 0 {
+		log.Printf("Found %d existing organizations, skipping organization seed data", count)
+		return nil
+	}
+
+	log.Println("Creating test organizations...")
+
+	now := time.Now()
+	organizations := []interface{}{
+		models.Organization{
+			TenantID:  "team-alpha",
+			Name:      "Team Alpha",
+			Active:    true,
+			RateTier:  models.RateTierEnterprise,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		models.Organization{
+			TenantID: "team-beta",
+			Name:     "Team Beta",
+			Active:   true,
+			RateTier: models.RateTierFree,
+			Quotas: models.OrganizationQuotas{
+				MonthlyRequests: 10000,
+				MonthlyWallets:  100000,
+				MaxKeys:         2,
+			},
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+	}
+
+	if _, err := collection.InsertMany(ctx, organizations); err != nil {
+		return fmt.Errorf("failed to insert test organizations: %w", err)
+	}
+
+	log.Printf("Successfully created %d test organizations", len(organizations))
+	return nil
+}
+
+// AppendAuditEvent appends an event to the audit log hash chain
+func (di *DatabaseInitializer) AppendAuditEvent(event audit.Event) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	auditLog := audit.NewLogger(di.db.Collection(di.config.AuditCollection), audit.Config{})
+	defer auditLog.Close()
+
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	return auditLog.Append(ctx, event)
+}
+
+// CreateAPIKey inserts a new active API key with a random secret and the given scopes.
+// A positive expiresIn sets expires_at; a non-empty tenantID assigns the key to an organization.
+// Non-zero limits override the configured request limits for the key.
+func (di *DatabaseInitializer) CreateAPIKey(name string, scopes []string, expiresIn time.Duration, tenantID string, limits models.RequestLimits) (*models.APIKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	key, err := generateRandomAPIKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	apiKey := models.APIKey{
+		Key:       key,
+		Name:      name,
+		Active:    true,
+		CreatedAt: time.Now(),
+		Scopes:    scopes,
+		TenantID:  tenantID,
+	}
+	if expiresIn > 0 {
+		expiresAt := apiKey.CreatedAt.Add(expiresIn)
+		apiKey.ExpiresAt = &expiresAt
+	}
+	if limits != (models.RequestLimits{}) {
+		apiKey.Limits = &limits
+	}
+
+	collection := di.db.Collection(di.config.APIKeyCollection)
+	if _, err := collection.InsertOne(ctx, apiKey); err != nil {
+		return nil, fmt.Errorf("failed to insert API key: %w", err)
+	}
+
+	return &apiKey, nil
+}
+
+// CreateOrganization inserts a new organization
+func (di *DatabaseInitializer) CreateOrganization(org *models.Organization) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	org.CreatedAt = now
+	org.UpdatedAt = now
+
+	result, err := di.db.Collection(di.config.OrganizationCollection).InsertOne(ctx, org)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("organization %s already exists", org.TenantID)
+		}
+		return fmt.Errorf("failed to insert organization: %w", err)
 	}
 
+	if id, ok := result.InsertedID.(primitive.ObjectID); ok {
+		org.ID = id
+	}
+	return nil
+}
+
+// CheckOrganizationKeyQuota verifies that an organization exists, is active and can own another key
+func (di *DatabaseInitializer) CheckOrganizationKeyQuota(tenantID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var org models.Organization
+	err := di.db.Collection(di.config.OrganizationCollection).FindOne(ctx, bson.M{"tenant_id": tenantID}).Decode(&org)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return fmt.Errorf("organization %s does not exist; create it with -create-org", tenantID)
+		}
+		return fmt.Errorf("failed to load organization: %w", err)
+	}
+
+	if !org.Active {
+		return fmt.Errorf("organization %s is inactive", tenantID)
+	}
+
+	if org.Quotas.MaxKeys > 0 {
+		count, err := di.db.Collection(di.config.APIKeyCollection).CountDocuments(ctx, bson.M{"tenant_id": tenantID, "active": true})
+		if err != nil {
+			return fmt.Errorf("failed to count organization keys: %w", err)
+		}
+		if count >= int64(org.Quotas.MaxKeys) {
+			return fmt.Errorf("organization %s already has %d of %d allowed keys", tenantID, count, org.Quotas.MaxKeys)
+		}
+	}
+
+	return nil
+}
+
+// BackfillDefaultScopes grants the default scopes to keys created before scoped permissions
+func (di *DatabaseInitializer) BackfillDefaultScopes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	collection := di.db.Collection(di.config.APIKeyCollection)
+
+	filter := bson.M{"$or": []bson.M{
+		{"scopes": bson.M{"$exists": false}},
+		{"scopes": bson.M{"$size": 0}},
+	}}
+	update := bson.M{"$set": bson.M{"scopes": models.DefaultScopes}}
+
+	result, err := collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to add default scopes: %w", err)
+	}
+
+	log.Printf("Added default scopes %v to %d API keys", models.DefaultScopes, result.ModifiedCount)
 	return nil
 }
 
@@ -366,6 +863,11 @@ func generateRandomAPIKey() (string, error) {
 	return hex.EncodeToString(bytes), nil
 }
 
+// timePtr returns a pointer to t
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
 // Close closes the database connection
 func (di *DatabaseInitializer) Close() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -48,7 +49,7 @@ func (m *MockAuthService) AddValidKey(key string, active bool) {
 }
 
 // ValidateAPIKey validates an API key (mock implementation)
-func (m *MockAuthService) ValidateAPIKey(key string) (*models.APIKey, error) {
+func (m *MockAuthService) ValidateAPIKey(ctx context.Context, key string) (*models.APIKey, error) {
 	atomic.AddInt64(&m.callCount, 1)
 
 	m.mu.RLock()
@@ -118,7 +119,7 @@ func (m *MockSolanaClient) SetError(shouldError bool, errorMsg string) {
 }
 
 // GetBalance returns the mock balance for an address
-func (m *MockSolanaClient) GetBalance(address string) (float64, error) {
+func (m *MockSolanaClient) GetBalance(ctx context.Context, address string) (float64, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -145,10 +146,10 @@ func (m *MockSolanaClient) GetBalance(address string) (float64, error) {
 }
 
 // GetBalances returns balances for multiple addresses
-func (m *MockSolanaClient) GetBalances(addresses []string) (map[string]float64, error) {
+func (m *MockSolanaClient) GetBalances(ctx context.Context, addresses []string) (map[string]float64, error) {
 	result := make(map[string]float64)
 	for _, addr := range addresses {
-		balance, err := m.GetBalance(addr)
+		balance, err := m.GetBalance(ctx, addr)
 		if err != nil {
 			return nil, err
 		}
@@ -270,7 +271,7 @@ func setupTestRoutes(engine *gin.Engine, server *TestServer) {
 		}
 
 		// Validate API key
-		_, err := server.authService.ValidateAPIKey(apiKey)
+		_, err := server.authService.ValidateAPIKey(c.Request.Context(), apiKey)
 		if err != nil {
 			var message string
 			switch err {
@@ -310,7 +311,7 @@ func setupTestRoutes(engine *gin.Engine, server *TestServer) {
 			}
 		}
 
-		response, err := server.balanceService.GetBalances(req.Wallets)
+		response, err := server.balanceService.GetBalances(c.Request.Context(), req.Wallets)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch balances"})
 			return
@@ -328,8 +329,8 @@ func TestSingleWalletBalanceRetrieval(t *testing.T) {
 			CleanupInterval: 1 * time.Minute,
 		},
 		RateLimit: config.RateLimitConfig{
-			RequestsPerMinute: 60,
-			WindowSize:        time.Minute,
+			UnitsPerWindow: 60,
+			WindowSize:     time.Minute,
 		},
 	}
 
@@ -448,8 +449,8 @@ func TestMultipleWalletBatchProcessing(t *testing.T) {
 			CleanupInterval: 1 * time.Minute,
 		},
 		RateLimit: config.RateLimitConfig{
-			RequestsPerMinute: 60,
-			WindowSize:        time.Minute,
+			UnitsPerWindow: 60,
+			WindowSize:     time.Minute,
 		},
 	}
 
@@ -585,8 +586,8 @@ func TestConcurrentRequestsWithSameWallet(t *testing.T) {
 			CleanupInterval: 1 * time.Minute,
 		},
 		RateLimit: config.RateLimitConfig{
-			RequestsPerMinute: 100, // Higher limit for concurrent testing
-			WindowSize:        time.Minute,
+			UnitsPerWindow: 100, // Higher limit for concurrent testing
+			WindowSize:     time.Minute,
 		},
 	}
 
@@ -718,8 +719,8 @@ func TestAuthenticationScenarios(t *testing.T) {
 			CleanupInterval: 1 * time.Minute,
 		},
 		RateLimit: config.RateLimitConfig{
-			RequestsPerMinute: 60,
-			WindowSize:        time.Minute,
+			UnitsPerWindow: 60,
+			WindowSize:     time.Minute,
 		},
 	}
 
@@ -994,8 +995,8 @@ func TestCacheTTLBehavior(t *testing.T) {
 			CleanupInterval: 1 * time.Minute,
 		},
 		RateLimit: config.RateLimitConfig{
-			RequestsPerMinute: 60,
-			WindowSize:        time.Minute,
+			UnitsPerWindow: 60,
+			WindowSize:     time.Minute,
 		},
 	}
 
@@ -3,19 +3,30 @@ package main
 import (
 	"context"
 	"crypto/tls"
+	"flag"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"solana-balance-api/internal/config"
 	"solana-balance-api/internal/handlers"
 	"solana-balance-api/internal/middleware"
+	"solana-balance-api/internal/models"
 	"solana-balance-api/internal/services"
+	"solana-balance-api/pkg/audit"
+	"solana-balance-api/pkg/clientip"
+	"solana-balance-api/pkg/ipfilter"
 	"solana-balance-api/pkg/logger"
+	"solana-balance-api/pkg/metrics"
 	"solana-balance-api/pkg/ratelimiter"
+	"solana-balance-api/pkg/secrets"
+	"solana-balance-api/pkg/tracing"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -26,21 +37,92 @@ type Server struct {
 	httpServer     *http.Server
 	config         *config.Config
 	authService    *services.AuthService
+	authenticator  services.AuthServiceInterface
+	jwtService     *services.JWTAuthService
 	solanaClient   *services.SolanaClient
 	balanceService *services.BalanceService
 	rateLimiter    *ratelimiter.RateLimiter
+	tenantLimiter  *ratelimiter.RateLimiter
+	organizations  *services.OrganizationService
+	usage          *services.UsageService
+	auditLogger    *audit.Logger
+	ipDenylist     *ipfilter.Denylist
+	tracer         *tracing.Provider
 	router         *handlers.Router
+
+	configFile   string         // Configuration file reloaded on SIGHUP and on change
+	loadedConfig *config.Config // Most recently loaded configuration, for reload diffs
+	reloadMutex  sync.Mutex
+	stopCh       chan struct{}
+	stopOnce     sync.Once
 }
 
 func main() {
-	// Load configuration
-	cfg := config.LoadConfig()
+	configFile := flag.String("config", os.Getenv(config.ConfigFileEnv), "Path to a YAML or JSON configuration file; environment variables override its values")
+	printConfig := flag.Bool("print-config", false, "Print the effective configuration with secrets redacted and exit")
+	generateKey := flag.Bool("generate-secrets-key", false, "Print a new random key for "+secrets.KeyEnv+" and exit")
+	encryptSecret := flag.Bool("encrypt-secret", false, "Encrypt a secret read from stdin with "+secrets.KeyEnv+" for the env-encrypted provider and exit")
+	flag.Parse()
+
+	if *generateKey || *encryptSecret {
+		if err := runSecretsCommand(*generateKey); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Load configuration; any invalid field is fatal
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *printConfig {
+		if err := cfg.Dump(os.Stdout, "yaml"); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to print configuration: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	// Initialize logging
 	loggerConfig := &logger.Config{
 		Level:       cfg.Logging.Level,
 		Environment: cfg.Logging.Environment,
 		OutputPaths: cfg.Logging.OutputPaths,
+		Redaction: logger.RedactionConfig{
+			Wallets: cfg.Logging.RedactWallets,
+			Fields:  cfg.Logging.RedactFields,
+		},
+		Sampling: logger.SamplingConfig{
+			Enabled:    cfg.Logging.Sampling.Enabled,
+			Tick:       cfg.Logging.Sampling.Tick,
+			Initial:    cfg.Logging.Sampling.Initial,
+			Thereafter: cfg.Logging.Sampling.Thereafter,
+			Messages:   cfg.Logging.Sampling.Messages,
+		},
+	}
+	for _, sink := range cfg.Logging.Sinks {
+		loggerConfig.Sinks = append(loggerConfig.Sinks, logger.SinkConfig{
+			Path:     sink.Path,
+			Level:    sink.Level,
+			Encoding: sink.Encoding,
+			Rotation: logger.RotationConfig{
+				MaxSizeMB:      sink.MaxSizeMB,
+				RotateInterval: sink.RotateInterval,
+				MaxAge:         sink.MaxAge,
+				MaxBackups:     sink.MaxBackups,
+				Compress:       sink.Compress,
+			},
+			Async: logger.AsyncConfig{
+				Enabled:       sink.Async,
+				BufferSize:    sink.BufferSize,
+				FlushInterval: sink.FlushInterval,
+				DropPolicy:    sink.DropPolicy,
+			},
+		})
 	}
 
 	if err := logger.Initialize(loggerConfig); err != nil {
@@ -51,12 +133,14 @@ func main() {
 	log := logger.GetLogger()
 
 	log.Info("Starting Solana Balance API server",
+		zap.String("config_file", *configFile),
 		zap.String("host", cfg.Server.Host),
 		zap.String("port", cfg.Server.Port),
-		zap.String("mongodb_uri", cfg.MongoDB.URI),
-		zap.String("rpc_endpoint", cfg.RPC.Endpoint),
+		zap.String("mongodb_uri", secrets.RedactURL(cfg.MongoDB.URI)),
+		zap.String("rpc_endpoint", secrets.RedactURL(cfg.RPC.Endpoint)),
 		zap.Duration("cache_ttl", cfg.Cache.TTL),
-		zap.Int("rate_limit_rpm", cfg.RateLimit.RequestsPerMinute),
+		zap.Int("rate_limit_units", cfg.RateLimit.UnitsPerWindow),
+		zap.Duration("rate_limit_window", cfg.RateLimit.WindowSize),
 		zap.String("log_level", cfg.Logging.Level),
 		zap.String("environment", cfg.Logging.Environment),
 	)
@@ -66,6 +150,7 @@ func main() {
 	if err != nil {
 		log.Fatal("Failed to create server", zap.Error(err))
 	}
+	server.configFile = *configFile
 
 	// Start server with graceful shutdown
 	if err := server.Start(); err != nil {
@@ -73,19 +158,100 @@ func main() {
 	}
 }
 
+// runSecretsCommand prints a new secrets key, or encrypts the secret on stdin
+// with the configured key for use as secret:env-encrypted:<VARIABLE>
+func runSecretsCommand(generateKey bool) error {
+	if generateKey {
+		key, err := secrets.GenerateKey()
+		if err != nil {
+			return err
+		}
+		fmt.Println(key)
+		return nil
+	}
+
+	key, err := secrets.DecodeKey(os.Getenv(secrets.KeyEnv))
+	if err != nil {
+		return fmt.Errorf("%s: %w", secrets.KeyEnv, err)
+	}
+	plaintext, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("failed to read secret from stdin: %w", err)
+	}
+	ciphertext, err := secrets.Encrypt(key, strings.TrimRight(string(plaintext), "\r\n"))
+	if err != nil {
+		return err
+	}
+	fmt.Println(ciphertext)
+	return nil
+}
+
 // NewServer creates a new server instance with all dependencies
 func NewServer(cfg *config.Config) (*Server, error) {
 	log := logger.GetLogger()
 
 	log.Info("Initializing server components")
 
+	// Error bodies link each code to its documentation
+	models.SetDocsBaseURL(cfg.Errors.DocsBaseURL)
+
+	// Initialize tracing first so every component's spans are exported
+	log.Debug("Initializing tracing", zap.Bool("enabled", cfg.Tracing.Enabled))
+	tracer, err := tracing.Setup(context.Background(), tracing.Config{
+		Enabled:     cfg.Tracing.Enabled,
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		URLPath:     cfg.Tracing.URLPath,
+		Insecure:    cfg.Tracing.Insecure,
+		Headers:     cfg.Tracing.Headers,
+		SampleRatio: cfg.Tracing.SampleRatio,
+		ServiceName: cfg.Tracing.ServiceName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize tracing: %w", err)
+	}
+
 	// Initialize authentication service
 	log.Debug("Initializing authentication service")
-	authService, err := services.NewAuthService(&cfg.MongoDB)
+	authService, err := services.NewAuthService(&cfg.MongoDB, &cfg.Auth)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize auth service: %w", err)
 	}
 
+	// Initialize JWT service and the authenticator for the configured mode
+	log.Debug("Initializing JWT service", zap.String("auth_mode", cfg.Auth.Mode))
+	jwtService, err := services.NewJWTAuthService(&cfg.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize JWT service: %w", err)
+	}
+	if cfg.Auth.SigningKeyFile == "" {
+		log.Warn("No token signing key configured, using an ephemeral key; issued tokens will not survive restarts")
+	}
+
+	// Initialize the audit log on the shared MongoDB connection
+	var auditLogger *audit.Logger
+	if cfg.Audit.Enabled {
+		log.Debug("Initializing audit log")
+		auditCfg := audit.Config{
+			BufferSize:    cfg.Audit.BufferSize,
+			BatchSize:     cfg.Audit.BatchSize,
+			FlushInterval: cfg.Audit.FlushInterval,
+			MaxRetries:    cfg.Audit.MaxRetries,
+			RetryBackoff:  cfg.Audit.RetryBackoff,
+			SpillFile:     cfg.Audit.SpillFile,
+		}
+		if !cfg.Audit.LogAuthSuccess {
+			auditCfg.SkipTypes = []string{audit.EventAuthSuccess}
+		}
+		auditLogger = audit.NewLogger(authService.Database().Collection(cfg.MongoDB.AuditCollection), auditCfg)
+		audit.SetDefault(auditLogger)
+	}
+
+	authenticator, err := services.NewMultiModeAuthService(cfg.Auth.Mode, authService, jwtService)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize authenticator: %w", err)
+	}
+
 	// Initialize Solana RPC client
 	log.Debug("Initializing Solana RPC client")
 	solanaClient := services.NewSolanaClient(&cfg.RPC)
@@ -104,7 +270,35 @@ func NewServer(cfg *config.Config) (*Server, error) {
 
 	// Initialize rate limiter
 	log.Debug("Initializing rate limiter")
-	rateLimiter := ratelimiter.New(cfg.RateLimit.RequestsPerMinute, cfg.RateLimit.WindowSize)
+	rateCosts := ratelimiter.Costs{
+		Request: cfg.RateLimit.RequestCost,
+		Wallet:  cfg.RateLimit.WalletCost,
+		RPCMiss: cfg.RateLimit.RPCMissCost,
+	}
+	rateLimiter := ratelimiter.NewWithCosts(cfg.RateLimit.UnitsPerWindow, cfg.RateLimit.WindowSize, rateCosts)
+
+	// Initialize organizations and the per-tenant rate limiter (limits come from rate tiers)
+	log.Debug("Initializing organization service")
+	organizations := services.NewOrganizationService(authService.Database(), &cfg.MongoDB, &cfg.Tenant)
+	tenantLimiter := ratelimiter.NewWithCosts(organizations.TierLimit(nil), cfg.RateLimit.WindowSize, rateCosts)
+
+	// Audit clients locked out by either limiter
+	rateLimiter.OnExceeded(middleware.AuditRateLimitExceeded("ip"))
+	tenantLimiter.OnExceeded(middleware.AuditRateLimitExceeded("tenant"))
+	rateLimiter.OnReject(middleware.RateLimitRejected)
+	tenantLimiter.OnReject(middleware.RateLimitRejected)
+
+	// Initialize per-key usage metering
+	log.Debug("Initializing usage service")
+	usage := services.NewUsageService(authService.Database(), &cfg.MongoDB, &cfg.Usage)
+
+	// Initialize global IP denylist
+	log.Debug("Initializing IP denylist")
+	ipDenylist, err := ipfilter.NewDenylist(cfg.IPFilter.DeniedCIDRs, cfg.IPFilter.DenylistFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize IP denylist: %w", err)
+	}
+	log.Info("IP denylist loaded", zap.Int("networks", ipDenylist.Size()))
 
 	// Initialize database health checker
 	log.Debug("Initializing database health checker")
@@ -119,17 +313,32 @@ func NewServer(cfg *config.Config) (*Server, error) {
 
 	// Initialize router
 	log.Debug("Initializing router")
-	router := handlers.NewRouter(balanceService, healthHandler)
+	oauthHandler := handlers.NewOAuthHandler(authService, jwtService)
+	adminHandler := handlers.NewAdminHandler(authService, organizations)
+	usageHandler := handlers.NewUsageHandler(usage)
+	router := handlers.NewRouter(balanceService, healthHandler, oauthHandler, adminHandler, usageHandler)
+	router.GetBalanceHandler().SetLimits(requestLimits(cfg))
+	router.GetBalanceHandler().SetPublicCache(cfg.Cache.PublicResponses)
 
 	log.Info("Server components initialized successfully")
 
 	return &Server{
 		config:         cfg,
 		authService:    authService,
+		authenticator:  authenticator,
+		jwtService:     jwtService,
 		solanaClient:   solanaClient,
 		balanceService: balanceService,
 		rateLimiter:    rateLimiter,
+		tenantLimiter:  tenantLimiter,
+		organizations:  organizations,
+		usage:          usage,
+		auditLogger:    auditLogger,
+		ipDenylist:     ipDenylist,
+		tracer:         tracer,
 		router:         router,
+		loadedConfig:   cfg,
+		stopCh:         make(chan struct{}),
 	}, nil
 }
 
@@ -147,6 +356,11 @@ func (s *Server) Start() error {
 	// Create Gin engine
 	engine := gin.New()
 
+	// Configure trusted proxies so c.ClientIP() cannot be spoofed by clients
+	if err := clientip.Configure(engine, s.clientIPConfig()); err != nil {
+		return fmt.Errorf("invalid trusted proxy configuration: %w", err)
+	}
+
 	// Setup middleware stack
 	s.setupMiddleware(engine)
 
@@ -174,13 +388,27 @@ func (s *Server) Start() error {
 		zap.Duration("idle_timeout", s.config.Server.IdleTimeout),
 	)
 
+	// Open listener (with PROXY protocol support when configured)
+	listener, err := clientip.Listen(s.httpServer.Addr, s.clientIPConfig())
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.httpServer.Addr, err)
+	}
+
+	log.Info("Client IP resolution configured",
+		zap.Strings("trusted_proxies", s.config.Server.TrustedProxies),
+		zap.String("client_ip_header", s.config.Server.ClientIPHeader),
+	)
+
 	// Start cleanup routines
 	s.startCleanupRoutines()
 
+	// Reload configuration on SIGHUP and when the configuration file changes
+	go s.watchConfig(s.stopCh)
+
 	// Start server in a goroutine
 	go func() {
 		log.Info("Starting HTTP server", zap.String("address", s.httpServer.Addr))
-		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := s.httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
 			log.Fatal("Server failed to start", zap.Error(err))
 		}
 	}()
@@ -189,6 +417,14 @@ func (s *Server) Start() error {
 	return s.waitForShutdown()
 }
 
+// clientIPConfig returns the trusted proxy configuration for client IP resolution
+func (s *Server) clientIPConfig() clientip.Config {
+	return clientip.Config{
+		TrustedProxies: s.config.Server.TrustedProxies,
+		Header:         s.config.Server.ClientIPHeader,
+	}
+}
+
 // setupMiddleware configures the middleware stack
 func (s *Server) setupMiddleware(engine *gin.Engine) {
 	log := logger.GetLogger()
@@ -198,9 +434,15 @@ func (s *Server) setupMiddleware(engine *gin.Engine) {
 	// Recovery middleware with structured logging (should be first)
 	engine.Use(logger.RecoveryMiddleware())
 
+	// Request tracing (before logging so request logs carry the trace ID)
+	engine.Use(tracing.Middleware())
+
 	// Structured logging middleware with correlation IDs
 	engine.Use(logger.LoggingMiddleware())
 
+	// Global IP denylist (before any other work is done for the request)
+	engine.Use(middleware.IPDenylistMiddleware(s.ipDenylist))
+
 	// Performance monitoring middleware stack
 	engine.Use(middleware.PerformanceMiddleware(s.balanceService.GetMetricsCollector()))
 	engine.Use(middleware.RequestSizeMiddleware())
@@ -223,12 +465,43 @@ func (s *Server) setupRoutes(engine *gin.Engine) {
 	// Health check routes (no authentication required)
 	s.router.SetupHealthRoutes(engine)
 
-	// API routes with authentication
-	api := engine.Group("/api")
-	api.Use(middleware.AuthMiddleware(s.authService))
+	// OAuth2 client-credentials token endpoint and JWKS
+	s.router.SetupOAuthRoutes(engine)
+
+	// Authentication, metering and tenant limits of every API route
+	authenticated := []gin.HandlerFunc{
+		middleware.AuthMiddleware(s.authenticator),
+		middleware.KeyExpiryWarningMiddleware(s.config.Auth.KeyExpiryWarningDays),
+		middleware.TenantMiddleware(s.organizations, s.tenantLimiter),
+		s.tenantLimiter.KeyedMiddleware(middleware.TenantRateLimitKey),
+		middleware.UsageMiddleware(s.usage),
+	}
+
+	// Versioned API routes; the version is checked before authentication
+	v1 := engine.Group("/api/v1", middleware.APIVersionMiddleware(models.APIVersion1))
+	s.router.SetupErrorRoutes(v1) // No authentication required
+	v1 = v1.Group("", authenticated...)
 	{
-		// Balance endpoints
-		api.POST("/get-balance", s.router.GetBalanceHandler().GetBalance)
+		balances := v1.Group("", middleware.RequireScope(models.ScopeBalanceRead))
+		balances.POST("/balances", s.router.GetBalanceHandler().GetBalance)
+		balances.GET("/balances", s.router.GetBalanceHandler().ListBalances)
+		balances.GET("/wallets/:address/balance", s.router.GetBalanceHandler().GetWalletBalance)
+
+		s.setupAccountRoutes(v1)
+	}
+
+	// Unversioned legacy aliases of the v1 routes, deprecated until their sunset
+	deprecatedAt, sunset, _ := s.config.API.LegacyDates() // Validated at startup
+	legacy := engine.Group("/api",
+		middleware.APIVersionMiddleware(models.APIVersions...),
+		middleware.LegacyRouteMiddleware(deprecatedAt, sunset),
+	)
+	s.router.SetupErrorRoutes(legacy)
+	legacy = legacy.Group("", authenticated...)
+	{
+		legacy.POST("/get-balance", middleware.RequireScope(models.ScopeBalanceRead), s.router.GetBalanceHandler().GetBalance)
+
+		s.setupAccountRoutes(legacy)
 	}
 
 	// Additional monitoring endpoints
@@ -236,12 +509,29 @@ func (s *Server) setupRoutes(engine *gin.Engine) {
 	engine.GET("/status", s.statusHandler)
 }
 
+// setupAccountRoutes configures the usage and admin routes of an API version
+func (s *Server) setupAccountRoutes(api *gin.RouterGroup) {
+	// Usage of the calling key
+	api.GET("/usage", s.router.GetUsageHandler().GetUsage)
+
+	// Admin endpoints
+	admin := api.Group("/admin", middleware.AdminAuditMiddleware(), middleware.RequireScope(models.ScopeAdmin))
+	admin.POST("/keys/:id/rotate", s.router.GetAdminHandler().RotateAPIKey)
+	admin.GET("/organizations/:tenant_id", s.router.GetAdminHandler().GetOrganization)
+	admin.GET("/usage", s.router.GetUsageHandler().GetAllUsage)
+	admin.GET("/log-level", s.router.GetAdminHandler().GetLogLevel)
+	admin.PUT("/log-level", s.router.GetAdminHandler().SetLogLevel)
+	admin.PUT("/log-level/:component", s.router.GetAdminHandler().SetLogLevel)
+	admin.DELETE("/log-level/:component", s.router.GetAdminHandler().ResetLogLevel)
+}
+
 // corsMiddleware adds CORS headers
 func (s *Server) corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization, X-Correlation-ID, X-Request-ID, traceparent, tracestate, If-None-Match, If-Modified-Since, API-Version")
+		c.Header("Access-Control-Expose-Headers", "X-Correlation-ID, X-Request-ID, ETag, API-Version, Deprecation, Sunset, Link, Warning, X-API-Key-Expires-At, X-API-Key-Secret-Expires-At")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -252,14 +542,43 @@ func (s *Server) corsMiddleware() gin.HandlerFunc {
 	}
 }
 
-// metricsHandler provides comprehensive metrics endpoint
+// metricsHandler provides comprehensive metrics endpoint. The Prometheus text and
+// OpenMetrics formats are selected with the Accept header or ?format=; JSON is the default.
 func (s *Server) metricsHandler(c *gin.Context) {
+	c.Header("Vary", "Accept")
+
+	format := metrics.NegotiateFormat(c.GetHeader("Accept"))
+	if name := c.Query("format"); name != "" {
+		if parsed, ok := metrics.ParseFormat(name); ok {
+			format = parsed
+		}
+	}
+
+	if format != metrics.FormatJSON {
+		c.Status(http.StatusOK)
+		c.Header("Content-Type", format.ContentType())
+		if err := s.balanceService.GetMetricsCollector().WriteExposition(c.Writer, format); err != nil {
+			logger.GetLogger().Warn("Failed to write metrics exposition", zap.Error(err))
+		}
+		return
+	}
+
 	performanceStats := s.balanceService.GetPerformanceStats()
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"service":     "solana-balance-api",
 		"version":     "1.0.0",
 		"performance": performanceStats,
-	})
+		"auth":        s.authService.GetCacheStats(),
+		"logging": gin.H{
+			"level":           logger.Level(),
+			"sampled_dropped": logger.SampledDropped(),
+			"async_dropped":   logger.AsyncDropped(),
+		},
+	}
+	if s.auditLogger != nil {
+		response["audit"] = s.auditLogger.GetStats()
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // statusHandler provides detailed status information
@@ -271,11 +590,12 @@ func (s *Server) statusHandler(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"service":     "solana-balance-api",
-		"status":      "running",
-		"rpc_healthy": rpcHealthy,
-		"uptime":      time.Since(startTime).String(),
-		"version":     "1.0.0",
+		"service":      "solana-balance-api",
+		"status":       "running",
+		"rpc_healthy":  rpcHealthy,
+		"rpc_endpoint": secrets.RedactURL(s.solanaClient.Endpoint()),
+		"uptime":       time.Since(startTime).String(),
+		"version":      "1.0.0",
 	})
 }
 
@@ -294,9 +614,20 @@ func (s *Server) startCleanupRoutines() {
 
 		for range ticker.C {
 			s.rateLimiter.Cleanup()
+			s.tenantLimiter.Cleanup()
 		}
 	}()
 
+	// JWKS refresh for externally issued tokens
+	go s.jwtService.KeySet().StartRefresh(s.config.Auth.JWKSRefreshInterval, s.stopCh, func(err error) {
+		log.Error("Failed to refresh JWKS, keeping previous keys", zap.Error(err))
+	})
+
+	// IP denylist file reload
+	go s.ipDenylist.Watch(s.config.IPFilter.ReloadInterval, s.stopCh, func(err error) {
+		log.Error("Failed to reload IP denylist, keeping previous list", zap.Error(err))
+	})
+
 	log.Info("Background cleanup routines started")
 }
 
@@ -337,12 +668,44 @@ func (s *Server) cleanup() {
 
 	log.Info("Cleaning up services...")
 
+	// Stop configuration, denylist and JWKS reloads
+	s.stopOnce.Do(func() {
+		if s.stopCh != nil {
+			close(s.stopCh)
+		}
+	})
+
 	// Stop balance service
 	if s.balanceService != nil {
 		log.Debug("Stopping balance service")
 		s.balanceService.Stop()
 	}
 
+	// Flush organization usage while the shared MongoDB connection is still open
+	if s.organizations != nil {
+		log.Debug("Closing organization service")
+		if err := s.organizations.Close(); err != nil {
+			log.Error("Error flushing organization usage", zap.Error(err))
+		}
+	}
+
+	// Flush per-key usage buckets
+	if s.usage != nil {
+		log.Debug("Closing usage service")
+		if err := s.usage.Close(); err != nil {
+			log.Error("Error flushing API key usage", zap.Error(err))
+		}
+	}
+
+	// Append queued audit events
+	if s.auditLogger != nil {
+		log.Debug("Closing audit log")
+		audit.SetDefault(nil)
+		if err := s.auditLogger.Close(); err != nil {
+			log.Error("Error closing audit log", zap.Error(err))
+		}
+	}
+
 	// Close auth service (MongoDB connection)
 	if s.authService != nil {
 		log.Debug("Closing auth service")
@@ -351,13 +714,23 @@ func (s *Server) cleanup() {
 		}
 	}
 
-	// Sync logger before exit
-	if err := logger.GetLogger().Sync(); err != nil {
-		// Don't log this error as logger might be closed
-		fmt.Printf("Error syncing logger: %v\n", err)
+	// Export buffered spans
+	if s.tracer != nil {
+		log.Debug("Shutting down tracing")
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := s.tracer.Shutdown(ctx); err != nil {
+			log.Error("Error shutting down tracing", zap.Error(err))
+		}
+		cancel()
 	}
 
 	log.Info("Cleanup completed")
+
+	// Flush buffered log entries and close log files before exit
+	if err := logger.GetLogger().Close(); err != nil {
+		// Don't log this error as logger might be closed
+		fmt.Printf("Error closing logger: %v\n", err)
+	}
 }
 
 // Global variable to track server start time for uptime calculation
@@ -22,11 +22,12 @@ import (
 // TestPerformanceOptimizations tests all performance optimizations
 func TestPerformanceOptimizations(t *testing.T) {
 	// Load test configuration
-	cfg := config.LoadConfig()
+	cfg, err := config.LoadConfig()
+	require.NoError(t, err)
 
 	// Override with test values
 	cfg.Cache.TTL = 2 * time.Second
-	cfg.RateLimit.RequestsPerMinute = 100 // Higher limit for testing
+	cfg.RateLimit.UnitsPerWindow = 100 // Higher limit for testing
 
 	// Create test server
 	server, err := NewServer(cfg)
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"solana-balance-api/internal/config"
+	"solana-balance-api/internal/models"
+	"solana-balance-api/pkg/logger"
+	"solana-balance-api/pkg/ratelimiter"
+
+	"go.uber.org/zap"
+)
+
+// reloadableFields are the configuration fields applied to the running server on
+// reload; changes to any other field are logged and take effect after a restart
+var reloadableFields = map[string]bool{
+	"logging.level":               true,
+	"rate_limit.units_per_window": true,
+	"rate_limit.window_size":      true,
+	"rate_limit.request_cost":     true,
+	"rate_limit.wallet_cost":      true,
+	"rate_limit.rpc_miss_cost":    true,
+	"cache.ttl":                   true,
+	"rpc.endpoint":                true,
+	"limits.max_body_bytes":       true,
+	"limits.max_wallets":          true,
+}
+
+// watchConfig reloads the configuration on SIGHUP and, when a configuration file
+// is in use, whenever its modification time changes
+func (s *Server) watchConfig(stopCh <-chan struct{}) {
+	log := logger.GetLogger()
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	var modTime time.Time
+	var tick <-chan time.Time
+	if s.configFile != "" && s.config.Reload.Interval > 0 {
+		if info, err := os.Stat(s.configFile); err == nil {
+			modTime = info.ModTime()
+		}
+		ticker := time.NewTicker(s.config.Reload.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+
+		log.Debug("Watching configuration file",
+			zap.String("file", s.configFile),
+			zap.Duration("interval", s.config.Reload.Interval),
+		)
+	}
+
+	for {
+		select {
+		case <-hangup:
+			s.reloadConfig("SIGHUP")
+		case <-tick:
+			info, err := os.Stat(s.configFile)
+			if err != nil {
+				log.Error("Failed to check configuration file", zap.Error(err))
+				continue
+			}
+			if !info.ModTime().Equal(modTime) {
+				modTime = info.ModTime()
+				s.reloadConfig("file change")
+			}
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// reloadConfig loads and validates the configuration again and applies the
+// reloadable fields. An invalid configuration is rejected as a whole and the
+// running configuration is kept.
+func (s *Server) reloadConfig(trigger string) {
+	log := logger.GetLogger()
+
+	next, err := config.Load(s.configFile)
+	if err != nil {
+		log.Error("Failed to reload configuration, keeping current configuration",
+			zap.String("trigger", trigger),
+			zap.Error(err),
+		)
+		return
+	}
+
+	s.applyConfig(next, trigger)
+}
+
+// applyConfig applies the reloadable fields of next to the running components and
+// logs changed fields that require a restart
+func (s *Server) applyConfig(next *config.Config, trigger string) {
+	log := logger.GetLogger()
+
+	s.reloadMutex.Lock()
+	defer s.reloadMutex.Unlock()
+
+	var applied, restart []string
+	for _, field := range config.Changes(s.loadedConfig, next) {
+		if reloadableFields[field] {
+			applied = append(applied, field)
+		} else {
+			restart = append(restart, field)
+		}
+	}
+
+	if len(applied) == 0 && len(restart) == 0 {
+		log.Info("Configuration reloaded, no changes", zap.String("trigger", trigger))
+		return
+	}
+
+	for _, field := range applied {
+		switch field {
+		case "logging.level":
+			if err := logger.SetLevel(next.Logging.Level); err != nil {
+				log.Error("Failed to apply log level", zap.Error(err))
+			}
+		case "cache.ttl":
+			s.balanceService.SetCacheTTL(next.Cache.TTL)
+		case "rpc.endpoint":
+			s.solanaClient.SetEndpoint(next.RPC.Endpoint)
+		}
+	}
+	if changedUnder(applied, "limits.") {
+		s.router.GetBalanceHandler().SetLimits(requestLimits(next))
+	}
+	if changedUnder(applied, "rate_limit.") {
+		costs := ratelimiter.Costs{
+			Request: next.RateLimit.RequestCost,
+			Wallet:  next.RateLimit.WalletCost,
+			RPCMiss: next.RateLimit.RPCMissCost,
+		}
+		s.rateLimiter.Configure(next.RateLimit.UnitsPerWindow, next.RateLimit.WindowSize, costs)
+		// Tenant limits come from rate tiers; only the window and costs follow the config
+		s.tenantLimiter.Configure(0, next.RateLimit.WindowSize, costs)
+	}
+
+	s.loadedConfig = next
+
+	log.Info("Configuration reloaded",
+		zap.String("trigger", trigger),
+		zap.Strings("applied", applied),
+	)
+	if len(restart) > 0 {
+		log.Warn("Changed configuration fields require a restart to take effect",
+			zap.Strings("fields", restart),
+		)
+	}
+}
+
+// changedUnder reports whether any field in fields lives under prefix
+func changedUnder(fields []string, prefix string) bool {
+	for _, field := range fields {
+		if strings.HasPrefix(field, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestLimits returns the configured default balance request limits
+func requestLimits(cfg *config.Config) models.RequestLimits {
+	return models.RequestLimits{
+		MaxBodyBytes: cfg.Limits.MaxBodyBytes,
+		MaxWallets:   cfg.Limits.MaxWallets,
+	}
+}
@@ -13,10 +13,13 @@ import (
 
 func AuthUsageExample() {
 	// Load configuration
-	cfg := config.LoadConfig()
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
 
 	// Initialize authentication service
-	authService, err := services.NewAuthService(&cfg.MongoDB)
+	authService, err := services.NewAuthService(&cfg.MongoDB, &cfg.Auth)
 	if err != nil {
 		log.Fatalf("Failed to initialize auth service: %v", err)
 	}
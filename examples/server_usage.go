@@ -211,7 +211,7 @@ func runServerExample() {
 	fmt.Println("  MONGODB_URI=mongodb://localhost:27017")
 	fmt.Println("  MONGODB_DATABASE=solana_api")
 	fmt.Println("  SOLANA_RPC_ENDPOINT=https://your-helius-endpoint")
-	fmt.Println("  RATE_LIMIT_REQUESTS_PER_MINUTE=10")
+	fmt.Println("  RATE_LIMIT_UNITS_PER_WINDOW=100")
 	fmt.Println("  CACHE_TTL=10s")
 
 	// Example of setting environment variables programmatically
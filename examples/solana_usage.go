@@ -1,6 +1,7 @@
 package examples
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"time"
@@ -11,7 +12,12 @@ import (
 
 func SolanaUsageExample() {
 	// Load configuration
-	cfg := config.LoadConfig()
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	ctx := context.Background()
 
 	// Create Solana client
 	solanaClient := services.NewSolanaClient(&cfg.RPC)
@@ -33,7 +39,7 @@ func SolanaUsageExample() {
 
 	// Get single balance
 	fmt.Printf("\nGetting balance for single address: %s\n", addresses[0])
-	balance, err := solanaClient.GetBalance(addresses[0])
+	balance, err := solanaClient.GetBalance(ctx, addresses[0])
 	if err != nil {
 		log.Printf("Error getting balance: %v", err)
 	} else {
@@ -42,11 +48,16 @@ func SolanaUsageExample() {
 
 	// Get multiple balances
 	fmt.Printf("\nGetting balances for multiple addresses...\n")
-	balances, err := solanaClient.GetBalances(addresses)
+	balances, err := solanaClient.GetBalances(ctx, addresses)
 	if err != nil {
 		log.Printf("Error getting balances: %v", err)
 	} else {
-		for address, balance := range balances {
+		for _, address := range addresses {
+			balance, found := balances[address]
+			if !found {
+				fmt.Printf("Address: %s, account not found\n", address)
+				continue
+			}
 			fmt.Printf("Address: %s, Balance: %.9f SOL\n", address, balance)
 		}
 	}
@@ -60,7 +71,7 @@ func SolanaUsageExample() {
 	}
 
 	shortTimeoutClient := services.NewSolanaClient(shortTimeoutConfig)
-	_, err = shortTimeoutClient.GetBalance(addresses[0])
+	_, err = shortTimeoutClient.GetBalance(ctx, addresses[0])
 	if err != nil {
 		fmt.Printf("Expected timeout error: %v\n", err)
 	}
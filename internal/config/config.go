@@ -1,9 +1,12 @@
 package config
 
 import (
+	"fmt"
 	"os"
-	"strconv"
+	"strings"
 	"time"
+
+	"solana-balance-api/pkg/secrets"
 )
 
 // Config holds all configuration for the application
@@ -14,6 +17,16 @@ type Config struct {
 	Cache     CacheConfig     `json:"cache"`
 	RateLimit RateLimitConfig `json:"rate_limit"`
 	Logging   LoggingConfig   `json:"logging"`
+	IPFilter  IPFilterConfig  `json:"ip_filter"`
+	Auth      AuthConfig      `json:"auth"`
+	Tenant    TenantConfig    `json:"tenant"`
+	Usage     UsageConfig     `json:"usage"`
+	Audit     AuditConfig     `json:"audit"`
+	Tracing   TracingConfig   `json:"tracing"`
+	Reload    ReloadConfig    `json:"reload"`
+	Errors    ErrorsConfig    `json:"errors"`
+	Limits    LimitsConfig    `json:"limits"`
+	API       APIConfig       `json:"api"`
 }
 
 // ServerConfig holds HTTP server configuration
@@ -23,6 +36,12 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration `json:"read_timeout"`
 	WriteTimeout time.Duration `json:"write_timeout"`
 	IdleTimeout  time.Duration `json:"idle_timeout"`
+
+	// Trusted reverse proxies (CIDRs or IPs) and the header they use to forward the client IP.
+	// ClientIPHeader is one of X-Forwarded-For, X-Real-IP, CF-Connecting-IP or PROXY
+	// (PROXY protocol v1/v2). Empty means the TCP peer address is always used.
+	TrustedProxies []string `json:"trusted_proxies"`
+	ClientIPHeader string   `json:"client_ip_header"`
 }
 
 // MongoDBConfig holds MongoDB connection configuration
@@ -32,6 +51,11 @@ type MongoDBConfig struct {
 	APIKeyCollection string        `json:"api_key_collection"`
 	ConnectTimeout   time.Duration `json:"connect_timeout"`
 	MaxPoolSize      uint64        `json:"max_pool_size"`
+
+	OrganizationCollection      string `json:"organization_collection"`
+	OrganizationUsageCollection string `json:"organization_usage_collection"`
+	UsageCollection             string `json:"usage_collection"`
+	AuditCollection             string `json:"audit_collection"`
 }
 
 // RPCConfig holds Solana RPC configuration
@@ -49,104 +73,405 @@ type CacheConfig struct {
 	TTL             time.Duration `json:"ttl"`
 	CleanupInterval time.Duration `json:"cleanup_interval"`
 	MaxSize         int           `json:"max_size"`
+
+	// PublicResponses lets shared caches (CDNs, proxies) store balance responses.
+	// Off by default: responses are sent as private because the routes are
+	// authenticated.
+	PublicResponses bool `json:"public_responses"`
 }
 
 // RateLimitConfig holds rate limiting configuration
 type RateLimitConfig struct {
-	RequestsPerMinute int           `json:"requests_per_minute"`
-	WindowSize        time.Duration `json:"window_size"`
-	CleanupInterval   time.Duration `json:"cleanup_interval"`
+	WindowSize      time.Duration `json:"window_size"`
+	CleanupInterval time.Duration `json:"cleanup_interval"`
+
+	// Cost-weighted limiting: each client gets UnitsPerWindow units per window
+	UnitsPerWindow int `json:"units_per_window"`
+	RequestCost    int `json:"request_cost"`
+	WalletCost     int `json:"wallet_cost"`
+	RPCMissCost    int `json:"rpc_miss_cost"`
+}
+
+// IPFilterConfig holds the global IP denylist configuration.
+// The denylist file (one CIDR per line) is reloaded when it changes.
+type IPFilterConfig struct {
+	DeniedCIDRs    []string      `json:"denied_cidrs"`
+	DenylistFile   string        `json:"denylist_file"`
+	ReloadInterval time.Duration `json:"reload_interval"`
+}
+
+// AuthConfig holds authentication mode and JWT configuration
+type AuthConfig struct {
+	// Mode is one of "api_key", "jwt" or "hybrid"
+	Mode string `json:"mode"`
+
+	// External issuer whose tokens are verified with keys from JWKSURL or JWKSFile
+	JWTIssuer           string        `json:"jwt_issuer"`
+	Audience            string        `json:"audience"`
+	JWKSURL             string        `json:"jwks_url"`
+	JWKSFile            string        `json:"jwks_file"`
+	JWKSRefreshInterval time.Duration `json:"jwks_refresh_interval"`
+	ScopeClaim          string        `json:"scope_claim"`
+	TenantClaim         string        `json:"tenant_claim"`
+	ClockSkew           time.Duration `json:"clock_skew"`
+
+	// Local client-credentials token endpoint (/oauth/token)
+	TokenIssuer    string        `json:"token_issuer"`
+	TokenTTL       time.Duration `json:"token_ttl"`
+	SigningKeyFile string        `json:"signing_key_file"`
+
+	// API key validation cache and batched last_used writes
+	KeyCacheTTL           time.Duration `json:"key_cache_ttl"`
+	KeyCacheNegativeTTL   time.Duration `json:"key_cache_negative_ttl"`
+	KeyCacheMaxSize       int           `json:"key_cache_max_size"`
+	KeyCachePollInterval  time.Duration `json:"key_cache_poll_interval"`
+	LastUsedFlushInterval time.Duration `json:"last_used_flush_interval"`
+
+	// API key lifecycle: expiry warnings, rotation grace window and auto-deactivation
+	KeyExpiryWarningDays   int           `json:"key_expiry_warning_days"`
+	KeyRotationGracePeriod time.Duration `json:"key_rotation_grace_period"`
+	KeyExpirySweepInterval time.Duration `json:"key_expiry_sweep_interval"`
+}
+
+// TenantConfig holds multi-tenant organization configuration
+type TenantConfig struct {
+	CacheTTL           time.Duration  `json:"cache_ttl"`
+	UsageFlushInterval time.Duration  `json:"usage_flush_interval"`
+	RateTiers          map[string]int `json:"rate_tiers"` // Rate limit units per window by tier
+	DefaultTier        string         `json:"default_tier"`
+}
+
+// UsageConfig holds per-key usage metering configuration
+type UsageConfig struct {
+	FlushInterval time.Duration `json:"flush_interval"`
+	MaxQueryRange time.Duration `json:"max_query_range"`
+}
+
+// AuditConfig holds audit log configuration
+type AuditConfig struct {
+	Enabled        bool          `json:"enabled"`
+	LogAuthSuccess bool          `json:"log_auth_success"` // Audit every successful authentication
+	BufferSize     int           `json:"buffer_size"`
+	BatchSize      int           `json:"batch_size"`
+	FlushInterval  time.Duration `json:"flush_interval"`
+	MaxRetries     int           `json:"max_retries"`   // Retries of a failed batch
+	RetryBackoff   time.Duration `json:"retry_backoff"` // Delay before the first retry, doubled per retry
+	SpillFile      string        `json:"spill_file"`    // Batches that still fail are appended here and replayed
+}
+
+// TracingConfig holds OpenTelemetry tracing configuration
+type TracingConfig struct {
+	Enabled     bool              `json:"enabled"`
+	Exporter    string            `json:"exporter"`     // "otlp", "memory" or "none"
+	Endpoint    string            `json:"endpoint"`     // OTLP/HTTP collector host:port
+	URLPath     string            `json:"url_path"`     // OTLP/HTTP traces path
+	Insecure    bool              `json:"insecure"`     // Plain HTTP to the collector
+	Headers     map[string]string `json:"headers"`      // Collector headers; may hold credentials
+	SampleRatio float64           `json:"sample_ratio"` // Fraction of new traces sampled
+	ServiceName string            `json:"service_name"`
+}
+
+// ReloadConfig holds live configuration reload settings. The configuration file is
+// polled for changes every Interval and re-read on SIGHUP; zero disables polling.
+type ReloadConfig struct {
+	Interval time.Duration `json:"interval"`
+}
+
+// ErrorsConfig holds error response settings. Error codes link to DocsBaseURL plus
+// the code; empty links to the built-in catalog at /api/v1/errors.
+type ErrorsConfig struct {
+	DocsBaseURL string `json:"docs_base_url"`
+}
+
+// LimitsConfig holds the default balance request limits; API keys may override them
+type LimitsConfig struct {
+	MaxBodyBytes int64 `json:"max_body_bytes"`
+	MaxWallets   int   `json:"max_wallets"`
+}
+
+// APIConfig holds API versioning settings. Unversioned legacy paths are announced
+// as deprecated from LegacyDeprecatedAt and stop working at LegacySunset, both
+// RFC 3339 timestamps.
+type APIConfig struct {
+	LegacyDeprecatedAt string `json:"legacy_deprecated_at"`
+	LegacySunset       string `json:"legacy_sunset"`
+}
+
+// LegacyDates parses the deprecation and sunset dates of the legacy paths
+func (c APIConfig) LegacyDates() (deprecatedAt, sunset time.Time, err error) {
+	if deprecatedAt, err = time.Parse(time.RFC3339, c.LegacyDeprecatedAt); err != nil {
+		return deprecatedAt, sunset, fmt.Errorf("legacy_deprecated_at: %w", err)
+	}
+	if sunset, err = time.Parse(time.RFC3339, c.LegacySunset); err != nil {
+		return deprecatedAt, sunset, fmt.Errorf("legacy_sunset: %w", err)
+	}
+	return deprecatedAt, sunset, nil
 }
 
 // LoggingConfig holds logging configuration
 type LoggingConfig struct {
-	Level       string   `json:"level"`
-	Environment string   `json:"environment"`
-	OutputPaths []string `json:"output_paths"`
+	Level         string            `json:"level"`
+	Environment   string            `json:"environment"`
+	OutputPaths   []string          `json:"output_paths"`
+	RedactWallets string            `json:"redact_wallets"` // hash, truncate or none
+	RedactFields  map[string]string `json:"redact_fields"`  // Extra field rules: field name -> action
+	Sampling      LogSamplingConfig `json:"sampling"`
+	Sinks         []LogSinkConfig   `json:"sinks"` // Replace output_paths when set
+}
+
+// LogSinkConfig holds the configuration of one log output
+type LogSinkConfig struct {
+	Path           string        `json:"path"`            // stdout, stderr or a file path
+	Level          string        `json:"level"`           // Minimum level; empty follows logging.level
+	Encoding       string        `json:"encoding"`        // json or console; empty follows the environment
+	MaxSizeMB      int           `json:"max_size_mb"`     // Rotate files at this size
+	RotateInterval time.Duration `json:"rotate_interval"` // Also rotate files this old; 0 disables
+	MaxAge         time.Duration `json:"max_age"`         // Delete rotated files older than this; 0 keeps them
+	MaxBackups     int           `json:"max_backups"`     // Rotated files kept; 0 keeps all
+	Compress       bool          `json:"compress"`
+	Async          bool          `json:"async"`
+	BufferSize     int           `json:"buffer_size"` // Entries buffered by the async writer
+	FlushInterval  time.Duration `json:"flush_interval"`
+	DropPolicy     string        `json:"drop_policy"` // drop_newest, drop_oldest or block
+}
+
+// LogSamplingConfig holds per-message log sampling configuration
+type LogSamplingConfig struct {
+	Enabled    bool          `json:"enabled"`
+	Tick       time.Duration `json:"tick"`
+	Initial    int           `json:"initial"`    // Entries logged per message and tick before sampling
+	Thereafter int           `json:"thereafter"` // Then every Nth entry is logged
+	Messages   []string      `json:"messages"`   // Messages to sample; empty samples all messages
+}
+
+// ConfigFileEnv names the environment variable holding the configuration file path
+const ConfigFileEnv = "CONFIG_FILE"
+
+// DefaultRPCEndpoint is the public Solana mainnet endpoint used when none is
+// configured. It is rate limited and not accepted in production.
+const DefaultRPCEndpoint = "https://api.mainnet-beta.solana.com"
+
+// LoadConfig loads configuration from the file named by CONFIG_FILE, if set, with
+// environment variable overrides and defaults
+func LoadConfig() (*Config, error) {
+	return Load(os.Getenv(ConfigFileEnv))
+}
+
+// Load loads configuration from an optional YAML or JSON file. Each value comes from
+// its environment variable if set, then the file, then the default. Unparseable
+// values, unknown file keys and failed validation rules are all reported together
+// in a *ValidationError. Secret references are resolved with the file provider and,
+// when SECRETS_KEY or SECRETS_KEY_FILE is set, the env-encrypted provider.
+func Load(path string) (*Config, error) {
+	resolver, err := secrets.NewResolverFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return LoadWithSecrets(path, resolver)
+}
+
+// LoadWithSecrets is Load with a caller-supplied resolver for secret references,
+// allowing additional secret providers to be registered
+func LoadWithSecrets(path string, resolver *secrets.Resolver) (*Config, error) {
+	s, err := newSource(path, resolver)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := s.load()
+
+	errs := append(s.errs, s.unknownKeys()...)
+	errs = append(errs, cfg.validate()...)
+	if cfg.Logging.Environment == "production" {
+		errs = append(errs, s.productionRPCErrors(cfg.RPC)...)
+	}
+	if len(errs) > 0 {
+		return nil, &ValidationError{File: path, Errors: errs}
+	}
+	return cfg, nil
+}
+
+// productionRPCErrors requires an explicitly configured RPC endpoint with
+// credentials, either SOLANA_RPC_API_KEY or a credential in the endpoint URL. The
+// public endpoint is rejected as it is rate limited.
+func (s *source) productionRPCErrors(rpc RPCConfig) []FieldError {
+	switch {
+	case !s.explicit["rpc.endpoint"]:
+		return []FieldError{{
+			Field:   "rpc.endpoint",
+			Message: "must be set explicitly in production (SOLANA_RPC_ENDPOINT, SOLANA_RPC_ENDPOINT_FILE or rpc.endpoint)",
+		}}
+	case strings.TrimSuffix(rpc.Endpoint, "/") == DefaultRPCEndpoint:
+		return []FieldError{{
+			Field:   "rpc.endpoint",
+			Message: "must not be the public endpoint " + DefaultRPCEndpoint + " in production",
+		}}
+	case rpc.APIKey == "" && !secrets.HasCredentials(rpc.Endpoint):
+		return []FieldError{{
+			Field:   "rpc.endpoint",
+			Message: "must carry credentials in production (SOLANA_RPC_API_KEY or a credential in the endpoint URL)",
+		}}
+	}
+	return nil
 }
 
-// LoadConfig loads configuration from environment variables with defaults
-func LoadConfig() *Config {
+// load builds the configuration, resolving every field through the source
+func (s *source) load() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Port:         getEnv("SERVER_PORT", "8080"),
-			Host:         getEnv("SERVER_HOST", "0.0.0.0"),
-			ReadTimeout:  getDurationEnv("SERVER_READ_TIMEOUT", 10*time.Second),
-			WriteTimeout: getDurationEnv("SERVER_WRITE_TIMEOUT", 10*time.Second),
-			IdleTimeout:  getDurationEnv("SERVER_IDLE_TIMEOUT", 60*time.Second),
+			Port:         s.getString("SERVER_PORT", "server.port", "8080"),
+			Host:         s.getString("SERVER_HOST", "server.host", "0.0.0.0"),
+			ReadTimeout:  s.getDuration("SERVER_READ_TIMEOUT", "server.read_timeout", 10*time.Second),
+			WriteTimeout: s.getDuration("SERVER_WRITE_TIMEOUT", "server.write_timeout", 10*time.Second),
+			IdleTimeout:  s.getDuration("SERVER_IDLE_TIMEOUT", "server.idle_timeout", 60*time.Second),
+
+			TrustedProxies: s.getStringSlice("SERVER_TRUSTED_PROXIES", "server.trusted_proxies", nil),
+			ClientIPHeader: s.getString("SERVER_CLIENT_IP_HEADER", "server.client_ip_header", ""),
 		},
 		MongoDB: MongoDBConfig{
-			URI:              getEnv("MONGODB_URI", "mongodb://localhost:27017"),
-			Database:         getEnv("MONGODB_DATABASE", "solana_api"),
-			APIKeyCollection: getEnv("MONGODB_APIKEY_COLLECTION", "api_keys"),
-			ConnectTimeout:   getDurationEnv("MONGODB_CONNECT_TIMEOUT", 10*time.Second),
-			MaxPoolSize:      getUint64Env("MONGODB_MAX_POOL_SIZE", 100),
+			URI:              s.getSecret("MONGODB_URI", "mongodb.uri", "mongodb://localhost:27017"),
+			Database:         s.getString("MONGODB_DATABASE", "mongodb.database", "solana_api"),
+			APIKeyCollection: s.getString("MONGODB_APIKEY_COLLECTION", "mongodb.api_key_collection", "api_keys"),
+			ConnectTimeout:   s.getDuration("MONGODB_CONNECT_TIMEOUT", "mongodb.connect_timeout", 10*time.Second),
+			MaxPoolSize:      s.getUint64("MONGODB_MAX_POOL_SIZE", "mongodb.max_pool_size", 100),
+
+			OrganizationCollection:      s.getString("MONGODB_ORGANIZATION_COLLECTION", "mongodb.organization_collection", "organizations"),
+			OrganizationUsageCollection: s.getString("MONGODB_ORGANIZATION_USAGE_COLLECTION", "mongodb.organization_usage_collection", "organization_usage"),
+			UsageCollection:             s.getString("MONGODB_USAGE_COLLECTION", "mongodb.usage_collection", "api_key_usage"),
+			AuditCollection:             s.getString("MONGODB_AUDIT_COLLECTION", "mongodb.audit_collection", "audit_log"),
 		},
 		RPC: RPCConfig{
-			Endpoint:           getEnv("SOLANA_RPC_ENDPOINT", "https://pomaded-lithotomies-xfbhnqagbt-dedicated.helius-rpc.com/?api-key=37ba4475-8fa3-4491-875f-758894981943"),
-			Timeout:            getDurationEnv("SOLANA_RPC_TIMEOUT", 30*time.Second),
-			APIKey:             getEnv("SOLANA_RPC_API_KEY", "37ba4475-8fa3-4491-875f-758894981943"),
-			MaxRetries:         getIntEnv("SOLANA_RPC_MAX_RETRIES", 3),
-			RetryDelay:         getDurationEnv("SOLANA_RPC_RETRY_DELAY", 1*time.Second),
-			ConnectionPoolSize: getIntEnv("SOLANA_RPC_CONNECTION_POOL_SIZE", 10),
+			Endpoint:           s.getSecret("SOLANA_RPC_ENDPOINT", "rpc.endpoint", DefaultRPCEndpoint),
+			Timeout:            s.getDuration("SOLANA_RPC_TIMEOUT", "rpc.timeout", 30*time.Second),
+			APIKey:             s.getSecret("SOLANA_RPC_API_KEY", "rpc.api_key", ""),
+			MaxRetries:         s.getInt("SOLANA_RPC_MAX_RETRIES", "rpc.max_retries", 3),
+			RetryDelay:         s.getDuration("SOLANA_RPC_RETRY_DELAY", "rpc.retry_delay", 1*time.Second),
+			ConnectionPoolSize: s.getInt("SOLANA_RPC_CONNECTION_POOL_SIZE", "rpc.connection_pool_size", 10),
 		},
 		Cache: CacheConfig{
-			TTL:             getDurationEnv("CACHE_TTL", 10*time.Second),
-			CleanupInterval: getDurationEnv("CACHE_CLEANUP_INTERVAL", 60*time.Second),
-			MaxSize:         getIntEnv("CACHE_MAX_SIZE", 10000),
+			TTL:             s.getDuration("CACHE_TTL", "cache.ttl", 10*time.Second),
+			CleanupInterval: s.getDuration("CACHE_CLEANUP_INTERVAL", "cache.cleanup_interval", 60*time.Second),
+			MaxSize:         s.getInt("CACHE_MAX_SIZE", "cache.max_size", 10000),
+			PublicResponses: s.getBool("CACHE_PUBLIC_RESPONSES", "cache.public_responses", false),
 		},
 		RateLimit: RateLimitConfig{
-			RequestsPerMinute: getIntEnv("RATE_LIMIT_REQUESTS_PER_MINUTE", 10),
-			WindowSize:        getDurationEnv("RATE_LIMIT_WINDOW_SIZE", time.Minute),
-			CleanupInterval:   getDurationEnv("RATE_LIMIT_CLEANUP_INTERVAL", 5*time.Minute),
+			WindowSize:      s.getDuration("RATE_LIMIT_WINDOW_SIZE", "rate_limit.window_size", time.Minute),
+			CleanupInterval: s.getDuration("RATE_LIMIT_CLEANUP_INTERVAL", "rate_limit.cleanup_interval", 5*time.Minute),
+			UnitsPerWindow:  s.getInt("RATE_LIMIT_UNITS_PER_WINDOW", "rate_limit.units_per_window", 100),
+			RequestCost:     s.getInt("RATE_LIMIT_REQUEST_COST", "rate_limit.request_cost", 1),
+			WalletCost:      s.getInt("RATE_LIMIT_WALLET_COST", "rate_limit.wallet_cost", 1),
+			RPCMissCost:     s.getInt("RATE_LIMIT_RPC_MISS_COST", "rate_limit.rpc_miss_cost", 1),
 		},
 		Logging: LoggingConfig{
-			Level:       getEnv("LOG_LEVEL", "info"),
-			Environment: getEnv("LOG_ENVIRONMENT", "development"),
-			OutputPaths: getStringSliceEnv("LOG_OUTPUT_PATHS", []string{"stdout"}),
-		},
-	}
-}
+			Level:       s.getString("LOG_LEVEL", "logging.level", "info"),
+			Environment: s.getString("LOG_ENVIRONMENT", "logging.environment", "development"),
+			OutputPaths: s.getStringSlice("LOG_OUTPUT_PATHS", "logging.output_paths", []string{"stdout"}),
 
-// Helper functions for environment variable parsing
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
-
-func getIntEnv(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
-		}
-	}
-	return defaultValue
-}
+			RedactWallets: s.getString("LOG_REDACT_WALLETS", "logging.redact_wallets", "hash"),
+			RedactFields:  s.getStringMap("LOG_REDACT_FIELDS", "logging.redact_fields", nil),
+			Sampling: LogSamplingConfig{
+				Enabled:    s.getBool("LOG_SAMPLING_ENABLED", "logging.sampling.enabled", true),
+				Tick:       s.getDuration("LOG_SAMPLING_TICK", "logging.sampling.tick", time.Second),
+				Initial:    s.getInt("LOG_SAMPLING_INITIAL", "logging.sampling.initial", 100),
+				Thereafter: s.getInt("LOG_SAMPLING_THEREAFTER", "logging.sampling.thereafter", 100),
+				Messages:   s.getStringSlice("LOG_SAMPLING_MESSAGES", "logging.sampling.messages", nil),
+			},
+			Sinks: s.getSinks("LOG_SINKS", "logging.sinks"),
+		},
+		IPFilter: IPFilterConfig{
+			DeniedCIDRs:    s.getStringSlice("IP_DENYLIST", "ip_filter.denied_cidrs", nil),
+			DenylistFile:   s.getString("IP_DENYLIST_FILE", "ip_filter.denylist_file", ""),
+			ReloadInterval: s.getDuration("IP_DENYLIST_RELOAD_INTERVAL", "ip_filter.reload_interval", 10*time.Second),
+		},
+		Auth: AuthConfig{
+			Mode:                s.getString("AUTH_MODE", "auth.mode", "api_key"),
+			JWTIssuer:           s.getString("AUTH_JWT_ISSUER", "auth.jwt_issuer", ""),
+			Audience:            s.getString("AUTH_JWT_AUDIENCE", "auth.audience", "solana-balance-api"),
+			JWKSURL:             s.getString("AUTH_JWKS_URL", "auth.jwks_url", ""),
+			JWKSFile:            s.getString("AUTH_JWKS_FILE", "auth.jwks_file", ""),
+			JWKSRefreshInterval: s.getDuration("AUTH_JWKS_REFRESH_INTERVAL", "auth.jwks_refresh_interval", 10*time.Minute),
+			ScopeClaim:          s.getString("AUTH_JWT_SCOPE_CLAIM", "auth.scope_claim", "scope"),
+			TenantClaim:         s.getString("AUTH_JWT_TENANT_CLAIM", "auth.tenant_claim", "tenant_id"),
+			ClockSkew:           s.getDuration("AUTH_JWT_CLOCK_SKEW", "auth.clock_skew", 30*time.Second),
+			TokenIssuer:         s.getString("AUTH_TOKEN_ISSUER", "auth.token_issuer", "solana-balance-api"),
+			TokenTTL:            s.getDuration("AUTH_TOKEN_TTL", "auth.token_ttl", 5*time.Minute),
+			SigningKeyFile:      s.getString("AUTH_TOKEN_SIGNING_KEY_FILE", "auth.signing_key_file", ""),
 
-func getUint64Env(key string, defaultValue uint64) uint64 {
-	if value := os.Getenv(key); value != "" {
-		if uint64Value, err := strconv.ParseUint(value, 10, 64); err == nil {
-			return uint64Value
-		}
-	}
-	return defaultValue
-}
+			KeyCacheTTL:           s.getDuration("AUTH_KEY_CACHE_TTL", "auth.key_cache_ttl", 30*time.Second),
+			KeyCacheNegativeTTL:   s.getDuration("AUTH_KEY_CACHE_NEGATIVE_TTL", "auth.key_cache_negative_ttl", 5*time.Second),
+			KeyCacheMaxSize:       s.getInt("AUTH_KEY_CACHE_MAX_SIZE", "auth.key_cache_max_size", 10000),
+			KeyCachePollInterval:  s.getDuration("AUTH_KEY_CACHE_POLL_INTERVAL", "auth.key_cache_poll_interval", 5*time.Second),
+			LastUsedFlushInterval: s.getDuration("AUTH_LAST_USED_FLUSH_INTERVAL", "auth.last_used_flush_interval", 10*time.Second),
 
-func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
-		if duration, err := time.ParseDuration(value); err == nil {
-			return duration
-		}
+			KeyExpiryWarningDays:   s.getInt("AUTH_KEY_EXPIRY_WARNING_DAYS", "auth.key_expiry_warning_days", 14),
+			KeyRotationGracePeriod: s.getDuration("AUTH_KEY_ROTATION_GRACE_PERIOD", "auth.key_rotation_grace_period", 24*time.Hour),
+			KeyExpirySweepInterval: s.getDuration("AUTH_KEY_EXPIRY_SWEEP_INTERVAL", "auth.key_expiry_sweep_interval", time.Minute),
+		},
+		Tenant: TenantConfig{
+			CacheTTL:           s.getDuration("TENANT_CACHE_TTL", "tenant.cache_ttl", time.Minute),
+			UsageFlushInterval: s.getDuration("TENANT_USAGE_FLUSH_INTERVAL", "tenant.usage_flush_interval", 30*time.Second),
+			RateTiers: s.getIntMap("TENANT_RATE_TIERS", "tenant.rate_tiers", map[string]int{
+				"free":       100,
+				"standard":   1000,
+				"enterprise": 10000,
+			}),
+			DefaultTier: s.getString("TENANT_DEFAULT_TIER", "tenant.default_tier", "standard"),
+		},
+		Usage: UsageConfig{
+			FlushInterval: s.getDuration("USAGE_FLUSH_INTERVAL", "usage.flush_interval", 15*time.Second),
+			MaxQueryRange: s.getDuration("USAGE_MAX_QUERY_RANGE", "usage.max_query_range", 92*24*time.Hour),
+		},
+		Audit: AuditConfig{
+			Enabled:        s.getBool("AUDIT_ENABLED", "audit.enabled", true),
+			LogAuthSuccess: s.getBool("AUDIT_LOG_AUTH_SUCCESS", "audit.log_auth_success", true),
+			BufferSize:     s.getInt("AUDIT_BUFFER_SIZE", "audit.buffer_size", 10000),
+			BatchSize:      s.getInt("AUDIT_BATCH_SIZE", "audit.batch_size", 100),
+			FlushInterval:  s.getDuration("AUDIT_FLUSH_INTERVAL", "audit.flush_interval", time.Second),
+			MaxRetries:     s.getInt("AUDIT_MAX_RETRIES", "audit.max_retries", 3),
+			RetryBackoff:   s.getDuration("AUDIT_RETRY_BACKOFF", "audit.retry_backoff", 500*time.Millisecond),
+			SpillFile:      s.getString("AUDIT_SPILL_FILE", "audit.spill_file", ""),
+		},
+		Tracing: TracingConfig{
+			Enabled:     s.getBool("TRACING_ENABLED", "tracing.enabled", false),
+			Exporter:    s.getString("TRACING_EXPORTER", "tracing.exporter", "otlp"),
+			Endpoint:    s.getString("TRACING_OTLP_ENDPOINT", "tracing.endpoint", "localhost:4318"),
+			URLPath:     s.getString("TRACING_OTLP_URL_PATH", "tracing.url_path", ""),
+			Insecure:    s.getBool("TRACING_OTLP_INSECURE", "tracing.insecure", true),
+			Headers:     s.getSecretMap("TRACING_OTLP_HEADERS", "tracing.headers", nil),
+			SampleRatio: s.getFloat("TRACING_SAMPLE_RATIO", "tracing.sample_ratio", 1.0),
+			ServiceName: s.getString("TRACING_SERVICE_NAME", "tracing.service_name", "solana-balance-api"),
+		},
+		Reload: ReloadConfig{
+			Interval: s.getDuration("CONFIG_RELOAD_INTERVAL", "reload.interval", 10*time.Second),
+		},
+		Errors: ErrorsConfig{
+			DocsBaseURL: s.getString("ERRORS_DOCS_BASE_URL", "errors.docs_base_url", ""),
+		},
+		Limits: LimitsConfig{
+			MaxBodyBytes: int64(s.getInt("LIMITS_MAX_BODY_BYTES", "limits.max_body_bytes", 1<<20)),
+			MaxWallets:   s.getInt("LIMITS_MAX_WALLETS", "limits.max_wallets", 100),
+		},
+		API: APIConfig{
+			LegacyDeprecatedAt: s.getString("API_LEGACY_DEPRECATED_AT", "api.legacy_deprecated_at", "2026-11-01T00:00:00Z"),
+			LegacySunset:       s.getString("API_LEGACY_SUNSET", "api.legacy_sunset", "2027-05-01T00:00:00Z"),
+		},
 	}
-	return defaultValue
 }
 
-func getStringSliceEnv(key string, defaultValue []string) []string {
-	if value := os.Getenv(key); value != "" {
-		// Simple comma-separated parsing
-		return []string{value}
+// loadSink builds a log sink from a source over one entry of logging.sinks
+func (s *source) loadSink() LogSinkConfig {
+	return LogSinkConfig{
+		Path:           s.getString("", "path", ""),
+		Level:          s.getString("", "level", ""),
+		Encoding:       s.getString("", "encoding", ""),
+		MaxSizeMB:      s.getInt("", "max_size_mb", 100),
+		RotateInterval: s.getDuration("", "rotate_interval", 0),
+		MaxAge:         s.getDuration("", "max_age", 0),
+		MaxBackups:     s.getInt("", "max_backups", 10),
+		Compress:       s.getBool("", "compress", true),
+		Async:          s.getBool("", "async", false),
+		BufferSize:     s.getInt("", "buffer_size", 1024),
+		FlushInterval:  s.getDuration("", "flush_interval", time.Second),
+		DropPolicy:     s.getString("", "drop_policy", "drop_newest"),
 	}
-	return defaultValue
 }
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"solana-balance-api/pkg/secrets"
+
+	"gopkg.in/yaml.v3"
+)
+
+// secretFields are masked entirely in dumps
+var secretFields = map[string]bool{
+	"rpc.api_key":     true,
+	"tracing.headers": true,
+}
+
+// credentialURLFields are URLs that may embed credentials; only the credentials
+// are masked
+var credentialURLFields = map[string]bool{
+	"mongodb.uri":   true,
+	"rpc.endpoint":  true,
+	"auth.jwks_url": true,
+}
+
+// Map returns the configuration as nested maps keyed by the json field names, the
+// same layout accepted in configuration files. Durations are rendered as strings
+// such as "10s". Secrets are redacted unless reveal is set.
+func (c *Config) Map(reveal bool) map[string]interface{} {
+	result := toMap(reflect.ValueOf(*c), "")
+	if !reveal {
+		redactMap(result, "")
+	}
+	return result
+}
+
+// toMap converts a struct to a map keyed by its json tags
+func toMap(value reflect.Value, prefix string) map[string]interface{} {
+	result := make(map[string]interface{})
+	valueType := value.Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		fieldValue := value.Field(i)
+		switch {
+		case fieldValue.Type() == reflect.TypeOf(time.Duration(0)):
+			result[name] = time.Duration(fieldValue.Int()).String()
+		case fieldValue.Kind() == reflect.Struct:
+			result[name] = toMap(fieldValue, prefix+name+".")
+		case fieldValue.Kind() == reflect.Slice && fieldValue.Type().Elem().Kind() == reflect.Struct:
+			items := make([]interface{}, fieldValue.Len())
+			for j := range items {
+				items[j] = toMap(fieldValue.Index(j), fmt.Sprintf("%s%s[%d].", prefix, name, j))
+			}
+			result[name] = items
+		default:
+			result[name] = fieldValue.Interface()
+		}
+	}
+	return result
+}
+
+// redactMap masks secret and credential-bearing fields in place
+func redactMap(values map[string]interface{}, prefix string) {
+	for name, value := range values {
+		path := prefix + name
+		switch {
+		case secretFields[path]:
+			values[name] = redactValue(value)
+		case credentialURLFields[path]:
+			if text, ok := value.(string); ok {
+				values[name] = secrets.RedactURL(text)
+			}
+		default:
+			if nested, ok := value.(map[string]interface{}); ok {
+				redactMap(nested, path+".")
+			}
+		}
+	}
+}
+
+// redactValue masks a secret, keeping map keys so the shape stays visible
+func redactValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case string:
+		if typed == "" {
+			return typed
+		}
+		return secrets.Redacted
+	case map[string]string:
+		masked := make(map[string]string, len(typed))
+		for key := range typed {
+			masked[key] = secrets.Redacted
+		}
+		return masked
+	default:
+		return secrets.Redacted
+	}
+}
+
+// Dump writes the redacted configuration as YAML or JSON
+func (c *Config) Dump(w io.Writer, format string) error {
+	values := c.Map(false)
+	switch strings.ToLower(format) {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(values)
+	case "yaml", "yml", "":
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(values); err != nil {
+			return err
+		}
+		return encoder.Close()
+	default:
+		return fmt.Errorf("unsupported config dump format %q (use yaml or json)", format)
+	}
+}
+
+// Changes returns the dotted paths of every field that differs between two
+// configurations, sorted
+func Changes(before, after *Config) []string {
+	old := flatten(before.Map(true), "")
+	updated := flatten(after.Map(true), "")
+
+	var changed []string
+	for path, value := range updated {
+		if old[path] != value {
+			changed = append(changed, path)
+		}
+	}
+	for path := range old {
+		if _, exists := updated[path]; !exists {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// flatten renders nested maps as dotted paths with comparable string values
+func flatten(values map[string]interface{}, prefix string) map[string]string {
+	result := make(map[string]string)
+	for name, value := range values {
+		path := prefix + name
+		if nested, ok := value.(map[string]interface{}); ok {
+			for nestedPath, nestedValue := range flatten(nested, path+".") {
+				result[nestedPath] = nestedValue
+			}
+			continue
+		}
+		encoded, _ := json.Marshal(value)
+		switch string(encoded) {
+		case "{}", "[]":
+			// Empty and unset lists and maps are equivalent
+			encoded = []byte("null")
+		}
+		result[path] = string(encoded)
+	}
+	return result
+}
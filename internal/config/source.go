@@ -0,0 +1,419 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"solana-balance-api/pkg/secrets"
+
+	"gopkg.in/yaml.v3"
+)
+
+// source resolves configuration values from environment variables, a parsed
+// configuration file and defaults, collecting every parse error
+type source struct {
+	file     map[string]interface{} // Parsed file contents; nil without a file
+	known    map[string]bool        // Dotted paths of every field that was resolved
+	explicit map[string]bool        // Dotted paths of secrets set explicitly rather than defaulted
+	resolver *secrets.Resolver
+	errs     []FieldError
+}
+
+// newSource parses the configuration file at path. An empty path yields a source
+// backed only by environment variables and defaults.
+func newSource(path string, resolver *secrets.Resolver) (*source, error) {
+	s := &source{
+		known:    make(map[string]bool),
+		explicit: make(map[string]bool),
+		resolver: resolver,
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(strings.NewReader(string(data)))
+		decoder.UseNumber()
+		if err := decoder.Decode(&s.file); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &s.file); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config file extension %q (use .yaml, .yml or .json)", filepath.Ext(path))
+	}
+
+	return s, nil
+}
+
+// lookupFile returns the raw file value at a dotted path
+func (s *source) lookupFile(path string) (interface{}, bool) {
+	s.known[path] = true
+
+	var current interface{} = s.file
+	for _, part := range strings.Split(path, ".") {
+		section, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = section[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// lookup returns the raw value for a field from its environment variable or the
+// file, and a description of where it came from for error messages
+func (s *source) lookup(env, path string) (string, interface{}, bool) {
+	fileValue, inFile := s.lookupFile(path)
+	if value := os.Getenv(env); value != "" {
+		return "environment variable " + env, value, true
+	}
+	if inFile && fileValue != nil {
+		return "config file", fileValue, true
+	}
+	return "", nil, false
+}
+
+// scalar looks up a single value and renders it as a string
+func (s *source) scalar(env, path string) (string, string, bool) {
+	origin, raw, ok := s.lookup(env, path)
+	if !ok {
+		return "", "", false
+	}
+	switch value := raw.(type) {
+	case string:
+		return origin, value, true
+	case map[string]interface{}, []interface{}:
+		s.fail(path, origin, "expected a single value")
+		return "", "", false
+	default:
+		return origin, fmt.Sprint(value), true
+	}
+}
+
+// fail records an invalid field
+func (s *source) fail(path, origin, message string) {
+	s.errs = append(s.errs, FieldError{Field: path, Source: origin, Message: message})
+}
+
+func (s *source) getString(env, path, defaultValue string) string {
+	if _, value, ok := s.scalar(env, path); ok {
+		return value
+	}
+	return defaultValue
+}
+
+func (s *source) getInt(env, path string, defaultValue int) int {
+	origin, value, ok := s.scalar(env, path)
+	if !ok {
+		return defaultValue
+	}
+	intValue, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		s.fail(path, origin, fmt.Sprintf("invalid integer %q", value))
+		return defaultValue
+	}
+	return intValue
+}
+
+func (s *source) getUint64(env, path string, defaultValue uint64) uint64 {
+	origin, value, ok := s.scalar(env, path)
+	if !ok {
+		return defaultValue
+	}
+	uint64Value, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		s.fail(path, origin, fmt.Sprintf("invalid unsigned integer %q", value))
+		return defaultValue
+	}
+	return uint64Value
+}
+
+func (s *source) getBool(env, path string, defaultValue bool) bool {
+	origin, value, ok := s.scalar(env, path)
+	if !ok {
+		return defaultValue
+	}
+	boolValue, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		s.fail(path, origin, fmt.Sprintf("invalid boolean %q", value))
+		return defaultValue
+	}
+	return boolValue
+}
+
+func (s *source) getFloat(env, path string, defaultValue float64) float64 {
+	origin, value, ok := s.scalar(env, path)
+	if !ok {
+		return defaultValue
+	}
+	floatValue, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		s.fail(path, origin, fmt.Sprintf("invalid number %q", value))
+		return defaultValue
+	}
+	return floatValue
+}
+
+func (s *source) getDuration(env, path string, defaultValue time.Duration) time.Duration {
+	origin, value, ok := s.scalar(env, path)
+	if !ok {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		s.fail(path, origin, fmt.Sprintf("invalid duration %q (use e.g. 500ms, 10s, 5m)", value))
+		return defaultValue
+	}
+	return duration
+}
+
+// getStringSlice reads a comma-separated environment variable or a file list,
+// ignoring empty entries
+func (s *source) getStringSlice(env, path string, defaultValue []string) []string {
+	origin, raw, ok := s.lookup(env, path)
+	if !ok {
+		return defaultValue
+	}
+
+	var parts []string
+	switch value := raw.(type) {
+	case string:
+		parts = strings.Split(value, ",")
+	case []interface{}:
+		for _, item := range value {
+			if _, nested := item.(map[string]interface{}); nested {
+				s.fail(path, origin, "expected a list of values")
+				return defaultValue
+			}
+			parts = append(parts, fmt.Sprint(item))
+		}
+	default:
+		s.fail(path, origin, "expected a list or comma-separated string")
+		return defaultValue
+	}
+
+	var result []string
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			result = append(result, part)
+		}
+	}
+	return result
+}
+
+// pairs reads "name<sep>value" pairs separated by commas from an environment
+// variable, or a mapping from the file
+func (s *source) pairs(env, path, sep string) (string, map[string]string, bool) {
+	origin, raw, ok := s.lookup(env, path)
+	if !ok {
+		return "", nil, false
+	}
+
+	result := make(map[string]string)
+	switch value := raw.(type) {
+	case string:
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part == "" {
+				continue
+			}
+			name, val, found := strings.Cut(part, sep)
+			if !found || strings.TrimSpace(name) == "" {
+				s.fail(path, origin, fmt.Sprintf("malformed entry %q (expected name%svalue)", part, sep))
+				continue
+			}
+			result[strings.TrimSpace(name)] = strings.TrimSpace(val)
+		}
+	case map[string]interface{}:
+		for name, val := range value {
+			result[name] = fmt.Sprint(val)
+		}
+	default:
+		s.fail(path, origin, "expected a mapping")
+		return "", nil, false
+	}
+	return origin, result, true
+}
+
+// getIntMap reads "name:value" pairs, e.g. TENANT_RATE_TIERS=free:100,standard:1000
+func (s *source) getIntMap(env, path string, defaultValue map[string]int) map[string]int {
+	origin, pairs, ok := s.pairs(env, path, ":")
+	if !ok {
+		return defaultValue
+	}
+
+	result := make(map[string]int, len(pairs))
+	for name, value := range pairs {
+		intValue, err := strconv.Atoi(value)
+		if err != nil {
+			s.fail(path+"."+name, origin, fmt.Sprintf("invalid integer %q", value))
+			continue
+		}
+		result[name] = intValue
+	}
+	return result
+}
+
+// getStringMap reads "name:value" pairs, e.g. LOG_REDACT_FIELDS=user_id:hash,ip:truncate
+func (s *source) getStringMap(env, path string, defaultValue map[string]string) map[string]string {
+	if _, pairs, ok := s.pairs(env, path, ":"); ok {
+		return pairs
+	}
+	return defaultValue
+}
+
+// getSinks reads log sinks from a list in the file or a JSON array in the
+// environment variable, e.g. LOG_SINKS='[{"path":"stdout","encoding":"console"}]'
+func (s *source) getSinks(env, path string) []LogSinkConfig {
+	origin, raw, ok := s.lookup(env, path)
+	if !ok {
+		return nil
+	}
+
+	if text, isString := raw.(string); isString {
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.UseNumber()
+		if err := decoder.Decode(&raw); err != nil {
+			s.fail(path, origin, fmt.Sprintf("invalid JSON: %v", err))
+			return nil
+		}
+	}
+
+	items, isList := raw.([]interface{})
+	if !isList {
+		s.fail(path, origin, "expected a list of sinks")
+		return nil
+	}
+
+	sinks := make([]LogSinkConfig, 0, len(items))
+	for i, item := range items {
+		prefix := fmt.Sprintf("%s[%d]", path, i)
+		fields, isMap := item.(map[string]interface{})
+		if !isMap {
+			s.fail(prefix, origin, "expected a mapping")
+			continue
+		}
+
+		// Resolve the entry's fields through a source over the entry alone
+		entry := &source{
+			file:     fields,
+			known:    make(map[string]bool),
+			explicit: make(map[string]bool),
+			resolver: s.resolver,
+		}
+		sinks = append(sinks, entry.loadSink())
+		for _, fieldErr := range append(entry.errs, entry.unknownKeys()...) {
+			fieldErr.Field = prefix + "." + fieldErr.Field
+			fieldErr.Source = origin
+			s.errs = append(s.errs, fieldErr)
+		}
+	}
+	return sinks
+}
+
+// getSecret reads a credential. ENV_FILE may name a file holding the value instead
+// of ENV, and any value may be a secret reference resolved by a provider.
+func (s *source) getSecret(env, path, defaultValue string) string {
+	fileEnv := env + "_FILE"
+	if secretFile := os.Getenv(fileEnv); secretFile != "" {
+		s.lookupFile(path)
+		origin := "environment variable " + fileEnv
+		if os.Getenv(env) != "" {
+			s.fail(path, origin, fmt.Sprintf("only one of %s and %s may be set", env, fileEnv))
+			return defaultValue
+		}
+		value, err := secrets.ReadFile(secretFile)
+		if err != nil {
+			s.fail(path, origin, err.Error())
+			return defaultValue
+		}
+		s.explicit[path] = true
+		return value
+	}
+
+	origin, value, ok := s.scalar(env, path)
+	if !ok {
+		return defaultValue
+	}
+	resolved, err := s.resolver.Resolve(value)
+	if err != nil {
+		s.fail(path, origin, err.Error())
+		return defaultValue
+	}
+	s.explicit[path] = true
+	return resolved
+}
+
+// getSecretMap reads "name=value" pairs whose values may be secret references,
+// e.g. TRACING_OTLP_HEADERS=x-api-key=secret:file:/run/secrets/otlp
+func (s *source) getSecretMap(env, path string, defaultValue map[string]string) map[string]string {
+	origin, pairs, ok := s.pairs(env, path, "=")
+	if !ok {
+		return defaultValue
+	}
+	for name, value := range pairs {
+		resolved, err := s.resolver.Resolve(value)
+		if err != nil {
+			s.fail(path+"."+name, origin, err.Error())
+			continue
+		}
+		pairs[name] = resolved
+	}
+	return pairs
+}
+
+// unknownKeys reports file entries that do not correspond to any field. It must be
+// called after every field has been resolved.
+func (s *source) unknownKeys() []FieldError {
+	var errs []FieldError
+	var walk func(prefix string, value interface{})
+	walk = func(prefix string, value interface{}) {
+		if s.known[prefix] {
+			return
+		}
+		section, ok := value.(map[string]interface{})
+		if !ok || (prefix != "" && !s.hasFieldsUnder(prefix)) {
+			errs = append(errs, FieldError{Field: prefix, Source: "config file", Message: "unknown field"})
+			return
+		}
+		for key, child := range section {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			walk(path, child)
+		}
+	}
+	if s.file != nil {
+		walk("", s.file)
+	}
+
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+	return errs
+}
+
+// hasFieldsUnder reports whether any known field lives below prefix
+func (s *source) hasFieldsUnder(prefix string) bool {
+	for path := range s.known {
+		if strings.HasPrefix(path, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,238 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"solana-balance-api/pkg/clientip"
+	"solana-balance-api/pkg/logger"
+)
+
+// FieldError describes one invalid configuration field
+type FieldError struct {
+	Field   string // Dotted path, e.g. rate_limit.window_size
+	Source  string // Where the value came from; empty for defaults
+	Message string
+}
+
+// Error implements the error interface
+func (e FieldError) Error() string {
+	if e.Source != "" {
+		return fmt.Sprintf("%s: %s (from %s)", e.Field, e.Message, e.Source)
+	}
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationError reports every invalid field found while loading configuration
+type ValidationError struct {
+	File   string
+	Errors []FieldError
+}
+
+// Error lists every invalid field, one per line
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	if e.File != "" {
+		fmt.Fprintf(&b, "invalid configuration (%s):", e.File)
+	} else {
+		b.WriteString("invalid configuration:")
+	}
+	for _, fieldErr := range e.Errors {
+		b.WriteString("\n  - ")
+		b.WriteString(fieldErr.Error())
+	}
+	return b.String()
+}
+
+// Validate checks the configuration and reports every invalid field
+func (c *Config) Validate() error {
+	if errs := c.validate(); len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// validator accumulates field errors
+type validator struct {
+	errs []FieldError
+}
+
+func (v *validator) check(ok bool, field, format string, args ...interface{}) {
+	if !ok {
+		v.errs = append(v.errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+func (v *validator) positive(field string, d time.Duration) {
+	v.check(d > 0, field, "must be a positive duration, got %s", d)
+}
+
+func (v *validator) oneOf(field, value string, allowed ...string) {
+	for _, candidate := range allowed {
+		if value == candidate {
+			return
+		}
+	}
+	v.check(false, field, "must be one of %s, got %q", strings.Join(allowed, ", "), value)
+}
+
+// validate returns every rule the configuration violates
+func (c *Config) validate() []FieldError {
+	v := &validator{}
+
+	// Server
+	port, err := strconv.Atoi(c.Server.Port)
+	v.check(err == nil && port > 0 && port <= 65535, "server.port", "must be a port number between 1 and 65535, got %q", c.Server.Port)
+	v.positive("server.read_timeout", c.Server.ReadTimeout)
+	v.positive("server.write_timeout", c.Server.WriteTimeout)
+	v.positive("server.idle_timeout", c.Server.IdleTimeout)
+	if err := (clientip.Config{TrustedProxies: c.Server.TrustedProxies, Header: c.Server.ClientIPHeader}).Validate(); err != nil {
+		v.check(false, "server.client_ip_header", "%v", err)
+	}
+
+	// MongoDB
+	v.check(strings.HasPrefix(c.MongoDB.URI, "mongodb://") || strings.HasPrefix(c.MongoDB.URI, "mongodb+srv://"),
+		"mongodb.uri", "must start with mongodb:// or mongodb+srv://")
+	v.check(c.MongoDB.Database != "", "mongodb.database", "must not be empty")
+	v.positive("mongodb.connect_timeout", c.MongoDB.ConnectTimeout)
+	v.check(c.MongoDB.MaxPoolSize > 0, "mongodb.max_pool_size", "must be positive")
+
+	// RPC
+	v.check(validHTTPURL(c.RPC.Endpoint), "rpc.endpoint", "must be an http or https URL")
+	v.positive("rpc.timeout", c.RPC.Timeout)
+	v.check(c.RPC.MaxRetries >= 0, "rpc.max_retries", "must not be negative, got %d", c.RPC.MaxRetries)
+	v.check(c.RPC.RetryDelay >= 0, "rpc.retry_delay", "must not be negative, got %s", c.RPC.RetryDelay)
+
+	// Cache
+	v.positive("cache.ttl", c.Cache.TTL)
+	v.positive("cache.cleanup_interval", c.Cache.CleanupInterval)
+
+	// Rate limiting
+	v.positive("rate_limit.window_size", c.RateLimit.WindowSize)
+	v.positive("rate_limit.cleanup_interval", c.RateLimit.CleanupInterval)
+	v.check(c.RateLimit.UnitsPerWindow > 0, "rate_limit.units_per_window", "must be positive, got %d", c.RateLimit.UnitsPerWindow)
+	v.check(c.RateLimit.RequestCost >= 0, "rate_limit.request_cost", "must not be negative, got %d", c.RateLimit.RequestCost)
+	v.check(c.RateLimit.WalletCost >= 0, "rate_limit.wallet_cost", "must not be negative, got %d", c.RateLimit.WalletCost)
+	v.check(c.RateLimit.RPCMissCost >= 0, "rate_limit.rpc_miss_cost", "must not be negative, got %d", c.RateLimit.RPCMissCost)
+
+	// Logging
+	v.oneOf("logging.level", strings.ToLower(c.Logging.Level), "debug", "info", "warn", "error", "dpanic", "panic", "fatal")
+	v.oneOf("logging.redact_wallets", c.Logging.RedactWallets, logger.RedactHash, logger.RedactTruncate, logger.RedactNone)
+	redactFields := make([]string, 0, len(c.Logging.RedactFields))
+	for field := range c.Logging.RedactFields {
+		redactFields = append(redactFields, field)
+	}
+	sort.Strings(redactFields)
+	for _, field := range redactFields {
+		v.oneOf("logging.redact_fields."+field, c.Logging.RedactFields[field], logger.RedactionActions...)
+	}
+	for i, sink := range c.Logging.Sinks {
+		prefix := fmt.Sprintf("logging.sinks[%d].", i)
+		v.check(sink.Path != "", prefix+"path", "must not be empty")
+		if sink.Level != "" {
+			v.oneOf(prefix+"level", strings.ToLower(sink.Level), "debug", "info", "warn", "error", "dpanic", "panic", "fatal")
+		}
+		if sink.Encoding != "" {
+			v.oneOf(prefix+"encoding", sink.Encoding, logger.EncodingJSON, logger.EncodingConsole)
+		}
+		v.check(sink.MaxSizeMB >= 0, prefix+"max_size_mb", "must not be negative, got %d", sink.MaxSizeMB)
+		v.check(sink.MaxBackups >= 0, prefix+"max_backups", "must not be negative, got %d", sink.MaxBackups)
+		v.check(sink.RotateInterval >= 0, prefix+"rotate_interval", "must not be negative, got %s", sink.RotateInterval)
+		v.check(sink.MaxAge >= 0, prefix+"max_age", "must not be negative, got %s", sink.MaxAge)
+		if sink.Async {
+			v.check(sink.BufferSize > 0, prefix+"buffer_size", "must be positive, got %d", sink.BufferSize)
+			v.positive(prefix+"flush_interval", sink.FlushInterval)
+		}
+		v.oneOf(prefix+"drop_policy", sink.DropPolicy, logger.DropPolicies...)
+	}
+	if c.Logging.Sampling.Enabled {
+		v.positive("logging.sampling.tick", c.Logging.Sampling.Tick)
+		v.check(c.Logging.Sampling.Initial > 0, "logging.sampling.initial", "must be positive, got %d", c.Logging.Sampling.Initial)
+		v.check(c.Logging.Sampling.Thereafter >= 0, "logging.sampling.thereafter", "must not be negative, got %d", c.Logging.Sampling.Thereafter)
+	}
+
+	// IP filter
+	for _, cidr := range c.IPFilter.DeniedCIDRs {
+		v.check(validCIDROrIP(cidr), "ip_filter.denied_cidrs", "invalid IP or CIDR %q", cidr)
+	}
+
+	// Auth
+	v.oneOf("auth.mode", c.Auth.Mode, "api_key", "jwt", "hybrid")
+	if c.Auth.JWKSURL != "" {
+		v.check(validHTTPURL(c.Auth.JWKSURL), "auth.jwks_url", "must be an http or https URL")
+	}
+	v.positive("auth.token_ttl", c.Auth.TokenTTL)
+	v.check(c.Auth.ClockSkew >= 0, "auth.clock_skew", "must not be negative, got %s", c.Auth.ClockSkew)
+	v.positive("auth.key_cache_ttl", c.Auth.KeyCacheTTL)
+	v.check(c.Auth.KeyCacheMaxSize > 0, "auth.key_cache_max_size", "must be positive, got %d", c.Auth.KeyCacheMaxSize)
+	v.check(c.Auth.KeyRotationGracePeriod >= 0, "auth.key_rotation_grace_period", "must not be negative, got %s", c.Auth.KeyRotationGracePeriod)
+
+	// Tenants
+	tiers := make([]string, 0, len(c.Tenant.RateTiers))
+	for tier := range c.Tenant.RateTiers {
+		tiers = append(tiers, tier)
+	}
+	sort.Strings(tiers)
+	for _, tier := range tiers {
+		units := c.Tenant.RateTiers[tier]
+		v.check(units > 0, "tenant.rate_tiers."+tier, "must be positive, got %d", units)
+	}
+	_, tierExists := c.Tenant.RateTiers[c.Tenant.DefaultTier]
+	v.check(tierExists, "tenant.default_tier", "must name a tier in tenant.rate_tiers, got %q", c.Tenant.DefaultTier)
+
+	// Usage and audit
+	v.positive("usage.flush_interval", c.Usage.FlushInterval)
+	v.positive("usage.max_query_range", c.Usage.MaxQueryRange)
+	if c.Audit.Enabled {
+		v.check(c.Audit.BufferSize > 0, "audit.buffer_size", "must be positive, got %d", c.Audit.BufferSize)
+		v.check(c.Audit.BatchSize > 0, "audit.batch_size", "must be positive, got %d", c.Audit.BatchSize)
+		v.positive("audit.flush_interval", c.Audit.FlushInterval)
+		v.check(c.Audit.MaxRetries >= 0, "audit.max_retries", "must not be negative, got %d", c.Audit.MaxRetries)
+		v.positive("audit.retry_backoff", c.Audit.RetryBackoff)
+	}
+
+	// Tracing
+	v.oneOf("tracing.exporter", c.Tracing.Exporter, "otlp", "memory", "none")
+	v.check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio", "must be between 0 and 1, got %g", c.Tracing.SampleRatio)
+
+	// Reloading
+	v.check(c.Reload.Interval >= 0, "reload.interval", "must not be negative, got %s", c.Reload.Interval)
+
+	// Errors
+	if docs := c.Errors.DocsBaseURL; docs != "" {
+		v.check(strings.HasPrefix(docs, "/") || strings.HasPrefix(docs, "https://") || strings.HasPrefix(docs, "http://"),
+			"errors.docs_base_url", "must be an absolute path or http(s) URL, got %q", docs)
+	}
+
+	// Limits
+	v.check(c.Limits.MaxBodyBytes > 0, "limits.max_body_bytes", "must be positive, got %d", c.Limits.MaxBodyBytes)
+	v.check(c.Limits.MaxWallets > 0, "limits.max_wallets", "must be positive, got %d", c.Limits.MaxWallets)
+
+	// API versioning
+	if deprecatedAt, sunset, err := c.API.LegacyDates(); err != nil {
+		v.check(false, "api", "invalid RFC 3339 timestamp: %v", err)
+	} else {
+		v.check(sunset.After(deprecatedAt), "api.legacy_sunset", "must be after api.legacy_deprecated_at")
+	}
+
+	return v.errs
+}
+
+// validCIDROrIP reports whether value is an IP address or CIDR block
+func validCIDROrIP(value string) bool {
+	if _, _, err := net.ParseCIDR(value); err == nil {
+		return true
+	}
+	return net.ParseIP(value) != nil
+}
+
+// validHTTPURL reports whether value is an absolute http or https URL
+func validHTTPURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"solana-balance-api/internal/models"
+	"solana-balance-api/internal/services"
+	"solana-balance-api/pkg/audit"
+	"solana-balance-api/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// AdminHandler handles administrative API key and organization operations
+type AdminHandler struct {
+	keyManager    services.APIKeyManagerInterface
+	organizations services.OrganizationServiceInterface
+}
+
+// NewAdminHandler creates a new AdminHandler instance
+func NewAdminHandler(keyManager services.APIKeyManagerInterface, organizations services.OrganizationServiceInterface) *AdminHandler {
+	return &AdminHandler{
+		keyManager:    keyManager,
+		organizations: organizations,
+	}
+}
+
+// RotateAPIKey handles POST /api/admin/keys/:id/rotate requests
+func (h *AdminHandler) RotateAPIKey(c *gin.Context) {
+	log := logger.GetLogger().WithContext(c.Request.Context())
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		appErr := models.NewAppErrorWithDetails(
+			models.ErrorCodeInvalidRequest,
+			"Invalid API key ID",
+			"API key ID must be a 24-character hex string",
+		)
+		models.HandleError(c, appErr, log)
+		return
+	}
+
+	// Body is optional
+	var req models.KeyRotationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			appErr := models.NewAppErrorWithCause(models.ErrorCodeMalformedJSON, "Invalid JSON format", err)
+			models.HandleError(c, appErr, log)
+			return
+		}
+	}
+
+	// Without a grace period the configured default applies; "0s" revokes the old secret at once
+	var grace *time.Duration
+	if req.GracePeriod != "" {
+		parsed, err := time.ParseDuration(req.GracePeriod)
+		if err != nil || parsed < 0 {
+			appErr := models.NewAppErrorWithDetails(
+				models.ErrorCodeInvalidRequest,
+				"Invalid grace period",
+				"grace_period must be a non-negative duration such as \"24h\"",
+			)
+			models.HandleError(c, appErr, log)
+			return
+		}
+		grace = &parsed
+	}
+
+	rotated, err := h.keyManager.RotateAPIKey(id, grace)
+	if err != nil {
+		var appErr *models.AppError
+		switch err {
+		case services.ErrAPIKeyNotFound:
+			appErr = models.NewAppError(models.ErrorCodeAPIKeyNotFound, "API key not found")
+		case services.ErrKeyRotationConflict:
+			appErr = models.NewAppErrorWithDetails(models.ErrorCodeInvalidRequest, "API key rotation conflict", "The key was rotated concurrently; retry the request")
+		case services.ErrKeyRotationPending:
+			appErr = models.NewAppErrorWithDetails(models.ErrorCodeInvalidRequest, "API key rotation pending",
+				"The previous secret is still in its grace window; retry after it closes, or rotate with grace_period \"0s\" to revoke both old secrets")
+		default:
+			appErr = models.NewAppErrorWithCause(models.ErrorCodeDatabaseError, "Failed to rotate API key", err)
+		}
+		models.HandleError(c, appErr, log)
+		return
+	}
+
+	log.Info("API key rotated",
+		zap.String("audit_event", "key_rotated"),
+		zap.String("api_key_id", rotated.ID.Hex()),
+		zap.String("admin_key_id", c.GetString("api_key_id")),
+		zap.Timep("previous_key_expires_at", rotated.PreviousKeyExpiresAt),
+	)
+	event := audit.RequestEvent(c, audit.EventKeyRotated, audit.OutcomeSuccess)
+	event.Target = rotated.ID.Hex()
+	if rotated.PreviousKeyExpiresAt != nil {
+		event.Details = map[string]string{
+			"previous_key_expires_at": rotated.PreviousKeyExpiresAt.UTC().Format(time.RFC3339),
+		}
+	} else {
+		event.Details = map[string]string{"previous_key_revoked": "true"}
+	}
+	audit.Record(event)
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, models.KeyRotationResponse{
+		ID:                   rotated.ID.Hex(),
+		Key:                  rotated.Key,
+		RotatedAt:            *rotated.RotatedAt,
+		PreviousKeyExpiresAt: rotated.PreviousKeyExpiresAt,
+	})
+}
+
+// GetOrganization handles GET /api/admin/organizations/:tenant_id requests
+func (h *AdminHandler) GetOrganization(c *gin.Context) {
+	log := logger.GetLogger().WithContext(c.Request.Context())
+	tenantID := c.Param("tenant_id")
+
+	org, err := h.organizations.GetOrganization(tenantID)
+	if err != nil {
+		var appErr *models.AppError
+		if err == services.ErrOrganizationNotFound {
+			appErr = models.NewAppErrorWithDetails(models.ErrorCodeOrganizationNotFound, "Organization not found", "Tenant ID: "+tenantID)
+		} else {
+			appErr = models.NewAppErrorWithCause(models.ErrorCodeDatabaseError, "Failed to load organization", err)
+		}
+		models.HandleError(c, appErr, log)
+		return
+	}
+
+	usage, err := h.organizations.GetUsage(tenantID)
+	if err != nil {
+		appErr := models.NewAppErrorWithCause(models.ErrorCodeDatabaseError, "Failed to load organization usage", err)
+		models.HandleError(c, appErr, log)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.OrganizationResponse{
+		Organization: *org,
+		Usage:        *usage,
+	})
+}
+
+// GetLogLevel handles GET /api/admin/log-level requests
+func (h *AdminHandler) GetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, logger.Levels())
+}
+
+// SetLogLevel handles PUT /api/admin/log-level and PUT /api/admin/log-level/:component
+// requests. Without a component the global level changes.
+func (h *AdminHandler) SetLogLevel(c *gin.Context) {
+	log := logger.GetLogger().WithContext(c.Request.Context())
+	component := c.Param("component")
+
+	var req models.LogLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		appErr := models.NewAppErrorWithCause(models.ErrorCodeMalformedJSON, "Invalid JSON format", err)
+		models.HandleError(c, appErr, log)
+		return
+	}
+
+	var ttl time.Duration
+	if req.TTL != "" {
+		var err error
+		ttl, err = time.ParseDuration(req.TTL)
+		if err != nil || ttl <= 0 {
+			appErr := models.NewAppErrorWithDetails(
+				models.ErrorCodeInvalidRequest,
+				"Invalid TTL",
+				"ttl must be a positive duration such as \"15m\"",
+			)
+			models.HandleError(c, appErr, log)
+			return
+		}
+	}
+
+	if err := logger.SetLevelFor(component, req.Level, ttl); err != nil {
+		appErr := models.NewAppErrorWithDetails(models.ErrorCodeInvalidRequest, "Invalid log level change", err.Error())
+		models.HandleError(c, appErr, log)
+		return
+	}
+
+	log.Info("Log level changed",
+		zap.String("audit_event", audit.EventLogLevelChanged),
+		zap.String("log_component", component),
+		zap.String("log_level", req.Level),
+		zap.Duration("ttl", ttl),
+		zap.String("admin_key_id", c.GetString("api_key_id")),
+	)
+	recordLogLevelChange(c, component, req.Level, req.TTL)
+
+	c.JSON(http.StatusOK, logger.Levels())
+}
+
+// ResetLogLevel handles DELETE /api/admin/log-level/:component requests, removing
+// the component's override
+func (h *AdminHandler) ResetLogLevel(c *gin.Context) {
+	log := logger.GetLogger().WithContext(c.Request.Context())
+	component := c.Param("component")
+
+	if err := logger.ResetComponentLevel(component); err != nil {
+		appErr := models.NewAppErrorWithDetails(models.ErrorCodeInvalidRequest, "Invalid log component", err.Error())
+		models.HandleError(c, appErr, log)
+		return
+	}
+
+	log.Info("Log level override removed",
+		zap.String("audit_event", audit.EventLogLevelChanged),
+		zap.String("log_component", component),
+		zap.String("admin_key_id", c.GetString("api_key_id")),
+	)
+	recordLogLevelChange(c, component, "", "")
+
+	c.JSON(http.StatusOK, logger.Levels())
+}
+
+// recordLogLevelChange audits a runtime log level change; an empty level records
+// a removed override
+func recordLogLevelChange(c *gin.Context, component, level, ttl string) {
+	event := audit.RequestEvent(c, audit.EventLogLevelChanged, audit.OutcomeSuccess)
+	event.Target = component
+	if event.Target == "" {
+		event.Target = "global"
+	}
+	event.Details = map[string]string{"level": level}
+	if ttl != "" {
+		event.Details["ttl"] = ttl
+	}
+	audit.Record(event)
+}
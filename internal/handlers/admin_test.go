@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"solana-balance-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// stubKeyManager rotates keys like AuthService without a database
+type stubKeyManager struct{}
+
+func (stubKeyManager) RotateAPIKey(id primitive.ObjectID, grace *time.Duration) (*models.APIKey, error) {
+	now := time.Now().UTC()
+	rotated := &models.APIKey{ID: id, Key: "new-secret", RotatedAt: &now}
+	if grace == nil || *grace > 0 {
+		expiresAt := now.Add(time.Hour)
+		rotated.PreviousKeyExpiresAt = &expiresAt
+	}
+	return rotated, nil
+}
+
+func TestRotateAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/keys/:id/rotate", NewAdminHandler(stubKeyManager{}, nil).RotateAPIKey)
+
+	rotate := func(body string) map[string]interface{} {
+		req := httptest.NewRequest(http.MethodPost, "/keys/"+primitive.NewObjectID().Hex()+"/rotate", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	t.Run("GracePeriod", func(t *testing.T) {
+		response := rotate(`{"grace_period":"1h"}`)
+		assert.Equal(t, "new-secret", response["key"])
+		assert.Contains(t, response, "previous_key_expires_at")
+	})
+
+	t.Run("ImmediateRevocation", func(t *testing.T) {
+		response := rotate(`{"grace_period":"0s"}`)
+		assert.NotContains(t, response, "previous_key_expires_at")
+	})
+}
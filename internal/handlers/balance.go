@@ -1,13 +1,20 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 
 	"solana-balance-api/internal/models"
+	v1 "solana-balance-api/internal/models/v1"
 	"solana-balance-api/internal/services"
 	"solana-balance-api/pkg/logger"
+	"solana-balance-api/pkg/ratelimiter"
 
+	"github.com/gagliardetto/solana-go"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -15,6 +22,11 @@ import (
 // BalanceHandler handles balance-related HTTP requests
 type BalanceHandler struct {
 	balanceService services.BalanceServiceInterface
+
+	limits      models.RequestLimits // Defaults; zero fields are unlimited
+	limitsMutex sync.RWMutex
+
+	publicCache bool // Allow shared caches to store GET responses
 }
 
 // NewBalanceHandler creates a new BalanceHandler instance
@@ -24,20 +36,69 @@ func NewBalanceHandler(balanceService services.BalanceServiceInterface) *Balance
 	}
 }
 
-// GetBalance handles POST /api/get-balance requests
+// SetPublicCache allows shared caches such as CDNs to store GET balance responses.
+// By default they are marked private. Must be called before serving requests.
+func (h *BalanceHandler) SetPublicCache(public bool) {
+	h.publicCache = public
+}
+
+// SetLimits changes the default request limits, e.g. on configuration reload.
+// API keys with their own limits keep them.
+func (h *BalanceHandler) SetLimits(limits models.RequestLimits) {
+	h.limitsMutex.Lock()
+	defer h.limitsMutex.Unlock()
+	h.limits = limits
+}
+
+// limitsFor returns the limits of the request: the API key's overrides, falling
+// back to the defaults
+func (h *BalanceHandler) limitsFor(c *gin.Context) models.RequestLimits {
+	h.limitsMutex.RLock()
+	limits := h.limits
+	h.limitsMutex.RUnlock()
+
+	value, _ := c.Get("api_key")
+	if apiKey, ok := value.(*models.APIKey); ok && apiKey.Limits != nil {
+		if apiKey.Limits.MaxBodyBytes > 0 {
+			limits.MaxBodyBytes = apiKey.Limits.MaxBodyBytes
+		}
+		if apiKey.Limits.MaxWallets > 0 {
+			limits.MaxWallets = apiKey.Limits.MaxWallets
+		}
+	}
+	return limits
+}
+
+// GetBalance handles POST /api/v1/balances and legacy POST /api/get-balance requests
 func (h *BalanceHandler) GetBalance(c *gin.Context) {
 	// Get logger with context
 	log := logger.GetLogger().WithContext(c.Request.Context())
 
 	log.Info("Processing balance request",
-		zap.String("endpoint", "/api/get-balance"),
+		zap.String("endpoint", c.FullPath()),
 		zap.String("method", "POST"),
 	)
 
-	var req models.BalanceRequest
+	var req v1.BalanceRequest
+	limits := h.limitsFor(c)
+
+	// Reject oversized bodies before reading them when the length is known
+	if limits.MaxBodyBytes > 0 {
+		if c.Request.ContentLength > limits.MaxBodyBytes {
+			h.rejectBodyTooLarge(c, log, limits.MaxBodyBytes)
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limits.MaxBodyBytes)
+	}
 
 	// Bind JSON request without validation
 	if err := c.ShouldBindJSON(&req); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.rejectBodyTooLarge(c, log, limits.MaxBodyBytes)
+			return
+		}
+
 		log.Warn("Invalid JSON in request",
 			zap.Error(err),
 			zap.String("content_type", c.GetHeader("Content-Type")),
@@ -52,8 +113,100 @@ func (h *BalanceHandler) GetBalance(c *gin.Context) {
 		return
 	}
 
+	if !v1.ValidFormat(req.Format) {
+		rejectFormat(c, log, req.Format)
+		return
+	}
+
+	response, ok := h.fetchBalances(c, log, req.Wallets, limits.MaxWallets)
+	if !ok {
+		return
+	}
+
+	// Some wallets failing is reported per wallet with a Multi-Status response
+	status := http.StatusOK
+	if response.Partial {
+		status = http.StatusMultiStatus
+	}
+	if req.Format == v1.FormatMap {
+		c.JSON(status, v1.NewBalanceMapResponse(response))
+		return
+	}
+	c.JSON(status, v1.NewBalanceResponse(response))
+}
+
+// GetWalletBalance handles GET /api/v1/wallets/{address}/balance requests. The
+// response can be cached by clients and CDNs until the balance leaves the cache.
+func (h *BalanceHandler) GetWalletBalance(c *gin.Context) {
+	log := logger.GetLogger().WithContext(c.Request.Context())
+
+	log.Info("Processing balance request",
+		zap.String("endpoint", "/api/v1/wallets/:address/balance"),
+		zap.String("method", "GET"),
+	)
+
+	response, ok := h.fetchBalances(c, log, []string{c.Param("address")}, 0)
+	if !ok {
+		return
+	}
+
+	balance := response.Balances[0]
+	if balance.Status == models.WalletStatusError {
+		appErr := models.NewAppErrorWithDetails(balance.ErrorCode, "Failed to fetch balance", balance.Error)
+		models.HandleError(c, appErr, log)
+		return
+	}
+
+	writeCacheable(c, h.publicCache, response.Balances, http.StatusOK, "wallet", v1.NewWalletBalance(balance))
+}
+
+// ListBalances handles GET /api/v1/balances?wallets=a,b,c requests; the response
+// has the body of POST /api/v1/balances and can be cached like GetWalletBalance
+func (h *BalanceHandler) ListBalances(c *gin.Context) {
+	log := logger.GetLogger().WithContext(c.Request.Context())
+
+	log.Info("Processing balance request",
+		zap.String("endpoint", "/api/v1/balances"),
+		zap.String("method", "GET"),
+	)
+
+	var wallets []string
+	for _, value := range c.QueryArray("wallets") {
+		for _, wallet := range strings.Split(value, ",") {
+			if wallet = strings.TrimSpace(wallet); wallet != "" {
+				wallets = append(wallets, wallet)
+			}
+		}
+	}
+
+	format := c.Query("format")
+	if !v1.ValidFormat(format) {
+		rejectFormat(c, log, format)
+		return
+	}
+
+	response, ok := h.fetchBalances(c, log, wallets, h.limitsFor(c).MaxWallets)
+	if !ok {
+		return
+	}
+
+	status := http.StatusOK
+	if response.Partial {
+		status = http.StatusMultiStatus
+	}
+	if format == v1.FormatMap {
+		writeCacheable(c, h.publicCache, response.Balances, status, v1.FormatMap, v1.NewBalanceMapResponse(response))
+		return
+	}
+	writeCacheable(c, h.publicCache, response.Balances, status, v1.FormatList, v1.NewBalanceResponse(response))
+}
+
+// fetchBalances validates wallets, charges their cost and fetches their balances.
+// A positive maxWallets bounds the wallet count. When it returns false, the error
+// response has been written.
+func (h *BalanceHandler) fetchBalances(c *gin.Context, log *logger.Logger, wallets []string, maxWallets int) (*models.BalanceResponse, bool) {
 	// Validate request data
-	if len(req.Wallets) == 0 {
+	if len(wallets) == 0 {
 		log.Warn("Empty wallets array in request")
 
 		appErr := models.NewAppErrorWithDetails(
@@ -62,80 +215,175 @@ func (h *BalanceHandler) GetBalance(c *gin.Context) {
 			"At least one wallet address must be provided",
 		)
 		models.HandleError(c, appErr, log)
-		return
+		return nil, false
+	}
+
+	if maxWallets > 0 && len(wallets) > maxWallets {
+		log.Warn("Too many wallets in request",
+			zap.Int("wallet_count", len(wallets)),
+			zap.Int("max_wallets", maxWallets),
+		)
+
+		appErr := models.NewAppErrorWithDetails(
+			models.ErrorCodeTooManyWallets,
+			"Too many wallets",
+			fmt.Sprintf("%d wallets requested, at most %d allowed", len(wallets), maxWallets),
+		).WithContext("wallet_count", len(wallets)).WithContext("max_wallets", maxWallets)
+		models.HandleError(c, appErr, log)
+		return nil, false
 	}
 
 	log.Debug("Validating wallet addresses",
-		zap.Int("wallet_count", len(req.Wallets)),
+		zap.Int("wallet_count", len(wallets)),
 	)
 
-	// Validate wallet addresses format
-	for i, wallet := range req.Wallets {
-		if !isValidSolanaAddress(wallet) {
-			log.Warn("Invalid wallet address format",
-				zap.String("wallet_address", wallet),
-				zap.Int("wallet_index", i),
-			)
-
-			appErr := models.NewAppErrorWithDetails(
-				models.ErrorCodeInvalidWallet,
-				"Invalid wallet address format",
-				"Wallet address: "+wallet,
-			).WithContext("wallet_index", i).WithContext("wallet_address", wallet)
-
-			models.HandleError(c, appErr, log)
-			return
+	// Validate every wallet address, reporting all invalid ones at once
+	addressTypes := make([]string, len(wallets))
+	var invalidIndexes []int
+	appErr := models.NewAppError(models.ErrorCodeInvalidWallet, "Invalid wallet address format")
+	for i, wallet := range wallets {
+		addressType, reason := classifySolanaAddress(wallet)
+		if reason != "" {
+			invalidIndexes = append(invalidIndexes, i)
+			appErr.WithInvalidParam(fmt.Sprintf("wallets[%d]", i), wallet, reason)
+			continue
 		}
+		addressTypes[i] = addressType
+	}
+	if len(invalidIndexes) > 0 {
+		log.Warn("Invalid wallet address format",
+			zap.Ints("wallet_indexes", invalidIndexes),
+		)
+
+		appErr.Details = fmt.Sprintf("Invalid wallet addresses at indexes %s", joinIndexes(invalidIndexes))
+		appErr.WithContext("wallet_indexes", invalidIndexes)
+		models.HandleError(c, appErr, log)
+		return nil, false
+	}
+
+	// Duplicate addresses are fetched once, so they are counted and charged once
+	walletCount := countUnique(wallets)
+
+	// Record the wallet count for tenant usage and metrics
+	c.Set("wallet_count", walletCount)
+
+	// Charge the per-wallet cost before any RPC credits are spent
+	if !ratelimiter.ChargeWallets(c, walletCount) {
+		log.Warn("Rate limit units exhausted by wallet cost",
+			zap.Int("wallet_count", walletCount),
+		)
+		return nil, false
 	}
 
 	log.Info("Fetching balances from service",
-		zap.Strings("wallet_addresses", req.Wallets),
+		zap.Strings("wallet_addresses", wallets),
 	)
 
 	// Get balances from service
-	response, err := h.balanceService.GetBalances(req.Wallets)
+	response, err := h.balanceService.GetBalances(c.Request.Context(), wallets)
 	if err != nil {
 		log.Error("Failed to fetch balances from service",
 			zap.Error(err),
-			zap.Strings("wallet_addresses", req.Wallets),
+			zap.Strings("wallet_addresses", wallets),
 		)
 
 		appErr := models.NewAppErrorWithCause(
 			models.ErrorCodeInternalError,
 			"Failed to fetch balances",
 			err,
-		).WithContext("wallet_addresses", req.Wallets)
+		).WithContext("wallet_addresses", wallets)
 
 		models.HandleError(c, appErr, log)
-		return
+		return nil, false
 	}
 
+	for i := range response.Balances {
+		response.Balances[i].AddressType = addressTypes[i]
+	}
+
+	// Charge cache misses that reached the RPC before headers are written
+	c.Set("rpc_fetches", response.RPCFetches)
+	c.Set("cache_hits", response.CacheHits)
+	ratelimiter.ChargeRPCMisses(c, response.RPCFetches)
+
 	// Log successful response
 	log.Info("Balance request completed successfully",
 		zap.Int("balance_count", len(response.Balances)),
 		zap.Bool("all_cached", response.Cached),
+		zap.Bool("partial", response.Partial),
 	)
 
-	// Return successful response
-	c.JSON(http.StatusOK, response)
+	return response, true
 }
 
-// isValidSolanaAddress validates Solana wallet address format
-// Solana addresses are base58 encoded and typically 32-44 characters long
-func isValidSolanaAddress(address string) bool {
-	// Basic validation: check length and characters
+// rejectFormat responds that format is not a response format
+func rejectFormat(c *gin.Context, log *logger.Logger, format string) {
+	appErr := models.NewAppErrorWithDetails(
+		models.ErrorCodeInvalidRequest,
+		"Invalid response format",
+		fmt.Sprintf("format must be %q or %q, got %q", v1.FormatList, v1.FormatMap, format),
+	)
+	models.HandleError(c, appErr, log)
+}
+
+// rejectBodyTooLarge responds that the request body exceeds maxBytes
+func (h *BalanceHandler) rejectBodyTooLarge(c *gin.Context, log *logger.Logger, maxBytes int64) {
+	log.Warn("Request body too large",
+		zap.Int64("content_length", c.Request.ContentLength),
+		zap.Int64("max_body_bytes", maxBytes),
+	)
+
+	appErr := models.NewAppErrorWithDetails(
+		models.ErrorCodeRequestTooLarge,
+		"Request body too large",
+		fmt.Sprintf("Request body must not exceed %d bytes", maxBytes),
+	).WithContext("max_body_bytes", maxBytes)
+	models.HandleError(c, appErr, log)
+}
+
+// countUnique returns the number of distinct addresses
+func countUnique(addresses []string) int {
+	seen := make(map[string]struct{}, len(addresses))
+	for _, address := range addresses {
+		seen[address] = struct{}{}
+	}
+	return len(seen)
+}
+
+// base58Alphabet is the alphabet of Solana addresses
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// classifySolanaAddress validates a Solana address and returns its address type,
+// or the reason it is invalid. Valid addresses are base58 encoded 32-byte public
+// keys, 32-44 characters long.
+func classifySolanaAddress(address string) (string, string) {
 	if len(address) < 32 || len(address) > 44 {
-		return false
+		return "", fmt.Sprintf("length %d is not between 32 and 44 characters", len(address))
 	}
 
-	// Check if address contains only valid base58 characters
-	// Base58 alphabet: 123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz
-	validChars := "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
-	for _, char := range address {
-		if !strings.ContainsRune(validChars, char) {
-			return false
+	// Report the offending character rather than a generic decoding error
+	for i, char := range address {
+		if !strings.ContainsRune(base58Alphabet, char) {
+			return "", fmt.Sprintf("invalid base58 character %q at position %d", char, i)
 		}
 	}
 
-	return true
+	publicKey, err := solana.PublicKeyFromBase58(address)
+	if err != nil {
+		return "", "does not decode to a 32-byte public key"
+	}
+
+	if publicKey.IsOnCurve() {
+		return models.AddressTypeWallet, ""
+	}
+	return models.AddressTypePDA, ""
+}
+
+// joinIndexes formats indexes as a comma-separated list
+func joinIndexes(indexes []int) string {
+	parts := make([]string, len(indexes))
+	for i, index := range indexes {
+		parts[i] = strconv.Itoa(index)
+	}
+	return strings.Join(parts, ", ")
 }
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"solana-balance-api/internal/models"
+	v1 "solana-balance-api/internal/models/v1"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Addresses of known type: sha256 digests that are and are not ed25519 points
+const (
+	onCurveAddress  = "67WKXSxm4oc149PvQjdXLacKFZpK5DyYdqBwpiVydJbb"
+	offCurveAddress = "8RBsoeyoRwajj86MZfZE6gMDJQVYGYcdSfx1zxqxNHbr"
+)
+
+// stubBalanceService returns a balance of 1 SOL for every address, fetched 2s ago
+// and cached for another 8s
+type stubBalanceService struct{}
+
+func (stubBalanceService) GetBalances(ctx context.Context, addresses []string) (*models.BalanceResponse, error) {
+	response := &models.BalanceResponse{}
+	now := time.Now()
+	for _, address := range addresses {
+		response.Balances = append(response.Balances, models.WalletBalance{
+			Address:   address,
+			Balance:   1,
+			Status:    models.WalletStatusOK,
+			FetchedAt: now.Add(-2 * time.Second),
+			ExpiresAt: now.Add(8 * time.Second),
+		})
+	}
+	return response, nil
+}
+
+func (s stubBalanceService) GetBalance(ctx context.Context, address string) (*models.WalletBalance, error) {
+	response, _ := s.GetBalances(ctx, []string{address})
+	return &response.Balances[0], nil
+}
+
+func TestClassifySolanaAddress(t *testing.T) {
+	tests := []struct {
+		address     string
+		addressType string
+		invalid     bool
+	}{
+		{address: onCurveAddress, addressType: models.AddressTypeWallet},
+		{address: offCurveAddress, addressType: models.AddressTypePDA},
+		{address: "1111111111111111111111111111111", invalid: true},              // Too short
+		{address: "111111111111111111111111111111111", invalid: true},            // Decodes to 33 bytes
+		{address: "0RBsoeyoRwajj86MZfZE6gMDJQVYGYcdSfx1zxqxNHbr", invalid: true}, // '0' is not base58
+	}
+
+	for _, tt := range tests {
+		addressType, reason := classifySolanaAddress(tt.address)
+		if tt.invalid {
+			assert.NotEmpty(t, reason, tt.address)
+			continue
+		}
+		assert.Empty(t, reason, tt.address)
+		assert.Equal(t, tt.addressType, addressType, tt.address)
+	}
+}
+
+func TestGetBalanceValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/get-balance", NewBalanceHandler(stubBalanceService{}).GetBalance)
+
+	post := func(wallets ...string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(models.BalanceRequest{Wallets: wallets})
+		req := httptest.NewRequest(http.MethodPost, "/api/get-balance", strings.NewReader(string(body)))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("ReportsEveryInvalidAddress", func(t *testing.T) {
+		w := post("short", onCurveAddress, "111111111111111111111111111111111")
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response models.ErrorResponseWithCorrelation
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, models.ErrorCodeInvalidWallet, response.Error.Code)
+		require.Len(t, response.Error.InvalidParams, 2)
+		assert.Equal(t, "wallets[0]", response.Error.InvalidParams[0].Name)
+		assert.Equal(t, "wallets[2]", response.Error.InvalidParams[1].Name)
+		assert.Contains(t, response.Error.Details, "0, 2")
+	})
+
+	t.Run("TooManyWallets", func(t *testing.T) {
+		handler := NewBalanceHandler(stubBalanceService{})
+		handler.SetLimits(models.RequestLimits{MaxWallets: 1, MaxBodyBytes: 1 << 10})
+		limited := gin.New()
+		limited.Use(func(c *gin.Context) {
+			if c.GetHeader("X-Test-Key") == "large" {
+				c.Set("api_key", &models.APIKey{Limits: &models.RequestLimits{MaxWallets: 2}})
+			}
+		})
+		limited.POST("/api/get-balance", handler.GetBalance)
+
+		send := func(key string, wallets ...string) *httptest.ResponseRecorder {
+			body, _ := json.Marshal(models.BalanceRequest{Wallets: wallets})
+			req := httptest.NewRequest(http.MethodPost, "/api/get-balance", strings.NewReader(string(body)))
+			req.Header.Set("X-Test-Key", key)
+			w := httptest.NewRecorder()
+			limited.ServeHTTP(w, req)
+			return w
+		}
+
+		w := send("", onCurveAddress, offCurveAddress)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), string(models.ErrorCodeTooManyWallets))
+
+		// The key's override raises the wallet limit but keeps the default body limit
+		assert.Equal(t, http.StatusOK, send("large", onCurveAddress, offCurveAddress).Code)
+		w = send("large", strings.Repeat(onCurveAddress, 30))
+		require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Contains(t, w.Body.String(), string(models.ErrorCodeRequestTooLarge))
+	})
+
+	t.Run("MapFormat", func(t *testing.T) {
+		body := `{"wallets":["` + onCurveAddress + `","` + offCurveAddress + `","` + onCurveAddress + `"],"format":"map"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/get-balance", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response v1.BalanceMapResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Balances, 2)
+		assert.Equal(t, models.AddressTypePDA, response.Balances[offCurveAddress].AddressType)
+	})
+
+	t.Run("AddressType", func(t *testing.T) {
+		w := post(onCurveAddress, offCurveAddress)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response v1.BalanceResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Balances, 2)
+		assert.Equal(t, models.AddressTypeWallet, response.Balances[0].AddressType)
+		assert.Equal(t, models.AddressTypePDA, response.Balances[1].AddressType)
+	})
+}
+
+func TestBalanceLookups(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewBalanceHandler(stubBalanceService{})
+	router := gin.New()
+	router.GET("/api/v1/wallets/:address/balance", handler.GetWalletBalance)
+	router.GET("/api/v1/balances", handler.ListBalances)
+
+	get := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("WalletBalance", func(t *testing.T) {
+		w := get("/api/v1/wallets/"+onCurveAddress+"/balance", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Regexp(t, `^private, max-age=[67]$`, w.Header().Get("Cache-Control"))
+		assert.Empty(t, w.Header().Get("Vary"))
+		assert.NotEmpty(t, w.Header().Get("Last-Modified"))
+
+		var balance v1.WalletBalance
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &balance))
+		assert.Equal(t, onCurveAddress, balance.Address)
+		assert.Equal(t, models.AddressTypeWallet, balance.AddressType)
+
+		etag := w.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+		w = get("/api/v1/wallets/"+onCurveAddress+"/balance", map[string]string{"If-None-Match": etag})
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+
+		w = get("/api/v1/wallets/"+onCurveAddress+"/balance", map[string]string{"If-Modified-Since": time.Now().UTC().Format(http.TimeFormat)})
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		w = get("/api/v1/wallets/"+onCurveAddress+"/balance", map[string]string{"If-Modified-Since": time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Balances", func(t *testing.T) {
+		w := get("/api/v1/balances?wallets="+onCurveAddress+","+offCurveAddress, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response v1.BalanceResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Balances, 2)
+		assert.Equal(t, offCurveAddress, response.Balances[1].Address)
+
+		// Other representations of the same balances have other ETags
+		mapped := get("/api/v1/balances?format=map&wallets="+onCurveAddress+","+offCurveAddress, nil)
+		require.Equal(t, http.StatusOK, mapped.Code)
+		assert.NotEqual(t, w.Header().Get("ETag"), mapped.Header().Get("ETag"))
+
+		w = get("/api/v1/balances?wallets="+onCurveAddress+",invalid", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = get("/api/v1/balances", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("PublicCache", func(t *testing.T) {
+		handler.SetPublicCache(true)
+		defer handler.SetPublicCache(false)
+
+		w := get("/api/v1/wallets/"+onCurveAddress+"/balance", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Regexp(t, `^public, max-age=[67]$`, w.Header().Get("Cache-Control"))
+		assert.Equal(t, "Authorization, Accept-Language", w.Header().Get("Vary"))
+	})
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"solana-balance-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeCacheable writes body with ETag, Last-Modified and Cache-Control headers
+// derived from the cache windows of its balances, and answers conditional requests
+// with 304 Not Modified. variant distinguishes representations of the same balances.
+// Responses with failed wallets are not cacheable. Responses are private unless
+// public is set, since the routes are authenticated.
+func writeCacheable(c *gin.Context, public bool, balances []models.WalletBalance, status int, variant string, body interface{}) {
+	now := time.Now()
+
+	var lastModified time.Time
+	var expiresAt time.Time
+	for _, balance := range balances {
+		if balance.Status == models.WalletStatusError || balance.ExpiresAt.IsZero() {
+			c.Header("Cache-Control", "no-store")
+			c.JSON(status, body)
+			return
+		}
+		if balance.FetchedAt.After(lastModified) {
+			lastModified = balance.FetchedAt
+		}
+		if expiresAt.IsZero() || balance.ExpiresAt.Before(expiresAt) {
+			expiresAt = balance.ExpiresAt
+		}
+	}
+
+	maxAge := int(expiresAt.Sub(now) / time.Second)
+	if maxAge < 0 {
+		maxAge = 0
+	}
+
+	etag := balancesETag(balances, variant)
+	c.Header("ETag", etag)
+	c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	if public {
+		// Shared caches must key on the credentials and language of the request
+		c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", maxAge))
+		c.Header("Vary", "Authorization, Accept-Language")
+	} else {
+		c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", maxAge))
+	}
+
+	if notModified(c, etag, lastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(status, body)
+}
+
+// balancesETag returns a weak ETag over the balances. Responses differing only in
+// whether they were served from the cache are equivalent, hence weak.
+func balancesETag(balances []models.WalletBalance, variant string) string {
+	hash := sha256.New()
+	hash.Write([]byte(variant))
+	for _, balance := range balances {
+		fmt.Fprintf(hash, "\n%s %s %s", balance.Address, balance.Status, strconv.FormatFloat(balance.Balance, 'g', -1, 64))
+	}
+	return `W/"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when no ETags are sent
+func notModified(c *gin.Context, etag string, lastModified time.Time) bool {
+	if header := c.GetHeader("If-None-Match"); header != "" {
+		for _, candidate := range strings.Split(header, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	if header := c.GetHeader("If-Modified-Since"); header != "" {
+		since, err := http.ParseTime(header)
+		return err == nil && !lastModified.Truncate(time.Second).After(since)
+	}
+	return false
+}
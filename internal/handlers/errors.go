@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+
+	"solana-balance-api/internal/models"
+	"solana-balance-api/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorsHandler serves the error catalog
+type ErrorsHandler struct{}
+
+// NewErrorsHandler creates a new ErrorsHandler instance
+func NewErrorsHandler() *ErrorsHandler {
+	return &ErrorsHandler{}
+}
+
+// GetCatalog handles GET /api/v1/errors requests, listing every error code with its
+// HTTP status, retryability and documentation URL in the client's language
+func (h *ErrorsHandler) GetCatalog(c *gin.Context) {
+	lang := models.NegotiateLanguage(c.GetHeader("Accept-Language"))
+
+	c.Header("Content-Language", lang)
+	c.Header("Vary", "Accept-Language")
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.JSON(http.StatusOK, models.ErrorCatalogResponse{
+		Language: lang,
+		Errors:   models.ErrorCatalog(lang),
+	})
+}
+
+// GetError handles GET /api/v1/errors/:code requests, the documentation of one error code
+func (h *ErrorsHandler) GetError(c *gin.Context) {
+	log := logger.GetLogger().WithContext(c.Request.Context())
+	lang := models.NegotiateLanguage(c.GetHeader("Accept-Language"))
+
+	info, found := models.LookupError(models.ErrorCode(c.Param("code")), lang)
+	if !found {
+		appErr := models.NewAppErrorWithDetails(
+			models.ErrorCodeInvalidRequest,
+			"Unknown error code",
+			"See GET /api/v1/errors for every error code",
+		)
+		models.HandleError(c, appErr, log)
+		return
+	}
+
+	c.Header("Content-Language", lang)
+	c.Header("Vary", "Accept-Language")
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.JSON(http.StatusOK, info)
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"solana-balance-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	legacy := func(c *gin.Context) { c.Header("Deprecation", "true") }
+	NewRouter(nil, nil, nil, nil, nil).SetupRoutes(engine, legacy)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	t.Run("Versioned", func(t *testing.T) {
+		w := serve("/api/v1/errors/INVALID_API_KEY")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Deprecation"))
+
+		var info models.ErrorInfo
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+		assert.Equal(t, "/api/v1/errors/INVALID_API_KEY", info.DocsURL)
+
+		assert.Equal(t, http.StatusOK, serve("/api/v1/errors").Code)
+		assert.Equal(t, http.StatusBadRequest, serve("/api/v1/errors/NO_SUCH_ERROR").Code)
+	})
+
+	t.Run("Legacy", func(t *testing.T) {
+		w := serve("/api/errors")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "true", w.Header().Get("Deprecation"))
+		assert.Equal(t, "true", serve("/api/errors/INVALID_API_KEY").Header().Get("Deprecation"))
+	})
+}
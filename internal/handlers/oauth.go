@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"solana-balance-api/internal/models"
+	"solana-balance-api/internal/services"
+	"solana-balance-api/pkg/audit"
+	"solana-balance-api/pkg/ipfilter"
+	"solana-balance-api/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// OAuthHandler implements the local OAuth2 client-credentials token endpoint
+type OAuthHandler struct {
+	apiKeys services.AuthServiceInterface
+	tokens  *services.JWTAuthService
+}
+
+// NewOAuthHandler creates a new OAuthHandler. API keys act as client secrets.
+func NewOAuthHandler(apiKeys services.AuthServiceInterface, tokens *services.JWTAuthService) *OAuthHandler {
+	return &OAuthHandler{
+		apiKeys: apiKeys,
+		tokens:  tokens,
+	}
+}
+
+// Token handles POST /oauth/token requests (grant_type=client_credentials).
+// Credentials are read from HTTP Basic auth or the client_id/client_secret form fields.
+func (h *OAuthHandler) Token(c *gin.Context) {
+	log := logger.GetLogger().WithContext(c.Request.Context())
+
+	// Token responses must never be cached
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	if grantType := c.PostForm("grant_type"); grantType != "client_credentials" {
+		h.oauthError(c, http.StatusBadRequest, "unsupported_grant_type", "Only client_credentials is supported")
+		return
+	}
+
+	clientID, clientSecret, hasBasic := c.Request.BasicAuth()
+	if !hasBasic {
+		clientID = c.PostForm("client_id")
+		clientSecret = c.PostForm("client_secret")
+	}
+
+	if clientSecret == "" {
+		h.oauthError(c, http.StatusUnauthorized, "invalid_client", "Client authentication is required")
+		return
+	}
+
+	apiKey, err := h.apiKeys.ValidateAPIKey(c.Request.Context(), clientSecret)
+	if err != nil || (clientID != "" && clientID != apiKey.ID.Hex()) {
+		log.Warn("Client credentials rejected",
+			zap.String("audit_event", "token_client_rejected"),
+			zap.String("client_id", clientID),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		audit.RecordRequest(c, audit.EventTokenClientRejected, audit.OutcomeFailure, map[string]string{
+			"client_id": clientID,
+		})
+		h.oauthError(c, http.StatusUnauthorized, "invalid_client", "Client authentication failed")
+		return
+	}
+
+	if allowed, _ := ipfilter.IsAllowed(apiKey.AllowedCIDRs, c.ClientIP()); !allowed {
+		log.Warn("Token requested from IP outside key allowlist",
+			zap.String("audit_event", "ip_not_allowed"),
+			zap.String("api_key_id", apiKey.ID.Hex()),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		event := audit.RequestEvent(c, audit.EventIPNotAllowed, audit.OutcomeFailure)
+		event.ActorKeyID = apiKey.ID.Hex()
+		event.TenantID = apiKey.TenantID
+		event.Details = map[string]string{"path": c.Request.URL.Path}
+		audit.Record(event)
+		h.oauthError(c, http.StatusUnauthorized, "invalid_client", "Client is not allowed from this IP address")
+		return
+	}
+
+	scopes, ok := grantScopes(apiKey, strings.Fields(c.PostForm("scope")))
+	if !ok {
+		h.oauthError(c, http.StatusBadRequest, "invalid_scope", "Requested scope exceeds the scopes granted to the client")
+		return
+	}
+
+	token, ttl, err := h.tokens.IssueToken(apiKey, scopes)
+	if err != nil {
+		log.Error("Failed to issue access token", zap.Error(err))
+		h.oauthError(c, http.StatusInternalServerError, "server_error", "Failed to issue access token")
+		return
+	}
+
+	log.Info("Access token issued",
+		zap.String("audit_event", "token_issued"),
+		zap.String("api_key_id", apiKey.ID.Hex()),
+		zap.Strings("scopes", scopes),
+		zap.Duration("ttl", ttl),
+	)
+	event := audit.RequestEvent(c, audit.EventTokenIssued, audit.OutcomeSuccess)
+	event.ActorKeyID = apiKey.ID.Hex()
+	event.TenantID = apiKey.TenantID
+	event.Details = map[string]string{
+		"scopes": strings.Join(scopes, " "),
+		"ttl":    ttl.String(),
+	}
+	audit.Record(event)
+
+	c.JSON(http.StatusOK, models.TokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(ttl.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	})
+}
+
+// JWKS handles GET /.well-known/jwks.json with the public keys of locally issued tokens
+func (h *OAuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.tokens.PublicJWKS())
+}
+
+// oauthError writes an RFC 6749 error response
+func (h *OAuthHandler) oauthError(c *gin.Context, status int, code, description string) {
+	if code == "invalid_client" {
+		c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+	c.JSON(status, models.OAuthErrorResponse{
+		Error:            code,
+		ErrorDescription: description,
+	})
+}
+
+// grantScopes returns the scopes to put in the token. With no request, all key scopes
+// are granted; otherwise every requested scope must be known and held by the key.
+func grantScopes(apiKey *models.APIKey, requested []string) ([]string, bool) {
+	if len(requested) == 0 {
+		return apiKey.EffectiveScopes(), true
+	}
+
+	for _, scope := range requested {
+		if !models.IsValidScope(scope) || !apiKey.HasScope(scope) {
+			return nil, false
+		}
+	}
+	return requested, true
+}
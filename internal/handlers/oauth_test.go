@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"testing"
+
+	"solana-balance-api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrantScopes(t *testing.T) {
+	apiKey := &models.APIKey{Scopes: []string{models.ScopeBalanceRead, models.ScopeTokensRead}}
+
+	tests := []struct {
+		name      string
+		apiKey    *models.APIKey
+		requested []string
+		granted   []string
+		ok        bool
+	}{
+		{name: "AllKeyScopesByDefault", apiKey: apiKey, granted: apiKey.Scopes, ok: true},
+		{name: "Subset", apiKey: apiKey, requested: []string{models.ScopeTokensRead}, granted: []string{models.ScopeTokensRead}, ok: true},
+		{name: "NotHeld", apiKey: apiKey, requested: []string{models.ScopeHistoryRead}},
+		{name: "Unknown", apiKey: &models.APIKey{Scopes: []string{models.ScopeAdmin}}, requested: []string{"balance:write"}},
+		{name: "AdminGrantsKnownScopes", apiKey: &models.APIKey{Scopes: []string{models.ScopeAdmin}}, requested: []string{models.ScopeWebhooksWrite}, granted: []string{models.ScopeWebhooksWrite}, ok: true},
+		{name: "LegacyKeyGetsDefaultScopes", apiKey: &models.APIKey{}, granted: models.DefaultScopes, ok: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			granted, ok := grantScopes(tt.apiKey, tt.requested)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.granted, granted)
+		})
+	}
+}
@@ -10,13 +10,21 @@ import (
 type Router struct {
 	balanceHandler *BalanceHandler
 	healthHandler  *HealthHandler
+	oauthHandler   *OAuthHandler
+	adminHandler   *AdminHandler
+	usageHandler   *UsageHandler
+	errorsHandler  *ErrorsHandler
 }
 
 // NewRouter creates a new Router instance with all handlers
-func NewRouter(balanceService services.BalanceServiceInterface, healthHandler *HealthHandler) *Router {
+func NewRouter(balanceService services.BalanceServiceInterface, healthHandler *HealthHandler, oauthHandler *OAuthHandler, adminHandler *AdminHandler, usageHandler *UsageHandler) *Router {
 	return &Router{
 		balanceHandler: NewBalanceHandler(balanceService),
 		healthHandler:  healthHandler,
+		oauthHandler:   oauthHandler,
+		adminHandler:   adminHandler,
+		usageHandler:   usageHandler,
+		errorsHandler:  NewErrorsHandler(),
 	}
 }
 
@@ -25,14 +33,35 @@ func (r *Router) GetBalanceHandler() *BalanceHandler {
 	return r.balanceHandler
 }
 
-// SetupRoutes configures all API routes
-func (r *Router) SetupRoutes(engine *gin.Engine) {
+// GetAdminHandler returns the admin handler for external access
+func (r *Router) GetAdminHandler() *AdminHandler {
+	return r.adminHandler
+}
+
+// GetUsageHandler returns the usage handler for external access
+func (r *Router) GetUsageHandler() *UsageHandler {
+	return r.usageHandler
+}
+
+// SetupRoutes configures the balance and error catalog routes of every API version.
+// legacy runs in front of the deprecated unversioned aliases, e.g. to add deprecation
+// headers.
+func (r *Router) SetupRoutes(engine *gin.Engine, legacy ...gin.HandlerFunc) {
 	// API v1 routes
-	api := engine.Group("/api")
+	v1 := engine.Group("/api/v1")
+	{
+		v1.POST("/balances", r.balanceHandler.GetBalance)
+		v1.GET("/balances", r.balanceHandler.ListBalances)
+		v1.GET("/wallets/:address/balance", r.balanceHandler.GetWalletBalance)
+	}
+	r.SetupErrorRoutes(v1)
+
+	// Unversioned aliases of v1 routes
+	api := engine.Group("/api", legacy...)
 	{
-		// Balance endpoints
 		api.POST("/get-balance", r.balanceHandler.GetBalance)
 	}
+	r.SetupErrorRoutes(api)
 }
 
 // SetupHealthRoutes configures health check routes
@@ -46,3 +75,19 @@ func (r *Router) SetupHealthRoutes(engine *gin.Engine) {
 		health.GET("/db", r.healthHandler.GetDatabaseHealth) // Database health
 	}
 }
+
+// SetupErrorRoutes configures the public error catalog of an API version
+func (r *Router) SetupErrorRoutes(api *gin.RouterGroup) {
+	api.GET("/errors", r.errorsHandler.GetCatalog)
+	api.GET("/errors/:code", r.errorsHandler.GetError)
+}
+
+// SetupOAuthRoutes configures the client-credentials token endpoint and public JWKS
+func (r *Router) SetupOAuthRoutes(engine *gin.Engine) {
+	if r.oauthHandler == nil {
+		return
+	}
+
+	engine.POST("/oauth/token", r.oauthHandler.Token)
+	engine.GET("/.well-known/jwks.json", r.oauthHandler.JWKS)
+}
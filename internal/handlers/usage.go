@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"solana-balance-api/internal/models"
+	"solana-balance-api/internal/services"
+	"solana-balance-api/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// defaultUsageRange is the report range used when "from" is omitted
+const defaultUsageRange = 24 * time.Hour
+
+// UsageHandler handles per-key usage reporting
+type UsageHandler struct {
+	usage services.UsageServiceInterface
+}
+
+// NewUsageHandler creates a new UsageHandler instance
+func NewUsageHandler(usage services.UsageServiceInterface) *UsageHandler {
+	return &UsageHandler{
+		usage: usage,
+	}
+}
+
+// GetUsage handles GET /api/usage requests for the caller's own API key
+func (h *UsageHandler) GetUsage(c *gin.Context) {
+	log := logger.GetLogger().WithContext(c.Request.Context())
+
+	keyID, err := primitive.ObjectIDFromHex(c.GetString("api_key_id"))
+	if err != nil {
+		appErr := models.NewAppErrorWithDetails(
+			models.ErrorCodeInvalidRequest,
+			"Usage is not available for this credential",
+			"Usage is metered per API key",
+		)
+		models.HandleError(c, appErr, log)
+		return
+	}
+
+	query, appErr := parseUsageQuery(c)
+	if appErr != nil {
+		models.HandleError(c, appErr, log)
+		return
+	}
+	query.KeyID = keyID
+
+	h.respond(c, query, log)
+}
+
+// GetAllUsage handles GET /api/admin/usage requests covering all keys, optionally
+// filtered by key_id or tenant_id
+func (h *UsageHandler) GetAllUsage(c *gin.Context) {
+	log := logger.GetLogger().WithContext(c.Request.Context())
+
+	query, appErr := parseUsageQuery(c)
+	if appErr != nil {
+		models.HandleError(c, appErr, log)
+		return
+	}
+
+	if keyID := c.Query("key_id"); keyID != "" {
+		id, err := primitive.ObjectIDFromHex(keyID)
+		if err != nil {
+			appErr := models.NewAppErrorWithDetails(
+				models.ErrorCodeInvalidRequest,
+				"Invalid API key ID",
+				"key_id must be a 24-character hex string",
+			)
+			models.HandleError(c, appErr, log)
+			return
+		}
+		query.KeyID = id
+	}
+	query.TenantID = c.Query("tenant_id")
+	query.PerKey = true
+
+	h.respond(c, query, log)
+}
+
+// respond runs the usage query and writes it as JSON or CSV
+func (h *UsageHandler) respond(c *gin.Context, query models.UsageQuery, log *logger.Logger) {
+	report, err := h.usage.Query(query)
+	if err != nil {
+		var appErr *models.AppError
+		switch err {
+		case services.ErrInvalidUsageRange:
+			appErr = models.NewAppErrorWithDetails(models.ErrorCodeInvalidRequest, "Invalid usage range", "\"to\" must be after \"from\" and the range must not exceed the maximum query range")
+		case services.ErrInvalidUsageGranularity:
+			appErr = models.NewAppErrorWithDetails(models.ErrorCodeInvalidRequest, "Invalid granularity", "granularity must be one of hour, day or month")
+		default:
+			appErr = models.NewAppErrorWithCause(models.ErrorCodeDatabaseError, "Failed to load usage", err)
+		}
+		models.HandleError(c, appErr, log)
+		return
+	}
+
+	if wantsCSV(c) {
+		writeUsageCSV(c, report)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// parseUsageQuery reads from, to and granularity from the query string.
+// Times are RFC 3339 or YYYY-MM-DD (UTC); the default range is the last 24 hours.
+func parseUsageQuery(c *gin.Context) (models.UsageQuery, *models.AppError) {
+	query := models.UsageQuery{
+		To:          time.Now().UTC(),
+		Granularity: c.DefaultQuery("granularity", models.GranularityHour),
+	}
+
+	if to := c.Query("to"); to != "" {
+		t, err := parseUsageTime(to)
+		if err != nil {
+			return query, models.NewAppErrorWithDetails(models.ErrorCodeInvalidRequest, "Invalid \"to\" time", "Use RFC 3339 or YYYY-MM-DD")
+		}
+		query.To = t
+	}
+
+	query.From = query.To.Add(-defaultUsageRange)
+	if from := c.Query("from"); from != "" {
+		t, err := parseUsageTime(from)
+		if err != nil {
+			return query, models.NewAppErrorWithDetails(models.ErrorCodeInvalidRequest, "Invalid \"from\" time", "Use RFC 3339 or YYYY-MM-DD")
+		}
+		query.From = t
+	}
+
+	return query, nil
+}
+
+// parseUsageTime parses an RFC 3339 timestamp or a YYYY-MM-DD date
+func parseUsageTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// wantsCSV reports whether the client asked for CSV via format=csv or the Accept header
+func wantsCSV(c *gin.Context) bool {
+	if format := c.Query("format"); format != "" {
+		return strings.EqualFold(format, "csv")
+	}
+	return strings.Contains(c.GetHeader("Accept"), "text/csv")
+}
+
+// writeUsageCSV writes the report rows as a CSV attachment
+func writeUsageCSV(c *gin.Context, report *models.UsageReport) {
+	filename := "usage-" + report.From.Format("20060102") + "-" + report.To.Format("20060102") + ".csv"
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"period_start", "key_id", "tenant_id", "requests", "wallets", "cache_hits", "cache_misses", "rpc_calls"})
+	for _, record := range report.Records {
+		keyID := record.KeyID
+		if keyID == "" {
+			keyID = report.KeyID
+		}
+		w.Write([]string{
+			record.PeriodStart.Format(time.RFC3339),
+			keyID,
+			record.TenantID,
+			strconv.FormatInt(record.Requests, 10),
+			strconv.FormatInt(record.Wallets, 10),
+			strconv.FormatInt(record.CacheHits, 10),
+			strconv.FormatInt(record.CacheMisses, 10),
+			strconv.FormatInt(record.RPCCalls, 10),
+		})
+	}
+	w.Flush()
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"solana-balance-api/internal/models"
+	"solana-balance-api/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// stubUsageService records the last query and reports one record per key
+type stubUsageService struct {
+	query models.UsageQuery
+}
+
+func (s *stubUsageService) RecordUsage(keyID primitive.ObjectID, tenantID string, counters models.UsageCounters) {
+}
+
+func (s *stubUsageService) Query(query models.UsageQuery) (*models.UsageReport, error) {
+	s.query = query
+	if query.Granularity != models.GranularityHour && query.Granularity != models.GranularityDay {
+		return nil, services.ErrInvalidUsageGranularity
+	}
+
+	record := models.UsageRecord{
+		PeriodStart:   query.From,
+		TenantID:      "team-a",
+		UsageCounters: models.UsageCounters{Requests: 3, Wallets: 12, CacheHits: 5, CacheMisses: 7, RPCCalls: 7},
+	}
+	report := &models.UsageReport{From: query.From, To: query.To, Granularity: query.Granularity}
+	if query.PerKey {
+		record.KeyID = "65f0c0ffee0000000000000b"
+	} else {
+		report.KeyID = query.KeyID.Hex()
+	}
+	report.Records = []models.UsageRecord{record}
+	report.Totals = record.UsageCounters
+	return report, nil
+}
+
+func TestUsageHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keyID := primitive.NewObjectID()
+	usage := &stubUsageService{}
+	handler := NewUsageHandler(usage)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("api_key_id", c.GetHeader("X-Test-Key"))
+	})
+	router.GET("/api/v1/usage", handler.GetUsage)
+	router.GET("/api/v1/admin/usage", handler.GetAllUsage)
+
+	get := func(path, key string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Test-Key", key)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("OwnUsage", func(t *testing.T) {
+		w := get("/api/v1/usage?from=2026-03-01&to=2026-03-02T12:00:00Z&granularity=day", keyID.Hex(), nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, keyID, usage.query.KeyID)
+		assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), usage.query.From)
+		assert.Equal(t, time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC), usage.query.To)
+		assert.False(t, usage.query.PerKey)
+
+		var report models.UsageReport
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		assert.EqualValues(t, 3, report.Totals.Requests)
+	})
+
+	t.Run("DefaultRange", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, get("/api/v1/usage", keyID.Hex(), nil).Code)
+		assert.Equal(t, 24*time.Hour, usage.query.To.Sub(usage.query.From))
+		assert.Equal(t, models.GranularityHour, usage.query.Granularity)
+	})
+
+	t.Run("TokenWithoutKey", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, get("/api/v1/usage", "", nil).Code)
+	})
+
+	t.Run("InvalidQuery", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, get("/api/v1/usage?from=yesterday", keyID.Hex(), nil).Code)
+		assert.Equal(t, http.StatusBadRequest, get("/api/v1/usage?granularity=week", keyID.Hex(), nil).Code)
+		assert.Equal(t, http.StatusBadRequest, get("/api/v1/admin/usage?key_id=nope", keyID.Hex(), nil).Code)
+	})
+
+	t.Run("AdminFilters", func(t *testing.T) {
+		other := primitive.NewObjectID()
+		w := get("/api/v1/admin/usage?key_id="+other.Hex()+"&tenant_id=team-a", keyID.Hex(), nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, other, usage.query.KeyID)
+		assert.Equal(t, "team-a", usage.query.TenantID)
+		assert.True(t, usage.query.PerKey)
+	})
+
+	t.Run("CSVExport", func(t *testing.T) {
+		for _, w := range []*httptest.ResponseRecorder{
+			get("/api/v1/usage?from=2026-03-01&to=2026-03-02&format=csv", keyID.Hex(), nil),
+			get("/api/v1/usage?from=2026-03-01&to=2026-03-02", keyID.Hex(), map[string]string{"Accept": "text/csv"}),
+		} {
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+			assert.Equal(t, `attachment; filename="usage-20260301-20260302.csv"`, w.Header().Get("Content-Disposition"))
+
+			rows, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+			require.NoError(t, err)
+			require.Len(t, rows, 2)
+			assert.Equal(t, []string{"period_start", "key_id", "tenant_id", "requests", "wallets", "cache_hits", "cache_misses", "rpc_calls"}, rows[0])
+			assert.Equal(t, []string{"2026-03-01T00:00:00Z", keyID.Hex(), "team-a", "3", "12", "5", "7", "7"}, rows[1])
+		}
+
+		// format=json wins over the Accept header
+		w := get("/api/v1/usage?format=json", keyID.Hex(), map[string]string{"Accept": "text/csv"})
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+	})
+
+	t.Run("AdminCSVCarriesRecordKeys", func(t *testing.T) {
+		w := get("/api/v1/admin/usage?format=csv", keyID.Hex(), nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		rows, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, "65f0c0ffee0000000000000b", rows[1][1])
+	})
+}
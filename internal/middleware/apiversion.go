@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"solana-balance-api/internal/models"
+	"solana-balance-api/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// legacySuccessors maps unversioned routes whose v1 successor was renamed; other
+// routes move under /api/v1 unchanged
+var legacySuccessors = map[string]string{
+	"/api/get-balance": "/api/v1/balances",
+}
+
+// APIVersionMiddleware serves requests with one of versions, the first being the
+// default. Clients select a version with the API-Version header ("1" or "v1");
+// versions not served on the path are rejected. The version is stored as
+// "api_version" and returned in the API-Version header.
+func APIVersionMiddleware(versions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version := versions[0]
+		if requested := c.GetHeader(models.APIVersionHeader); requested != "" {
+			requested = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(requested)), "v")
+			if !containsVersion(versions, requested) {
+				log := logger.GetLogger().WithContext(c.Request.Context())
+				log.Warn("Unsupported API version requested",
+					zap.String("api_version", requested),
+					zap.String("path", c.Request.URL.Path),
+				)
+
+				appErr := models.NewAppErrorWithDetails(
+					models.ErrorCodeUnsupportedVersion,
+					"Unsupported API version",
+					fmt.Sprintf("API version %q is not served on this path (supported: %s)", requested, strings.Join(versions, ", ")),
+				).WithContext("api_version", requested)
+				models.HandleError(c, appErr, log)
+				c.Abort()
+				return
+			}
+			version = requested
+		}
+
+		c.Set("api_version", version)
+		c.Header(models.APIVersionHeader, version)
+		c.Next()
+	}
+}
+
+// LegacyRouteMiddleware marks unversioned routes as deprecated aliases of their
+// /api/v1 successors with Deprecation, Sunset, Warning and Link headers
+func LegacyRouteMiddleware(deprecatedAt, sunset time.Time) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		successor, renamed := legacySuccessors[c.FullPath()]
+		if !renamed {
+			successor = "/api/v1" + strings.TrimPrefix(c.Request.URL.Path, "/api")
+		}
+
+		setSunsetHeaders(c, deprecatedAt, sunset,
+			fmt.Sprintf("Unversioned API paths are deprecated and stop working at %s; use %s", sunset.UTC().Format(time.RFC3339), successor))
+		c.Header("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+
+		c.Next()
+	}
+}
+
+// setSunsetHeaders writes Deprecation (RFC 9745) and Sunset (RFC 8594) headers and
+// adds a Warning
+func setSunsetHeaders(c *gin.Context, deprecatedAt, sunset time.Time, message string) {
+	c.Header("Deprecation", "@"+strconv.FormatInt(deprecatedAt.Unix(), 10))
+	c.Header("Sunset", sunset.UTC().Format(http.TimeFormat))
+	addWarning(c, message)
+}
+
+// containsVersion reports whether versions contains version
+func containsVersion(versions []string, version string) bool {
+	for _, candidate := range versions {
+		if candidate == version {
+			return true
+		}
+	}
+	return false
+}
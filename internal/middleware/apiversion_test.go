@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"solana-balance-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIVersioning(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	deprecatedAt := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	engine := gin.New()
+	ok := func(c *gin.Context) { c.String(http.StatusOK, c.GetString("api_version")) }
+	v1 := engine.Group("/api/v1", APIVersionMiddleware(models.APIVersion1))
+	v1.POST("/balances", ok)
+	v1.GET("/usage", ok)
+	legacy := engine.Group("/api", APIVersionMiddleware(models.APIVersions...), LegacyRouteMiddleware(deprecatedAt, sunset))
+	legacy.POST("/get-balance", ok)
+	legacy.GET("/usage", ok)
+
+	serve := func(method, path, version string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if version != "" {
+			req.Header.Set(models.APIVersionHeader, version)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Versioned", func(t *testing.T) {
+		w := serve(http.MethodPost, "/api/v1/balances", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, models.APIVersion1, w.Header().Get(models.APIVersionHeader))
+		assert.Empty(t, w.Header().Get("Deprecation"))
+
+		assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/api/v1/usage", "v1").Code)
+
+		w = serve(http.MethodGet, "/api/v1/usage", "2")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), string(models.ErrorCodeUnsupportedVersion))
+	})
+
+	t.Run("Legacy", func(t *testing.T) {
+		w := serve(http.MethodPost, "/api/get-balance", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, models.APIVersion1, w.Body.String())
+		assert.Equal(t, "@1793491200", w.Header().Get("Deprecation"))
+		assert.Equal(t, "Sat, 01 May 2027 00:00:00 GMT", w.Header().Get("Sunset"))
+		assert.Equal(t, `</api/v1/balances>; rel="successor-version"`, w.Header().Get("Link"))
+
+		w = serve(http.MethodGet, "/api/usage", "1")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `</api/v1/usage>; rel="successor-version"`, w.Header().Get("Link"))
+	})
+}
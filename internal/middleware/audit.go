@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"solana-balance-api/pkg/audit"
+	"solana-balance-api/pkg/ratelimiter"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuditMiddleware audits every request to admin routes with its outcome.
+// It must run after AuthMiddleware.
+func AdminAuditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		outcome := audit.OutcomeSuccess
+		if c.Writer.Status() >= 400 {
+			outcome = audit.OutcomeFailure
+		}
+
+		audit.RecordRequest(c, audit.EventAdminAction, outcome, map[string]string{
+			"method": c.Request.Method,
+			"route":  c.FullPath(),
+			"path":   c.Request.URL.Path,
+			"status": strconv.Itoa(c.Writer.Status()),
+		})
+	}
+}
+
+// AuditRateLimitExceeded returns a rate limiter hook that audits a client being
+// locked out for the rest of the window. limiter names the limiter, e.g. "ip".
+func AuditRateLimitExceeded(limiter string) ratelimiter.ExceededFunc {
+	return func(c *gin.Context, client string, cost int, resetTime time.Time) {
+		event := audit.RequestEvent(c, audit.EventRateLimitExceeded, audit.OutcomeFailure)
+		event.Target = client
+		event.Details = map[string]string{
+			"limiter":  limiter,
+			"cost":     strconv.Itoa(cost),
+			"reset_at": resetTime.UTC().Format(time.RFC3339),
+			"path":     c.Request.URL.Path,
+		}
+		audit.Record(event)
+	}
+}
@@ -1,10 +1,13 @@
 package middleware
 
 import (
+	"errors"
 	"strings"
 
 	"solana-balance-api/internal/models"
 	"solana-balance-api/internal/services"
+	"solana-balance-api/pkg/audit"
+	"solana-balance-api/pkg/ipfilter"
 	"solana-balance-api/pkg/logger"
 
 	"github.com/gin-gonic/gin"
@@ -35,6 +38,7 @@ func AuthMiddleware(authService services.AuthServiceInterface) gin.HandlerFunc {
 				"API key is required",
 				"Provide API key in Authorization header",
 			)
+			recordAuthFailure(c, appErr)
 			models.HandleError(c, appErr, log)
 			c.Abort()
 			return
@@ -62,6 +66,7 @@ func AuthMiddleware(authService services.AuthServiceInterface) gin.HandlerFunc {
 				"Invalid API key format",
 				"API key cannot be empty",
 			)
+			recordAuthFailure(c, appErr)
 			models.HandleError(c, appErr, log)
 			c.Abort()
 			return
@@ -70,7 +75,7 @@ func AuthMiddleware(authService services.AuthServiceInterface) gin.HandlerFunc {
 		// Validate API key (don't log the actual key for security)
 		log.Debug("Validating API key with auth service")
 
-		validatedKey, err := authService.ValidateAPIKey(apiKey)
+		validatedKey, err := authService.ValidateAPIKey(c.Request.Context(), apiKey)
 		if err != nil {
 			log.Warn("API key validation failed",
 				zap.Error(err),
@@ -83,12 +88,54 @@ func AuthMiddleware(authService services.AuthServiceInterface) gin.HandlerFunc {
 				appErr = models.NewAppError(models.ErrorCodeInvalidAPIKey, "Invalid API key")
 			case services.ErrInactiveAPIKey:
 				appErr = models.NewAppError(models.ErrorCodeInactiveAPIKey, "API key is inactive")
+			case services.ErrExpiredAPIKey:
+				appErr = models.NewAppError(models.ErrorCodeExpiredAPIKey, "API key has expired")
+			case services.ErrAPIKeyNotYetValid:
+				appErr = models.NewAppError(models.ErrorCodeKeyNotYetValid, "API key is not yet valid")
+			case services.ErrExpiredToken:
+				appErr = models.NewAppError(models.ErrorCodeTokenExpired, "Access token has expired")
 			case services.ErrDatabaseError:
 				appErr = models.NewAppErrorWithCause(models.ErrorCodeDatabaseError, "Authentication service unavailable", err)
 			default:
-				appErr = models.NewAppErrorWithCause(models.ErrorCodeInvalidAPIKey, "Authentication failed", err)
+				if errors.Is(err, services.ErrInvalidToken) {
+					appErr = models.NewAppErrorWithCause(models.ErrorCodeInvalidToken, "Invalid access token", err)
+				} else {
+					appErr = models.NewAppErrorWithCause(models.ErrorCodeInvalidAPIKey, "Authentication failed", err)
+				}
 			}
 
+			recordAuthFailure(c, appErr)
+			models.HandleError(c, appErr, log)
+			c.Abort()
+			return
+		}
+
+		// Enforce the key's IP allowlist, if any
+		allowed, err := ipfilter.IsAllowed(validatedKey.AllowedCIDRs, c.ClientIP())
+		if err != nil {
+			log.Error("Invalid IP allowlist on API key",
+				zap.Error(err),
+				zap.String("api_key_id", validatedKey.ID.Hex()),
+			)
+		}
+		if !allowed {
+			log.Warn("API key used from IP outside its allowlist",
+				zap.String("audit_event", "ip_not_allowed"),
+				zap.String("api_key_id", validatedKey.ID.Hex()),
+				zap.String("api_key_name", validatedKey.Name),
+				zap.String("client_ip", c.ClientIP()),
+			)
+			event := audit.RequestEvent(c, audit.EventIPNotAllowed, audit.OutcomeFailure)
+			event.ActorKeyID = validatedKey.ID.Hex()
+			event.TenantID = validatedKey.TenantID
+			event.Details = map[string]string{"path": c.Request.URL.Path}
+			audit.Record(event)
+
+			appErr := models.NewAppErrorWithDetails(
+				models.ErrorCodeIPNotAllowed,
+				"Access denied",
+				"API key is not allowed from this IP address",
+			)
 			models.HandleError(c, appErr, log)
 			c.Abort()
 			return
@@ -98,16 +145,38 @@ func AuthMiddleware(authService services.AuthServiceInterface) gin.HandlerFunc {
 		c.Set("api_key", validatedKey)
 		c.Set("api_key_id", validatedKey.ID.Hex())
 		c.Set("api_key_name", validatedKey.Name)
+		c.Set("api_key_scopes", validatedKey.Scopes)
+		if validatedKey.TenantID != "" {
+			c.Set("tenant_id", validatedKey.TenantID)
+		}
+		if validatedKey.PreviousKey != "" && apiKey == validatedKey.PreviousKey {
+			c.Set("api_key_previous_secret", true)
+		}
 
-		// Add user ID to request context for logging
+		// Add user and tenant IDs to request context for logging
 		ctx := logger.ContextWithUserID(c.Request.Context(), validatedKey.ID.Hex())
+		if validatedKey.TenantID != "" {
+			ctx = logger.ContextWithTenantID(ctx, validatedKey.TenantID)
+		}
 		c.Request = c.Request.WithContext(ctx)
 
 		log.Info("Authentication successful",
 			zap.String("api_key_id", validatedKey.ID.Hex()),
 			zap.String("api_key_name", validatedKey.Name),
+			zap.String("client_ip", c.ClientIP()),
 		)
+		audit.RecordRequest(c, audit.EventAuthSuccess, audit.OutcomeSuccess, map[string]string{
+			"path": c.Request.URL.Path,
+		})
 
 		c.Next()
 	}
 }
+
+// recordAuthFailure audits a rejected authentication attempt
+func recordAuthFailure(c *gin.Context, appErr *models.AppError) {
+	audit.RecordRequest(c, audit.EventAuthFailure, audit.OutcomeFailure, map[string]string{
+		"reason": string(appErr.Code),
+		"path":   c.Request.URL.Path,
+	})
+}
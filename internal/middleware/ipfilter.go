@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"solana-balance-api/internal/models"
+	"solana-balance-api/pkg/audit"
+	"solana-balance-api/pkg/ipfilter"
+	"solana-balance-api/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// IPDenylistMiddleware rejects requests from globally denied client IPs
+func IPDenylistMiddleware(denylist *ipfilter.Denylist) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientIP := c.ClientIP()
+
+		if denylist.IsDenied(clientIP) {
+			log := logger.GetLogger().WithContext(c.Request.Context())
+
+			log.Warn("Request from denied IP rejected",
+				zap.String("audit_event", "ip_denied"),
+				zap.String("client_ip", clientIP),
+				zap.String("path", c.Request.URL.Path),
+			)
+			audit.RecordRequest(c, audit.EventIPDenied, audit.OutcomeFailure, map[string]string{
+				"path": c.Request.URL.Path,
+			})
+
+			appErr := models.NewAppErrorWithDetails(
+				models.ErrorCodeIPNotAllowed,
+				"Access denied",
+				"Client IP is not allowed to access this service",
+			)
+			models.HandleError(c, appErr, log)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
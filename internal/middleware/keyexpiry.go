@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"fmt"
+	"time"
+
+	"solana-balance-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KeyExpiryWarningMiddleware adds a Warning header when the authenticated key
+// expires within warningDays, or when the request used a rotated secret that is only
+// valid during its grace window. Expiry times are reported in X-API-Key-Expires-At and
+// X-API-Key-Secret-Expires-At; Deprecation and Sunset are left to the route, e.g.
+// LegacyRouteMiddleware. It must run after AuthMiddleware.
+func KeyExpiryWarningMiddleware(warningDays int) gin.HandlerFunc {
+	window := time.Duration(warningDays) * 24 * time.Hour
+
+	return func(c *gin.Context) {
+		value, _ := c.Get("api_key")
+		apiKey, ok := value.(*models.APIKey)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		now := time.Now()
+
+		if c.GetBool("api_key_previous_secret") && apiKey.PreviousKeyExpiresAt != nil {
+			expiresAt := apiKey.PreviousKeyExpiresAt.UTC().Format(time.RFC3339)
+			c.Header("X-API-Key-Secret-Expires-At", expiresAt)
+			addWarning(c, fmt.Sprintf("API key secret has been rotated and stops working at %s", expiresAt))
+		} else if apiKey.ExpiresAt != nil && window > 0 && apiKey.ExpiresAt.Sub(now) <= window {
+			days := int(apiKey.ExpiresAt.Sub(now).Hours() / 24)
+			addWarning(c, fmt.Sprintf("API key expires in %d day(s) at %s", days, apiKey.ExpiresAt.UTC().Format(time.RFC3339)))
+		}
+
+		if apiKey.ExpiresAt != nil {
+			c.Header("X-API-Key-Expires-At", apiKey.ExpiresAt.UTC().Format(time.RFC3339))
+		}
+
+		c.Next()
+	}
+}
+
+// addWarning appends a Warning header, keeping warnings added by earlier middleware
+func addWarning(c *gin.Context, message string) {
+	c.Writer.Header().Add("Warning", fmt.Sprintf(`299 solana-balance-api %q`, message))
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"solana-balance-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyExpiryWarningOnLegacyRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	deprecatedAt := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, 5, 1, 0, 0, 0, 0, time.UTC)
+	expiresAt := time.Now().Add(3 * 24 * time.Hour).Truncate(time.Second)
+	previousExpiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	var previousSecret bool
+	authenticate := func(c *gin.Context) {
+		c.Set("api_key", &models.APIKey{ExpiresAt: &expiresAt, PreviousKeyExpiresAt: &previousExpiresAt})
+		c.Set("api_key_previous_secret", previousSecret)
+	}
+
+	engine := gin.New()
+	legacy := engine.Group("/api", LegacyRouteMiddleware(deprecatedAt, sunset), authenticate, KeyExpiryWarningMiddleware(14))
+	legacy.POST("/get-balance", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/get-balance", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		return w
+	}
+
+	t.Run("ExpiringKey", func(t *testing.T) {
+		w := serve()
+
+		// The route's deprecation headers survive the key warning
+		assert.Equal(t, "@1793491200", w.Header().Get("Deprecation"))
+		assert.Equal(t, "Sat, 01 May 2027 00:00:00 GMT", w.Header().Get("Sunset"))
+		assert.Equal(t, expiresAt.UTC().Format(time.RFC3339), w.Header().Get("X-API-Key-Expires-At"))
+
+		warnings := w.Header().Values("Warning")
+		require.Len(t, warnings, 2)
+		assert.Contains(t, warnings[0], "Unversioned API paths are deprecated")
+		assert.Contains(t, warnings[1], "API key expires in")
+	})
+
+	t.Run("RotatedSecret", func(t *testing.T) {
+		previousSecret = true
+		w := serve()
+
+		assert.Equal(t, "Sat, 01 May 2027 00:00:00 GMT", w.Header().Get("Sunset"))
+		assert.Equal(t, previousExpiresAt.UTC().Format(time.RFC3339), w.Header().Get("X-API-Key-Secret-Expires-At"))
+
+		warnings := w.Header().Values("Warning")
+		require.Len(t, warnings, 2)
+		assert.Contains(t, warnings[1], "API key secret has been rotated")
+	})
+}
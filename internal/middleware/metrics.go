@@ -27,5 +27,14 @@ func MetricsMiddleware(metricsCollector *metrics.MetricsCollector) gin.HandlerFu
 
 		// Record request completion
 		metricsCollector.RecordRequestComplete(duration, success)
+
+		// Record the labelled series by route template to keep cardinality bounded
+		metricsCollector.RecordHTTPRequest(c.Request.Method, c.FullPath(), c.Writer.Status(), duration)
+
+		// Record per-tenant counters once authentication has resolved the tenant
+		if tenantID := c.GetString("tenant_id"); tenantID != "" {
+			metricsCollector.RecordTenantRequest(tenantID, success)
+			metricsCollector.RecordTenantUsage(tenantID, c.GetInt("wallet_count"), c.GetInt("rpc_fetches"))
+		}
 	}
 }
@@ -9,26 +9,18 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// PerformanceMiddleware tracks request performance metrics
+// PerformanceMiddleware adds response time headers. Request metrics are recorded
+// once by MetricsMiddleware.
 func PerformanceMiddleware(metricsCollector *metrics.MetricsCollector) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		startTime := time.Now()
 
-		// Record request start
-		metricsCollector.RecordRequest()
-
 		// Process request
 		c.Next()
 
 		// Calculate duration
 		duration := time.Since(startTime)
 
-		// Determine if request was successful (2xx status codes)
-		success := c.Writer.Status() >= 200 && c.Writer.Status() < 300
-
-		// Record request completion
-		metricsCollector.RecordRequestComplete(duration, success)
-
 		// Add performance headers
 		c.Header("X-Response-Time", duration.String())
 		c.Header("X-Response-Time-Ms", strconv.FormatInt(duration.Milliseconds(), 10))
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"time"
+
+	"solana-balance-api/internal/models"
+	"solana-balance-api/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitRejected is a rate limiter hook writing rejections in the application's
+// error format, localized and with retry_after
+func RateLimitRejected(c *gin.Context, details string, retryAfter time.Duration) {
+	log := logger.GetLogger().WithContext(c.Request.Context())
+
+	appErr := models.NewAppErrorWithDetails(models.ErrorCodeRateLimitExceeded, "Rate limit exceeded", details).
+		WithRetryAfter(retryAfter)
+	models.HandleError(c, appErr, log)
+}
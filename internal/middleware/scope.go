@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+
+	"solana-balance-api/internal/models"
+	"solana-balance-api/pkg/audit"
+	"solana-balance-api/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RequireScope creates a middleware that only admits API keys holding all of the
+// given scopes. It must run after AuthMiddleware.
+func RequireScope(scopes ...string) gin.HandlerFunc {
+	required := strings.Join(scopes, " ")
+
+	return func(c *gin.Context) {
+		log := logger.GetLogger().WithContext(c.Request.Context())
+
+		value, exists := c.Get("api_key")
+		apiKey, ok := value.(*models.APIKey)
+		if !exists || !ok {
+			log.Error("Scope check without authenticated API key",
+				zap.String("path", c.Request.URL.Path),
+			)
+
+			appErr := models.NewAppError(models.ErrorCodeMissingAPIKey, "API key is required")
+			models.HandleError(c, appErr, log)
+			c.Abort()
+			return
+		}
+
+		for _, scope := range scopes {
+			if apiKey.HasScope(scope) {
+				continue
+			}
+
+			log.Warn("API key lacks required scope",
+				zap.String("audit_event", "scope_denied"),
+				zap.String("api_key_id", apiKey.ID.Hex()),
+				zap.String("required_scope", scope),
+				zap.Strings("key_scopes", apiKey.EffectiveScopes()),
+				zap.String("path", c.Request.URL.Path),
+			)
+			audit.RecordRequest(c, audit.EventScopeDenied, audit.OutcomeFailure, map[string]string{
+				"required_scope": scope,
+				"path":           c.Request.URL.Path,
+			})
+
+			// RFC 6750 section 3.1
+			c.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, required))
+
+			appErr := models.NewAppErrorWithDetails(
+				models.ErrorCodeInsufficientScope,
+				"Insufficient scope",
+				fmt.Sprintf("This endpoint requires the %q scope", scope),
+			)
+			models.HandleError(c, appErr, log)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"solana-balance-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(apiKey *models.APIKey, scopes ...string) *httptest.ResponseRecorder {
+		engine := gin.New()
+		engine.GET("/resource", func(c *gin.Context) {
+			if apiKey != nil {
+				c.Set("api_key", apiKey)
+			}
+		}, RequireScope(scopes...), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/resource", nil))
+		return w
+	}
+
+	t.Run("HeldScope", func(t *testing.T) {
+		apiKey := &models.APIKey{Scopes: []string{models.ScopeBalanceRead, models.ScopeTokensRead}}
+		assert.Equal(t, http.StatusOK, serve(apiKey, models.ScopeBalanceRead, models.ScopeTokensRead).Code)
+	})
+
+	t.Run("AdminWildcard", func(t *testing.T) {
+		apiKey := &models.APIKey{Scopes: []string{models.ScopeAdmin}}
+		assert.Equal(t, http.StatusOK, serve(apiKey, models.ScopeWebhooksWrite).Code)
+	})
+
+	t.Run("MissingScope", func(t *testing.T) {
+		apiKey := &models.APIKey{Scopes: []string{models.ScopeBalanceRead}}
+		w := serve(apiKey, models.ScopeBalanceRead, models.ScopeHistoryRead)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), string(models.ErrorCodeInsufficientScope))
+		assert.Equal(t, `Bearer error="insufficient_scope", scope="balance:read history:read"`, w.Header().Get("WWW-Authenticate"))
+	})
+
+	t.Run("ScopelessToken", func(t *testing.T) {
+		w := serve(&models.APIKey{FromToken: true}, models.ScopeBalanceRead)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		w := serve(nil, models.ScopeBalanceRead)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"solana-balance-api/internal/models"
+	"solana-balance-api/internal/services"
+	"solana-balance-api/pkg/audit"
+	"solana-balance-api/pkg/logger"
+	"solana-balance-api/pkg/ratelimiter"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// TenantMiddleware resolves the authenticated key's organization, enforces its status
+// and monthly quotas, applies its rate tier to tenantLimiter and records usage.
+// It must run after AuthMiddleware and before the tenant rate limiter.
+func TenantMiddleware(organizations services.OrganizationServiceInterface, tenantLimiter *ratelimiter.RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := c.GetString("tenant_id")
+		if tenantID == "" {
+			c.Next()
+			return
+		}
+
+		log := logger.GetLogger().WithContext(c.Request.Context())
+
+		org, err := organizations.GetOrganization(tenantID)
+		switch err {
+		case nil:
+			if !org.Active {
+				log.Warn("Request from inactive organization rejected",
+					zap.String("audit_event", "tenant_inactive"),
+					zap.String("api_key_id", c.GetString("api_key_id")),
+				)
+				audit.RecordRequest(c, audit.EventTenantInactive, audit.OutcomeFailure, nil)
+
+				appErr := models.NewAppErrorWithDetails(
+					models.ErrorCodeTenantInactive,
+					"Organization is inactive",
+					"The organization that owns this API key has been deactivated",
+				)
+				models.HandleError(c, appErr, log)
+				c.Abort()
+				return
+			}
+
+			if err := organizations.CheckQuota(org); err == services.ErrQuotaExceeded {
+				log.Warn("Organization quota exceeded",
+					zap.String("rate_tier", org.RateTier),
+				)
+
+				// Quotas reset at the start of the next calendar month
+				now := time.Now().UTC()
+				nextPeriod := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+				appErr := models.NewAppErrorWithDetails(
+					models.ErrorCodeQuotaExceeded,
+					"Organization quota exceeded",
+					"Monthly quota for organization "+tenantID+" has been used up",
+				).WithRetryAfter(nextPeriod.Sub(now))
+				models.HandleError(c, appErr, log)
+				c.Abort()
+				return
+			} else if err != nil {
+				log.Warn("Failed to check organization quota", zap.Error(err))
+			}
+		case services.ErrOrganizationNotFound:
+			// Tenants without an organization document get the default tier
+			log.Debug("No organization found for tenant, using default rate tier")
+			org = nil
+		default:
+			log.Warn("Failed to load organization, using default rate tier", zap.Error(err))
+			org = nil
+		}
+
+		tenantLimiter.SetLimit(TenantRateLimitKey(c), organizations.TierLimit(org))
+		if org != nil {
+			c.Set("organization", org)
+		}
+
+		c.Next()
+
+		// Roll usage up to the organization; rate limited requests were not served
+		if c.Writer.Status() == http.StatusTooManyRequests {
+			return
+		}
+		organizations.RecordUsage(tenantID, 1, c.GetInt("wallet_count"), c.GetInt("rpc_fetches"))
+	}
+}
+
+// TenantRateLimitKey returns the rate limiter key for the request's tenant, or ""
+// for requests without a tenant
+func TenantRateLimitKey(c *gin.Context) string {
+	if tenantID := c.GetString("tenant_id"); tenantID != "" {
+		return "tenant:" + tenantID
+	}
+	return ""
+}
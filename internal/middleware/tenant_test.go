@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"solana-balance-api/internal/models"
+	"solana-balance-api/internal/services"
+	"solana-balance-api/pkg/ratelimiter"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubOrganizations serves fixed organizations with a quota outcome per tenant
+type stubOrganizations struct {
+	orgs     map[string]*models.Organization
+	exceeded map[string]bool
+	recorded map[string]int
+}
+
+func (s *stubOrganizations) GetOrganization(tenantID string) (*models.Organization, error) {
+	org, exists := s.orgs[tenantID]
+	if !exists {
+		return nil, services.ErrOrganizationNotFound
+	}
+	return org, nil
+}
+
+func (s *stubOrganizations) GetUsage(tenantID string) (*models.OrganizationUsage, error) {
+	return &models.OrganizationUsage{TenantID: tenantID}, nil
+}
+
+func (s *stubOrganizations) RecordUsage(tenantID string, requests, wallets, rpcCalls int) {
+	s.recorded[tenantID] += requests
+}
+
+func (s *stubOrganizations) CheckQuota(org *models.Organization) error {
+	if s.exceeded[org.TenantID] {
+		return services.ErrQuotaExceeded
+	}
+	return nil
+}
+
+func (s *stubOrganizations) TierLimit(org *models.Organization) int {
+	if org != nil && org.RateTier == models.RateTierStandard {
+		return 3
+	}
+	return 1
+}
+
+func TestTenantMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	organizations := &stubOrganizations{
+		orgs: map[string]*models.Organization{
+			"active":   {TenantID: "active", Active: true, RateTier: models.RateTierStandard},
+			"inactive": {TenantID: "inactive", Active: false},
+			"over":     {TenantID: "over", Active: true},
+		},
+		exceeded: map[string]bool{"over": true},
+		recorded: make(map[string]int),
+	}
+	tenantLimiter := ratelimiter.New(100, time.Minute)
+
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set("tenant_id", c.GetHeader("X-Tenant"))
+	})
+	engine.Use(TenantMiddleware(organizations, tenantLimiter), tenantLimiter.KeyedMiddleware(TenantRateLimitKey))
+	engine.GET("/resource", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	serve := func(tenantID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+		req.Header.Set("X-Tenant", tenantID)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("InactiveTenant", func(t *testing.T) {
+		w := serve("inactive")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), string(models.ErrorCodeTenantInactive))
+	})
+
+	t.Run("QuotaExceeded", func(t *testing.T) {
+		w := serve("over")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Contains(t, w.Body.String(), string(models.ErrorCodeQuotaExceeded))
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+		assert.Zero(t, organizations.recorded["over"])
+	})
+
+	t.Run("TierLimit", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusOK, serve("active").Code)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, serve("active").Code)
+		assert.Equal(t, 3, tenantLimiter.LimitFor("tenant:active"))
+		assert.Equal(t, 3, organizations.recorded["active"], "rate limited requests are not rolled up")
+	})
+
+	t.Run("UnknownTenantGetsDefaultTier", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("unknown").Code)
+		assert.Equal(t, http.StatusTooManyRequests, serve("unknown").Code)
+	})
+
+	t.Run("NoTenant", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("").Code)
+		assert.Equal(t, http.StatusOK, serve("").Code)
+	})
+}
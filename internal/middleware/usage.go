@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+
+	"solana-balance-api/internal/models"
+	"solana-balance-api/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UsageMiddleware meters the authenticated key's usage once the request has been
+// handled. It must run after AuthMiddleware and the rate limiters; rate limited
+// requests are not metered.
+func UsageMiddleware(usage services.UsageServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.Writer.Status() == http.StatusTooManyRequests {
+			return
+		}
+
+		value, exists := c.Get("api_key")
+		if !exists {
+			return
+		}
+		apiKey, ok := value.(*models.APIKey)
+		if !ok || apiKey.ID.IsZero() {
+			return
+		}
+
+		rpcFetches := int64(c.GetInt("rpc_fetches"))
+		usage.RecordUsage(apiKey.ID, apiKey.TenantID, models.UsageCounters{
+			Requests:    1,
+			Wallets:     int64(c.GetInt("wallet_count")),
+			CacheHits:   int64(c.GetInt("cache_hits")),
+			CacheMisses: rpcFetches,
+			RPCCalls:    rpcFetches,
+		})
+	}
+}
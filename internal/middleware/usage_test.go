@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"solana-balance-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// recordingUsage counts metered requests per key
+type recordingUsage struct {
+	requests map[primitive.ObjectID]int64
+}
+
+func (r *recordingUsage) RecordUsage(keyID primitive.ObjectID, tenantID string, counters models.UsageCounters) {
+	r.requests[keyID] += counters.Requests
+}
+
+func (r *recordingUsage) Query(query models.UsageQuery) (*models.UsageReport, error) {
+	return &models.UsageReport{}, nil
+}
+
+func TestUsageMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	apiKey := &models.APIKey{ID: primitive.NewObjectID()}
+	usage := &recordingUsage{requests: make(map[primitive.ObjectID]int64)}
+
+	engine := gin.New()
+	engine.GET("/resource/:status", func(c *gin.Context) {
+		c.Set("api_key", apiKey)
+	}, UsageMiddleware(usage), func(c *gin.Context) {
+		if c.Param("status") == "limited" {
+			c.Status(http.StatusTooManyRequests)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{"/resource/ok", "/resource/limited", "/resource/ok"} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	assert.EqualValues(t, 2, usage.requests[apiKey.ID], "rate limited requests are not metered")
+}
@@ -6,6 +6,37 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Scopes that can be granted to API keys
+const (
+	ScopeBalanceRead   = "balance:read"
+	ScopeTokensRead    = "tokens:read"
+	ScopeHistoryRead   = "history:read"
+	ScopeWebhooksWrite = "webhooks:write"
+	ScopeAdmin         = "admin"
+)
+
+// AllScopes lists every known scope
+var AllScopes = []string{
+	ScopeBalanceRead,
+	ScopeTokensRead,
+	ScopeHistoryRead,
+	ScopeWebhooksWrite,
+	ScopeAdmin,
+}
+
+// DefaultScopes are granted to keys that were created without explicit scopes
+var DefaultScopes = []string{ScopeBalanceRead}
+
+// IsValidScope reports whether scope is a known scope
+func IsValidScope(scope string) bool {
+	for _, known := range AllScopes {
+		if scope == known {
+			return true
+		}
+	}
+	return false
+}
+
 // APIKey represents an API key stored in MongoDB
 type APIKey struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
@@ -14,4 +45,85 @@ type APIKey struct {
 	Active    bool               `bson:"active" json:"active"`
 	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
 	LastUsed  *time.Time         `bson:"last_used,omitempty" json:"last_used,omitempty"`
+
+	// Scopes granted to the key and the tenant that owns it
+	Scopes   []string `bson:"scopes,omitempty" json:"scopes,omitempty"`
+	TenantID string   `bson:"tenant_id,omitempty" json:"tenant_id,omitempty"`
+
+	// AllowedCIDRs restricts the key to the given client networks (empty means any IP)
+	AllowedCIDRs []string `bson:"allowed_cidrs,omitempty" json:"allowed_cidrs,omitempty"`
+
+	// Limits overrides the configured balance request limits for this key
+	Limits *RequestLimits `bson:"limits,omitempty" json:"limits,omitempty"`
+
+	// Validity window; nil means unbounded
+	NotBefore *time.Time `bson:"not_before,omitempty" json:"not_before,omitempty"`
+	ExpiresAt *time.Time `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+
+	// Rotation: the previous secret stays valid until PreviousKeyExpiresAt
+	PreviousKey          string     `bson:"previous_key,omitempty" json:"-"`
+	PreviousKeyExpiresAt *time.Time `bson:"previous_key_expires_at,omitempty" json:"previous_key_expires_at,omitempty"`
+	RotatedAt            *time.Time `bson:"rotated_at,omitempty" json:"rotated_at,omitempty"`
+
+	// Set when the key is deactivated automatically (e.g. on expiry)
+	DeactivatedAt      *time.Time `bson:"deactivated_at,omitempty" json:"deactivated_at,omitempty"`
+	DeactivationReason string     `bson:"deactivation_reason,omitempty" json:"deactivation_reason,omitempty"`
+
+	// FromToken marks identities built from JWT claims rather than stored keys
+	FromToken bool `bson:"-" json:"-"`
+}
+
+// EffectiveScopes returns the key's scopes, falling back to DefaultScopes for
+// stored keys that predate scoped permissions. Tokens only hold the scopes they carry.
+func (k *APIKey) EffectiveScopes() []string {
+	if len(k.Scopes) == 0 && !k.FromToken {
+		return DefaultScopes
+	}
+	return k.Scopes
+}
+
+// HasScope reports whether the key grants scope. The admin scope grants everything.
+func (k *APIKey) HasScope(scope string) bool {
+	for _, held := range k.EffectiveScopes() {
+		if held == scope || held == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyRotationRequest represents the request body for rotating an API key secret
+type KeyRotationRequest struct {
+	// GracePeriod during which the old secret keeps working, e.g. "24h" (optional);
+	// "0s" revokes the old secret immediately
+	GracePeriod string `json:"grace_period,omitempty"`
+}
+
+// LogLevelRequest represents the request body for changing a log level at runtime
+type LogLevelRequest struct {
+	Level string `json:"level" binding:"required"`
+	// TTL after which the previous level is restored, e.g. "15m" (optional)
+	TTL string `json:"ttl,omitempty"`
+}
+
+// KeyRotationResponse carries the new secret of a rotated API key
+type KeyRotationResponse struct {
+	ID                   string     `json:"id"`
+	Key                  string     `json:"key"`
+	RotatedAt            time.Time  `json:"rotated_at"`
+	PreviousKeyExpiresAt *time.Time `json:"previous_key_expires_at,omitempty"` // Unset when the old secret was revoked at once
+}
+
+// TokenResponse represents an OAuth2 access token response (RFC 6749 section 5.1)
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+// OAuthErrorResponse represents an OAuth2 error response (RFC 6749 section 5.2)
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
 }
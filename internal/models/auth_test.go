@@ -0,0 +1,35 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasScope(t *testing.T) {
+	t.Run("LegacyKeyGetsDefaultScopes", func(t *testing.T) {
+		apiKey := &APIKey{}
+		assert.Equal(t, DefaultScopes, apiKey.EffectiveScopes())
+		assert.True(t, apiKey.HasScope(ScopeBalanceRead))
+		assert.False(t, apiKey.HasScope(ScopeTokensRead))
+	})
+
+	t.Run("ExplicitScopes", func(t *testing.T) {
+		apiKey := &APIKey{Scopes: []string{ScopeTokensRead}}
+		assert.True(t, apiKey.HasScope(ScopeTokensRead))
+		assert.False(t, apiKey.HasScope(ScopeBalanceRead))
+	})
+
+	t.Run("AdminGrantsEverything", func(t *testing.T) {
+		apiKey := &APIKey{Scopes: []string{ScopeAdmin}}
+		for _, scope := range AllScopes {
+			assert.True(t, apiKey.HasScope(scope), scope)
+		}
+	})
+
+	t.Run("ScopelessTokenGrantsNothing", func(t *testing.T) {
+		apiKey := &APIKey{FromToken: true}
+		assert.Empty(t, apiKey.EffectiveScopes())
+		assert.False(t, apiKey.HasScope(ScopeBalanceRead))
+	})
+}
@@ -7,17 +7,54 @@ type BalanceRequest struct {
 	Wallets []string `json:"wallets"`
 }
 
+// RequestLimits bounds the size of balance requests. On API keys, zero fields
+// fall back to the configured defaults.
+type RequestLimits struct {
+	MaxBodyBytes int64 `bson:"max_body_bytes,omitempty" json:"max_body_bytes,omitempty"`
+	MaxWallets   int   `bson:"max_wallets,omitempty" json:"max_wallets,omitempty"`
+}
+
 // BalanceResponse represents the response containing wallet balances
 type BalanceResponse struct {
 	Balances []WalletBalance `json:"balances"`
 	Cached   bool            `json:"cached"`
+	// Partial is set when the balance of at least one wallet could not be fetched;
+	// the response is then sent with HTTP 207
+	Partial bool `json:"partial"`
+
+	// RPCFetches counts cache misses that reached the RPC (used for rate limit costs)
+	RPCFetches int `json:"-"`
+	// CacheHits counts wallets served from the cache (used for usage metering)
+	CacheHits int `json:"-"`
 }
 
+// Wallet balance statuses
+const (
+	WalletStatusOK       = "ok"        // Balance fetched; zero for existing accounts without lamports
+	WalletStatusNotFound = "not_found" // The account does not exist
+	WalletStatusError    = "error"     // The balance could not be fetched; Balance is meaningless
+)
+
+// Address types of valid wallet addresses
+const (
+	AddressTypeWallet = "wallet" // On the ed25519 curve: a key pair can sign for it
+	AddressTypePDA    = "pda"    // Off the curve: a program derived address
+)
+
 // WalletBalance represents the balance information for a single wallet
 type WalletBalance struct {
-	Address string  `json:"address"`
-	Balance float64 `json:"balance"`
-	Error   string  `json:"error,omitempty"`
+	Address     string    `json:"address"`
+	AddressType string    `json:"address_type,omitempty"`
+	Balance     float64   `json:"balance"`
+	Status      string    `json:"status"`
+	ErrorCode   ErrorCode `json:"error_code,omitempty"`
+	Retryable   bool      `json:"retryable"`
+	Error       string    `json:"error,omitempty"`
+
+	// When the balance was fetched and when its cache entry expires; zero for errors,
+	// which are not cached. Used for HTTP caching headers.
+	FetchedAt time.Time `json:"-"`
+	ExpiresAt time.Time `json:"-"`
 }
 
 // CacheEntry represents a cached balance entry with TTL
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"strings"
+)
+
+// DefaultDocsBaseURL serves error documentation from the catalog endpoint itself
+const DefaultDocsBaseURL = "/api/v1/errors"
+
+// ErrorCodes lists every error code, in catalog order
+var ErrorCodes = []ErrorCode{
+	ErrorCodeMissingAPIKey,
+	ErrorCodeInvalidAPIKey,
+	ErrorCodeInactiveAPIKey,
+	ErrorCodeInvalidToken,
+	ErrorCodeTokenExpired,
+	ErrorCodeExpiredAPIKey,
+	ErrorCodeKeyNotYetValid,
+	ErrorCodeIPNotAllowed,
+	ErrorCodeInsufficientScope,
+	ErrorCodeTenantInactive,
+	ErrorCodeAPIKeyNotFound,
+	ErrorCodeOrganizationNotFound,
+	ErrorCodeAccountNotFound,
+	ErrorCodeRateLimitExceeded,
+	ErrorCodeQuotaExceeded,
+	ErrorCodeInvalidRequest,
+	ErrorCodeInvalidWallet,
+	ErrorCodeEmptyWalletArray,
+	ErrorCodeTooManyWallets,
+	ErrorCodeMalformedJSON,
+	ErrorCodeRequestTooLarge,
+	ErrorCodeUnsupportedVersion,
+	ErrorCodeRPCUnavailable,
+	ErrorCodeRPCTimeout,
+	ErrorCodeInvalidRPCResponse,
+	ErrorCodeDatabaseError,
+	ErrorCodeCacheError,
+	ErrorCodeInternalError,
+}
+
+// retryableCodes are transient errors: the same request may succeed later, after
+// retry_after when given
+var retryableCodes = map[ErrorCode]bool{
+	ErrorCodeRateLimitExceeded:  true,
+	ErrorCodeQuotaExceeded:      true,
+	ErrorCodeRPCUnavailable:     true,
+	ErrorCodeRPCTimeout:         true,
+	ErrorCodeInvalidRPCResponse: true,
+	ErrorCodeDatabaseError:      true,
+	ErrorCodeCacheError:         true,
+}
+
+// docsBaseURL is prefixed to error codes to form their documentation URLs
+var docsBaseURL = DefaultDocsBaseURL
+
+// SetDocsBaseURL changes where error documentation is linked; empty restores the
+// default. It is called once at startup.
+func SetDocsBaseURL(base string) {
+	if base == "" {
+		base = DefaultDocsBaseURL
+	}
+	docsBaseURL = strings.TrimSuffix(base, "/")
+}
+
+// Retryable reports whether a request failing with this error may be retried unchanged
+func (e ErrorCode) Retryable() bool {
+	return retryableCodes[e]
+}
+
+// DocsURL returns the documentation URL of the error code
+func (e ErrorCode) DocsURL() string {
+	return docsBaseURL + "/" + string(e)
+}
+
+// ErrorInfo describes an error code in the error catalog
+type ErrorInfo struct {
+	Code        ErrorCode `json:"code"`
+	Status      int       `json:"status"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Retryable   bool      `json:"retryable"`
+	DocsURL     string    `json:"docs_url"`
+}
+
+// ErrorCatalogResponse lists every error code
+type ErrorCatalogResponse struct {
+	Language string      `json:"language"`
+	Errors   []ErrorInfo `json:"errors"`
+}
+
+// LookupError describes an error code in the given language
+func LookupError(code ErrorCode, language string) (ErrorInfo, bool) {
+	for _, known := range ErrorCodes {
+		if known == code {
+			text := localizedErrorText(language, code)
+			return ErrorInfo{
+				Code:        code,
+				Status:      code.HTTPStatusCode(),
+				Title:       text.Title,
+				Description: text.Description,
+				Retryable:   code.Retryable(),
+				DocsURL:     code.DocsURL(),
+			}, true
+		}
+	}
+	return ErrorInfo{}, false
+}
+
+// ErrorCatalog describes every error code in the given language
+func ErrorCatalog(language string) []ErrorInfo {
+	catalog := make([]ErrorInfo, 0, len(ErrorCodes))
+	for _, code := range ErrorCodes {
+		info, _ := LookupError(code, language)
+		catalog = append(catalog, info)
+	}
+	return catalog
+}
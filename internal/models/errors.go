@@ -3,9 +3,14 @@ package models
 import (
 	"context"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"solana-balance-api/pkg/logger"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -18,15 +23,33 @@ const (
 	ErrorCodeMissingAPIKey  ErrorCode = "MISSING_API_KEY"
 	ErrorCodeInvalidAPIKey  ErrorCode = "INVALID_API_KEY"
 	ErrorCodeInactiveAPIKey ErrorCode = "INACTIVE_API_KEY"
+	ErrorCodeInvalidToken   ErrorCode = "INVALID_TOKEN"
+	ErrorCodeTokenExpired   ErrorCode = "TOKEN_EXPIRED"
+	ErrorCodeExpiredAPIKey  ErrorCode = "EXPIRED_API_KEY"
+	ErrorCodeKeyNotYetValid ErrorCode = "API_KEY_NOT_YET_VALID"
+
+	// Access control errors
+	ErrorCodeIPNotAllowed      ErrorCode = "IP_NOT_ALLOWED"
+	ErrorCodeInsufficientScope ErrorCode = "INSUFFICIENT_SCOPE"
+	ErrorCodeTenantInactive    ErrorCode = "TENANT_INACTIVE"
+
+	// Resource errors
+	ErrorCodeAPIKeyNotFound       ErrorCode = "API_KEY_NOT_FOUND"
+	ErrorCodeOrganizationNotFound ErrorCode = "ORGANIZATION_NOT_FOUND"
+	ErrorCodeAccountNotFound      ErrorCode = "ACCOUNT_NOT_FOUND"
 
 	// Rate limiting errors
 	ErrorCodeRateLimitExceeded ErrorCode = "RATE_LIMIT_EXCEEDED"
+	ErrorCodeQuotaExceeded     ErrorCode = "QUOTA_EXCEEDED"
 
 	// Validation errors
-	ErrorCodeInvalidRequest   ErrorCode = "INVALID_REQUEST"
-	ErrorCodeInvalidWallet    ErrorCode = "INVALID_WALLET_ADDRESS"
-	ErrorCodeEmptyWalletArray ErrorCode = "EMPTY_WALLET_ARRAY"
-	ErrorCodeMalformedJSON    ErrorCode = "MALFORMED_JSON"
+	ErrorCodeInvalidRequest     ErrorCode = "INVALID_REQUEST"
+	ErrorCodeInvalidWallet      ErrorCode = "INVALID_WALLET_ADDRESS"
+	ErrorCodeEmptyWalletArray   ErrorCode = "EMPTY_WALLET_ARRAY"
+	ErrorCodeTooManyWallets     ErrorCode = "TOO_MANY_WALLETS"
+	ErrorCodeMalformedJSON      ErrorCode = "MALFORMED_JSON"
+	ErrorCodeRequestTooLarge    ErrorCode = "REQUEST_TOO_LARGE"
+	ErrorCodeUnsupportedVersion ErrorCode = "UNSUPPORTED_API_VERSION"
 
 	// RPC errors
 	ErrorCodeRPCUnavailable     ErrorCode = "RPC_UNAVAILABLE"
@@ -41,9 +64,32 @@ const (
 
 // ErrorDetail represents detailed error information
 type ErrorDetail struct {
-	Code    ErrorCode `json:"code"`
-	Message string    `json:"message"`
-	Details string    `json:"details,omitempty"`
+	Code       ErrorCode `json:"code"`
+	Message    string    `json:"message"`
+	Details    string    `json:"details,omitempty"`
+	Retryable  bool      `json:"retryable"`
+	RetryAfter *int      `json:"retry_after,omitempty"` // Seconds until a retry may succeed
+	DocsURL    string    `json:"docs_url,omitempty"`
+
+	InvalidParams []InvalidParam `json:"invalid_params,omitempty"`
+}
+
+// InvalidParam describes one invalid value of a request that may have several
+type InvalidParam struct {
+	Name   string `json:"name"` // e.g. "wallets[2]"
+	Value  string `json:"value,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// newErrorDetail creates the error detail for code
+func newErrorDetail(code ErrorCode, message, details string) ErrorDetail {
+	return ErrorDetail{
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		Retryable: code.Retryable(),
+		DocsURL:   code.DocsURL(),
+	}
 }
 
 // ErrorResponse represents the standardized error response format
@@ -55,12 +101,20 @@ type ErrorResponse struct {
 // HTTPStatusCode returns the appropriate HTTP status code for each error type
 func (e ErrorCode) HTTPStatusCode() int {
 	switch e {
-	case ErrorCodeMissingAPIKey, ErrorCodeInvalidAPIKey, ErrorCodeInactiveAPIKey:
+	case ErrorCodeMissingAPIKey, ErrorCodeInvalidAPIKey, ErrorCodeInactiveAPIKey, ErrorCodeInvalidToken, ErrorCodeTokenExpired,
+		ErrorCodeExpiredAPIKey, ErrorCodeKeyNotYetValid:
 		return http.StatusUnauthorized
-	case ErrorCodeRateLimitExceeded:
+	case ErrorCodeIPNotAllowed, ErrorCodeInsufficientScope, ErrorCodeTenantInactive:
+		return http.StatusForbidden
+	case ErrorCodeAPIKeyNotFound, ErrorCodeOrganizationNotFound, ErrorCodeAccountNotFound:
+		return http.StatusNotFound
+	case ErrorCodeRateLimitExceeded, ErrorCodeQuotaExceeded:
 		return http.StatusTooManyRequests
-	case ErrorCodeInvalidRequest, ErrorCodeInvalidWallet, ErrorCodeEmptyWalletArray, ErrorCodeMalformedJSON:
+	case ErrorCodeInvalidRequest, ErrorCodeInvalidWallet, ErrorCodeEmptyWalletArray, ErrorCodeTooManyWallets, ErrorCodeMalformedJSON,
+		ErrorCodeUnsupportedVersion:
 		return http.StatusBadRequest
+	case ErrorCodeRequestTooLarge:
+		return http.StatusRequestEntityTooLarge
 	case ErrorCodeRPCUnavailable, ErrorCodeRPCTimeout, ErrorCodeInvalidRPCResponse:
 		return http.StatusBadGateway
 	case ErrorCodeDatabaseError, ErrorCodeCacheError, ErrorCodeInternalError:
@@ -73,11 +127,7 @@ func (e ErrorCode) HTTPStatusCode() int {
 // NewErrorResponse creates a new error response with timestamp
 func NewErrorResponse(code ErrorCode, message, details string) *ErrorResponse {
 	return &ErrorResponse{
-		Error: ErrorDetail{
-			Code:    code,
-			Message: message,
-			Details: details,
-		},
+		Error:     newErrorDetail(code, message, details),
 		Timestamp: time.Now().UTC(),
 	}
 }
@@ -85,11 +135,7 @@ func NewErrorResponse(code ErrorCode, message, details string) *ErrorResponse {
 // NewErrorResponseWithCorrelation creates a new error response with correlation ID
 func NewErrorResponseWithCorrelation(code ErrorCode, message, details, correlationID string) *ErrorResponseWithCorrelation {
 	return &ErrorResponseWithCorrelation{
-		Error: ErrorDetail{
-			Code:    code,
-			Message: message,
-			Details: details,
-		},
+		Error:         newErrorDetail(code, message, details),
 		Timestamp:     time.Now().UTC(),
 		CorrelationID: correlationID,
 	}
@@ -102,6 +148,26 @@ type ErrorResponseWithCorrelation struct {
 	CorrelationID string      `json:"correlation_id"`
 }
 
+// ProblemContentType is the media type of RFC 7807 problem details
+const ProblemContentType = "application/problem+json"
+
+// ProblemDetails is the RFC 7807 form of an error response, sent to clients that
+// accept application/problem+json
+type ProblemDetails struct {
+	Type          string    `json:"type"`
+	Title         string    `json:"title"`
+	Status        int       `json:"status"`
+	Detail        string    `json:"detail,omitempty"`
+	Instance      string    `json:"instance,omitempty"`
+	Code          ErrorCode `json:"code"`
+	Retryable     bool      `json:"retryable"`
+	RetryAfter    *int      `json:"retry_after,omitempty"`
+	CorrelationID string    `json:"correlation_id"`
+	Timestamp     time.Time `json:"timestamp"`
+
+	InvalidParams []InvalidParam `json:"invalid_params,omitempty"`
+}
+
 // AppError represents an application error with context
 type AppError struct {
 	Code       ErrorCode
@@ -110,6 +176,9 @@ type AppError struct {
 	Cause      error
 	Context    map[string]interface{}
 	StatusCode int
+	RetryAfter time.Duration // When a retry may succeed; zero if unknown
+
+	InvalidParams []InvalidParam // Every invalid value, sent to the client
 }
 
 // Error implements the error interface
@@ -134,6 +203,20 @@ func (e *AppError) WithContext(key string, value interface{}) *AppError {
 	return e
 }
 
+// WithRetryAfter sets when a retry may succeed, sent as the Retry-After header and
+// the retry_after field
+func (e *AppError) WithRetryAfter(retryAfter time.Duration) *AppError {
+	e.RetryAfter = retryAfter
+	return e
+}
+
+// WithInvalidParam adds an invalid request value, so clients can fix every invalid
+// value at once
+func (e *AppError) WithInvalidParam(name, value, reason string) *AppError {
+	e.InvalidParams = append(e.InvalidParams, InvalidParam{Name: name, Value: value, Reason: reason})
+	return e
+}
+
 // NewAppError creates a new application error
 func NewAppError(code ErrorCode, message string) *AppError {
 	return &AppError{
@@ -166,21 +249,20 @@ func NewAppErrorWithDetails(code ErrorCode, message, details string) *AppError {
 	}
 }
 
-// HandleError handles application errors and sends appropriate HTTP response
-func HandleError(c *gin.Context, err error, logger interface{}) {
+// HandleError handles application errors and sends appropriate HTTP response.
+// Every error body carries the request's correlation ID; one is assigned here if the
+// logging middleware did not run.
+func HandleError(c *gin.Context, err error, log interface{}) {
 	var appErr *AppError
-	var correlationID string
 
 	// Extract correlation ID from context
-	if ctx := c.Request.Context(); ctx != nil {
-		if cid := ctx.Value("correlation_id"); cid != nil {
-			correlationID = cid.(string)
-		}
+	correlationID := logger.GetCorrelationIDFromContext(c.Request.Context())
+	if correlationID == "" {
+		correlationID = c.GetString(string(logger.CorrelationIDKey))
 	}
 	if correlationID == "" {
-		if cid := c.GetString("correlation_id"); cid != "" {
-			correlationID = cid
-		}
+		correlationID = logger.GenerateCorrelationID()
+		c.Header(logger.CorrelationIDHeader, correlationID)
 	}
 
 	// Convert error to AppError if needed
@@ -197,7 +279,7 @@ func HandleError(c *gin.Context, err error, logger interface{}) {
 		WithContext("client_ip", c.ClientIP())
 
 	// Log the error with appropriate level
-	if l, ok := logger.(interface {
+	if l, ok := log.(interface {
 		WithContext(context.Context) interface {
 			Error(string, ...zap.Field)
 			Warn(string, ...zap.Field)
@@ -222,27 +304,68 @@ func HandleError(c *gin.Context, err error, logger interface{}) {
 		}
 	}
 
-	// Create error response
-	var response interface{}
-	if correlationID != "" {
-		response = NewErrorResponseWithCorrelation(
-			appErr.Code,
-			appErr.Message,
-			appErr.Details,
-			correlationID,
-		)
-	} else {
-		response = NewErrorResponse(
-			appErr.Code,
-			appErr.Message,
-			appErr.Details,
-		)
+	// Messages are localized for the client; details stay in English
+	lang := NegotiateLanguage(c.GetHeader("Accept-Language"))
+	message := LocalizeMessage(lang, appErr.Code, appErr.Message)
+	c.Header("Content-Language", lang)
+	c.Writer.Header().Add("Vary", "Accept-Language")
+
+	retryAfter := retryAfterSeconds(c, appErr)
+
+	if acceptsProblem(c) {
+		c.Header("Content-Type", ProblemContentType)
+		c.JSON(appErr.StatusCode, ProblemDetails{
+			Type:          appErr.Code.DocsURL(),
+			Title:         message,
+			Status:        appErr.StatusCode,
+			Detail:        appErr.Details,
+			Instance:      c.Request.URL.Path,
+			Code:          appErr.Code,
+			Retryable:     appErr.Code.Retryable(),
+			RetryAfter:    retryAfter,
+			CorrelationID: correlationID,
+			Timestamp:     time.Now().UTC(),
+			InvalidParams: appErr.InvalidParams,
+		})
+		return
 	}
 
+	// Create error response
+	response := NewErrorResponseWithCorrelation(
+		appErr.Code,
+		message,
+		appErr.Details,
+		correlationID,
+	)
+	response.Error.RetryAfter = retryAfter
+	response.Error.InvalidParams = appErr.InvalidParams
+
 	// Send HTTP response
 	c.JSON(appErr.StatusCode, response)
 }
 
+// retryAfterSeconds returns the error's retry delay in whole seconds, setting the
+// Retry-After header. A Retry-After header set earlier, e.g. by a rate limiter, is
+// used when the error carries no delay.
+func retryAfterSeconds(c *gin.Context, appErr *AppError) *int {
+	if appErr.RetryAfter > 0 {
+		seconds := int(math.Ceil(appErr.RetryAfter.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		return &seconds
+	}
+	if header := c.Writer.Header().Get("Retry-After"); header != "" {
+		if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+			return &seconds
+		}
+	}
+	return nil
+}
+
+// acceptsProblem reports whether the client asked for RFC 7807 problem details
+func acceptsProblem(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), ProblemContentType)
+}
+
 // Common error constructors for specific scenarios
 
 // NewValidationError creates a validation error
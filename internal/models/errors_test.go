@@ -0,0 +1,117 @@
+package models
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorCatalog(t *testing.T) {
+	catalog := ErrorCatalog(DefaultLanguage)
+	require.Len(t, catalog, len(ErrorCodes))
+	for _, info := range catalog {
+		assert.NotEmpty(t, info.Title, info.Code)
+		assert.NotEmpty(t, info.Description, info.Code)
+		assert.Equal(t, "/api/v1/errors/"+string(info.Code), info.DocsURL)
+	}
+
+	rateLimited, found := LookupError(ErrorCodeRateLimitExceeded, "de")
+	require.True(t, found)
+	assert.Equal(t, http.StatusTooManyRequests, rateLimited.Status)
+	assert.True(t, rateLimited.Retryable)
+	assert.Equal(t, "Ratenlimit überschritten", rateLimited.Title)
+
+	_, found = LookupError("NO_SUCH_ERROR", DefaultLanguage)
+	assert.False(t, found)
+
+	t.Run("TranslationsAreComplete", func(t *testing.T) {
+		for _, lang := range Languages() {
+			for _, code := range ErrorCodes {
+				text := bundles[lang].Errors[code]
+				assert.NotEmpty(t, text.Title, "%s title of %s", lang, code)
+				assert.NotEmpty(t, text.Description, "%s description of %s", lang, code)
+			}
+		}
+	})
+}
+
+func TestNegotiateLanguage(t *testing.T) {
+	assert.Equal(t, "en", NegotiateLanguage(""))
+	assert.Equal(t, "es", NegotiateLanguage("es-MX,es;q=0.9,en;q=0.5"))
+	assert.Equal(t, "de", NegotiateLanguage("fr;q=0.9, de;q=0.8"))
+	assert.Equal(t, "en", NegotiateLanguage("fr"))
+	assert.Equal(t, "en", NegotiateLanguage("not a language header;;"))
+
+	assert.Equal(t, "Clave de API no válida", LocalizeMessage("es", ErrorCodeInvalidAPIKey, "Invalid API key"))
+	assert.Equal(t, "Solicitud no válida", LocalizeMessage("es", ErrorCodeInvalidRequest, "Some untranslated message"),
+		"untranslated messages fall back to the code's title")
+	assert.Equal(t, "Some message", LocalizeMessage("en", ErrorCodeInvalidRequest, "Some message"))
+}
+
+// handleError runs HandleError for appErr with the given request headers
+func handleError(t *testing.T, appErr *AppError, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/usage", nil)
+	for name, value := range headers {
+		c.Request.Header.Set(name, value)
+	}
+	HandleError(c, appErr, nil)
+	return recorder
+}
+
+func TestHandleError(t *testing.T) {
+	t.Run("RetryAfter", func(t *testing.T) {
+		appErr := NewAppErrorWithDetails(ErrorCodeQuotaExceeded, "Organization quota exceeded", "Monthly quota used up").
+			WithRetryAfter(90500 * time.Millisecond)
+		recorder := handleError(t, appErr, map[string]string{"Accept-Language": "es"})
+
+		assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+		assert.Equal(t, "91", recorder.Header().Get("Retry-After"))
+		assert.Equal(t, "es", recorder.Header().Get("Content-Language"))
+
+		var response ErrorResponseWithCorrelation
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.Equal(t, "Cuota de la organización superada", response.Error.Message)
+		assert.Equal(t, "Monthly quota used up", response.Error.Details)
+		assert.True(t, response.Error.Retryable)
+		require.NotNil(t, response.Error.RetryAfter)
+		assert.Equal(t, 91, *response.Error.RetryAfter)
+		assert.NotEmpty(t, response.CorrelationID)
+	})
+
+	t.Run("NotRetryable", func(t *testing.T) {
+		recorder := handleError(t, NewAppError(ErrorCodeInvalidAPIKey, "Invalid API key"), nil)
+
+		var body struct {
+			Error map[string]interface{} `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+		assert.Equal(t, false, body.Error["retryable"])
+		assert.NotContains(t, body.Error, "retry_after")
+		assert.Equal(t, "/api/v1/errors/INVALID_API_KEY", body.Error["docs_url"])
+	})
+
+	t.Run("ProblemDetails", func(t *testing.T) {
+		recorder := handleError(t, NewAppErrorWithDetails(ErrorCodeInvalidRequest, "Invalid granularity", "Use hour, day or month"),
+			map[string]string{"Accept": "application/problem+json, application/json;q=0.5"})
+
+		assert.Equal(t, ProblemContentType, recorder.Header().Get("Content-Type"))
+		var problem ProblemDetails
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &problem))
+		assert.Equal(t, "/api/v1/errors/INVALID_REQUEST", problem.Type)
+		assert.Equal(t, "Invalid granularity", problem.Title)
+		assert.Equal(t, http.StatusBadRequest, problem.Status)
+		assert.Equal(t, "Use hour, day or month", problem.Detail)
+		assert.Equal(t, "/api/usage", problem.Instance)
+		assert.Equal(t, ErrorCodeInvalidRequest, problem.Code)
+	})
+}
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultLanguage is used when no supported language is acceptable to the client
+const DefaultLanguage = "en"
+
+// localeFiles are the bundled translations, one file per language
+//
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// ErrorText is the localized title and description of an error code
+type ErrorText struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// translations holds one language's error texts and message translations. Messages
+// are keyed by their English text.
+type translations struct {
+	Errors   map[ErrorCode]ErrorText `json:"errors"`
+	Messages map[string]string       `json:"messages"`
+}
+
+var (
+	// bundles maps language names to their translations
+	bundles map[string]*translations
+
+	// languages lists the supported languages, the default first, in matcher order
+	languages []string
+	matcher   language.Matcher
+)
+
+func init() {
+	if err := loadTranslations(); err != nil {
+		panic(fmt.Sprintf("failed to load bundled translations: %v", err))
+	}
+}
+
+// loadTranslations reads the bundled translation files
+func loadTranslations() error {
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		return err
+	}
+
+	bundles = make(map[string]*translations, len(files))
+	languages = []string{DefaultLanguage}
+	for _, file := range files {
+		name := strings.TrimSuffix(file.Name(), ".json")
+		data, err := localeFiles.ReadFile(path.Join("locales", file.Name()))
+		if err != nil {
+			return err
+		}
+		bundle := &translations{}
+		if err := json.Unmarshal(data, bundle); err != nil {
+			return fmt.Errorf("%s: %w", file.Name(), err)
+		}
+		bundles[name] = bundle
+		if name != DefaultLanguage {
+			languages = append(languages, name)
+		}
+	}
+	if bundles[DefaultLanguage] == nil {
+		return fmt.Errorf("missing %s.json", DefaultLanguage)
+	}
+
+	tags := make([]language.Tag, len(languages))
+	for i, name := range languages {
+		tags[i] = language.Make(name)
+	}
+	matcher = language.NewMatcher(tags)
+	return nil
+}
+
+// Languages returns the supported languages, the default first
+func Languages() []string {
+	return append([]string(nil), languages...)
+}
+
+// NegotiateLanguage picks the supported language best matching an Accept-Language
+// header, falling back to the default language
+func NegotiateLanguage(acceptLanguage string) string {
+	if acceptLanguage == "" {
+		return DefaultLanguage
+	}
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return DefaultLanguage
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return DefaultLanguage
+	}
+	return languages[index]
+}
+
+// localizedErrorText returns the title and description of code in the given language,
+// falling back to English
+func localizedErrorText(lang string, code ErrorCode) ErrorText {
+	english := bundles[DefaultLanguage].Errors[code]
+	bundle, ok := bundles[lang]
+	if !ok {
+		return english
+	}
+	text := bundle.Errors[code]
+	if text.Title == "" {
+		text.Title = english.Title
+	}
+	if text.Description == "" {
+		text.Description = english.Description
+	}
+	return text
+}
+
+// LocalizeMessage translates an error message. Messages without a translation are
+// replaced by the localized title of their error code, so clients always get text in
+// their language; details are not translated.
+func LocalizeMessage(lang string, code ErrorCode, message string) string {
+	if message == "" {
+		return localizedErrorText(lang, code).Title
+	}
+	bundle, ok := bundles[lang]
+	if !ok || lang == DefaultLanguage {
+		return message
+	}
+	if translated, ok := bundle.Messages[message]; ok {
+		return translated
+	}
+	if title := bundle.Errors[code].Title; title != "" {
+		return title
+	}
+	return message
+}
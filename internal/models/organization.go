@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Rate tiers that can be assigned to organizations
+const (
+	RateTierFree       = "free"
+	RateTierStandard   = "standard"
+	RateTierEnterprise = "enterprise"
+)
+
+// Organization represents a tenant that owns API keys and their usage
+type Organization struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TenantID  string             `bson:"tenant_id" json:"tenant_id"`
+	Name      string             `bson:"name" json:"name"`
+	Active    bool               `bson:"active" json:"active"`
+	RateTier  string             `bson:"rate_tier" json:"rate_tier"`
+	Quotas    OrganizationQuotas `bson:"quotas" json:"quotas"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// OrganizationQuotas holds usage limits for an organization. Zero means unlimited.
+type OrganizationQuotas struct {
+	MonthlyRequests int64 `bson:"monthly_requests,omitempty" json:"monthly_requests,omitempty"`
+	MonthlyWallets  int64 `bson:"monthly_wallets,omitempty" json:"monthly_wallets,omitempty"`
+	MaxKeys         int   `bson:"max_keys,omitempty" json:"max_keys,omitempty"`
+}
+
+// OrganizationUsage holds usage rolled up per organization and billing period
+type OrganizationUsage struct {
+	TenantID  string    `bson:"tenant_id" json:"tenant_id"`
+	Period    string    `bson:"period" json:"period"` // Calendar month, e.g. "2024-05"
+	Requests  int64     `bson:"requests" json:"requests"`
+	Wallets   int64     `bson:"wallets" json:"wallets"`
+	RPCCalls  int64     `bson:"rpc_calls" json:"rpc_calls"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// OrganizationResponse represents an organization with its current usage
+type OrganizationResponse struct {
+	Organization Organization      `json:"organization"`
+	Usage        OrganizationUsage `json:"usage"`
+}
+
+// UsagePeriod returns the billing period containing t
+func UsagePeriod(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Usage report granularities
+const (
+	GranularityHour  = "hour"
+	GranularityDay   = "day"
+	GranularityMonth = "month"
+)
+
+// UsageCounters holds metered usage for an API key
+type UsageCounters struct {
+	Requests    int64 `bson:"requests" json:"requests"`
+	Wallets     int64 `bson:"wallets" json:"wallets"`
+	CacheHits   int64 `bson:"cache_hits" json:"cache_hits"`
+	CacheMisses int64 `bson:"cache_misses" json:"cache_misses"`
+	RPCCalls    int64 `bson:"rpc_calls" json:"rpc_calls"`
+}
+
+// Add adds other to the counters
+func (u *UsageCounters) Add(other UsageCounters) {
+	u.Requests += other.Requests
+	u.Wallets += other.Wallets
+	u.CacheHits += other.CacheHits
+	u.CacheMisses += other.CacheMisses
+	u.RPCCalls += other.RPCCalls
+}
+
+// UsageBucket holds one API key's usage for one hour, as stored in MongoDB
+type UsageBucket struct {
+	KeyID         primitive.ObjectID `bson:"key_id" json:"key_id"`
+	TenantID      string             `bson:"tenant_id,omitempty" json:"tenant_id,omitempty"`
+	Bucket        time.Time          `bson:"bucket" json:"bucket"` // Start of the hour (UTC)
+	UsageCounters `bson:",inline"`
+	UpdatedAt     time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// UsageRecord is one row of a usage report
+type UsageRecord struct {
+	PeriodStart time.Time `json:"period_start"`
+	KeyID       string    `json:"key_id,omitempty"`
+	TenantID    string    `json:"tenant_id,omitempty"`
+	UsageCounters
+}
+
+// UsageReport represents the response of the usage endpoints
+type UsageReport struct {
+	KeyID       string        `json:"key_id,omitempty"`
+	From        time.Time     `json:"from"`
+	To          time.Time     `json:"to"`
+	Granularity string        `json:"granularity"`
+	Records     []UsageRecord `json:"records"`
+	Totals      UsageCounters `json:"totals"`
+}
+
+// UsageQuery selects usage for a report. A zero KeyID and empty TenantID select all keys.
+type UsageQuery struct {
+	KeyID       primitive.ObjectID
+	TenantID    string
+	From        time.Time
+	To          time.Time
+	Granularity string
+	PerKey      bool // Split records by key (admin reports)
+}
+
+// TruncateToGranularity returns the start of the period containing t (UTC)
+func TruncateToGranularity(t time.Time, granularity string) time.Time {
+	t = t.UTC()
+	switch granularity {
+	case GranularityMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case GranularityDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	default:
+		return t.Truncate(time.Hour)
+	}
+}
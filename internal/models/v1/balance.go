@@ -0,0 +1,88 @@
+// Package v1 holds the request and response bodies of version 1 of the API. They
+// are kept separate from the internal models so later versions can change their
+// shape without breaking v1 clients.
+package v1
+
+import "solana-balance-api/internal/models"
+
+// Balance response formats
+const (
+	FormatList = "list" // Balances in request order, duplicates repeated
+	FormatMap  = "map"  // Balances keyed by address
+)
+
+// BalanceRequest is the body of POST /api/v1/balances
+type BalanceRequest struct {
+	Wallets []string `json:"wallets"`
+	Format  string   `json:"format,omitempty"` // "list" (default) or "map"
+}
+
+// WalletBalance is the balance of a single wallet; Balance is in SOL
+type WalletBalance struct {
+	Address     string           `json:"address"`
+	AddressType string           `json:"address_type,omitempty"`
+	Balance     float64          `json:"balance"`
+	Status      string           `json:"status"`
+	ErrorCode   models.ErrorCode `json:"error_code,omitempty"`
+	Retryable   bool             `json:"retryable"`
+	Error       string           `json:"error,omitempty"`
+}
+
+// BalanceResponse lists balances in request order. Partial is set when at least one
+// wallet failed; the response is then sent with HTTP 207.
+type BalanceResponse struct {
+	Balances []WalletBalance `json:"balances"`
+	Cached   bool            `json:"cached"`
+	Partial  bool            `json:"partial"`
+}
+
+// BalanceMapResponse is a BalanceResponse with the balances keyed by address
+type BalanceMapResponse struct {
+	Balances map[string]WalletBalance `json:"balances"`
+	Cached   bool                     `json:"cached"`
+	Partial  bool                     `json:"partial"`
+}
+
+// NewWalletBalance converts a wallet balance to its v1 body
+func NewWalletBalance(balance models.WalletBalance) WalletBalance {
+	return WalletBalance{
+		Address:     balance.Address,
+		AddressType: balance.AddressType,
+		Balance:     balance.Balance,
+		Status:      balance.Status,
+		ErrorCode:   balance.ErrorCode,
+		Retryable:   balance.Retryable,
+		Error:       balance.Error,
+	}
+}
+
+// NewBalanceResponse converts balances to their v1 list body
+func NewBalanceResponse(response *models.BalanceResponse) *BalanceResponse {
+	balances := make([]WalletBalance, len(response.Balances))
+	for i, balance := range response.Balances {
+		balances[i] = NewWalletBalance(balance)
+	}
+	return &BalanceResponse{
+		Balances: balances,
+		Cached:   response.Cached,
+		Partial:  response.Partial,
+	}
+}
+
+// NewBalanceMapResponse converts balances to their v1 body keyed by address
+func NewBalanceMapResponse(response *models.BalanceResponse) *BalanceMapResponse {
+	balances := make(map[string]WalletBalance, len(response.Balances))
+	for _, balance := range response.Balances {
+		balances[balance.Address] = NewWalletBalance(balance)
+	}
+	return &BalanceMapResponse{
+		Balances: balances,
+		Cached:   response.Cached,
+		Partial:  response.Partial,
+	}
+}
+
+// ValidFormat reports whether format selects a response format; empty is the default
+func ValidFormat(format string) bool {
+	return format == "" || format == FormatList || format == FormatMap
+}
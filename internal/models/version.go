@@ -0,0 +1,13 @@
+package models
+
+// API versions
+const (
+	APIVersion1 = "1"
+)
+
+// APIVersions lists the supported API versions, the default first
+var APIVersions = []string{APIVersion1}
+
+// APIVersionHeader carries the API version: clients may send it to select a version
+// on unversioned paths, and every API response states the version it was served with
+const APIVersionHeader = "API-Version"
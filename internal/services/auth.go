@@ -2,33 +2,63 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"solana-balance-api/internal/config"
 	"solana-balance-api/internal/models"
+	"solana-balance-api/pkg/audit"
+	"solana-balance-api/pkg/logger"
+	"solana-balance-api/pkg/tracing"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
 )
 
 var (
-	ErrInvalidAPIKey  = errors.New("invalid API key")
-	ErrInactiveAPIKey = errors.New("API key is inactive")
-	ErrDatabaseError  = errors.New("database error")
+	ErrInvalidAPIKey       = errors.New("invalid API key")
+	ErrInactiveAPIKey      = errors.New("API key is inactive")
+	ErrExpiredAPIKey       = errors.New("API key has expired")
+	ErrAPIKeyNotYetValid   = errors.New("API key is not yet valid")
+	ErrAPIKeyNotFound      = errors.New("API key not found")
+	ErrKeyRotationConflict = errors.New("API key was modified during rotation")
+	ErrKeyRotationPending  = errors.New("previous API key secret is still in its grace window")
+	ErrDatabaseError       = errors.New("database error")
 )
 
-// AuthService handles API key authentication using MongoDB
+// AuthService handles API key authentication using MongoDB.
+// Lookups are cached and invalidated through change streams (or polling when
+// change streams are unavailable); last_used writes are batched.
 type AuthService struct {
 	db         *mongo.Database
 	collection *mongo.Collection
 	config     *config.MongoDBConfig
+	authConfig *config.AuthConfig
+
+	keyCache         *keyCache
+	invalidationMode atomic.Value // "change_stream" or "polling"
+
+	pendingLastUsed map[primitive.ObjectID]time.Time
+	lastUsedMutex   sync.Mutex
+	lastUsedFlushes int64
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
 }
 
 // NewAuthService creates a new authentication service with optimized MongoDB connection
-func NewAuthService(cfg *config.MongoDBConfig) (*AuthService, error) {
+func NewAuthService(cfg *config.MongoDBConfig, authCfg *config.AuthConfig) (*AuthService, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
 	defer cancel()
 
@@ -83,58 +113,624 @@ func NewAuthService(cfg *config.MongoDBConfig) (*AuthService, error) {
 		// We'll continue without failing
 	}
 
-	return &AuthService{
-		db:         db,
-		collection: collection,
-		config:     cfg,
-	}, nil
+	a := &AuthService{
+		db:              db,
+		collection:      collection,
+		config:          cfg,
+		authConfig:      authCfg,
+		keyCache:        newKeyCache(authCfg.KeyCacheMaxSize),
+		pendingLastUsed: make(map[primitive.ObjectID]time.Time),
+		stopCh:          make(chan struct{}),
+	}
+	a.invalidationMode.Store("starting")
+
+	// Start background cache invalidation, last_used flushing and expiry sweeping
+	a.wg.Add(3)
+	go a.runInvalidation()
+	go a.runLastUsedFlusher()
+	go a.runExpirySweeper()
+
+	return a, nil
 }
 
-// ValidateAPIKey validates an API key against the MongoDB database
-func (a *AuthService) ValidateAPIKey(key string) (*models.APIKey, error) {
+// ValidateAPIKey validates an API key against the MongoDB database. The lookup runs
+// in an AuthService.ValidateAPIKey span, so database time shows up under it.
+func (a *AuthService) ValidateAPIKey(ctx context.Context, key string) (*models.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.ValidateAPIKey")
+	defer span.End()
+
+	apiKey, cacheHit, err := a.validateAPIKey(ctx, key)
+	span.SetAttributes(attribute.Bool("cache.hit", cacheHit))
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.String("api_key_id", apiKey.ID.Hex()))
+	return apiKey, nil
+}
+
+// validateAPIKey implements ValidateAPIKey and reports whether the key cache answered
+func (a *AuthService) validateAPIKey(ctx context.Context, key string) (*models.APIKey, bool, error) {
 	if key == "" {
-		return nil, ErrInvalidAPIKey
+		return nil, false, ErrInvalidAPIKey
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	apiKey, found := a.keyCache.get(key)
+	if !found {
+		var err error
+		apiKey, err = a.lookupAPIKey(ctx, key)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	// Negative cache entry: key does not exist
+	if apiKey == nil {
+		return nil, found, ErrInvalidAPIKey
+	}
+
+	if err := checkKeyValidity(apiKey, key, time.Now()); err != nil {
+		return nil, found, err
+	}
+
+	// Record last used timestamp (flushed in batches)
+	a.recordLastUsed(apiKey.ID)
+
+	// Return a copy so callers cannot mutate the cached document
+	validated := *apiKey
+	return &validated, found, nil
+}
+
+// lookupAPIKey loads an API key from MongoDB and caches the result.
+// A nil key with nil error means the key does not exist.
+func (a *AuthService) lookupAPIKey(ctx context.Context, key string) (*models.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
+	// Taken before the read, so an invalidation racing it discards the result
+	generation := a.keyCache.currentGeneration()
+
 	var apiKey models.APIKey
-	filter := bson.M{"key": key}
+	// Match the current secret or a rotated secret still inside its grace window
+	filter := bson.M{"$or": []bson.M{
+		{"key": key},
+		{"previous_key": key},
+	}}
 
 	err := a.collection.FindOne(ctx, filter).Decode(&apiKey)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, ErrInvalidAPIKey
+			a.keyCache.fill(key, nil, a.authConfig.KeyCacheNegativeTTL, generation)
+			return nil, nil
 		}
 		return nil, ErrDatabaseError
 	}
 
+	a.keyCache.fill(key, &apiKey, a.authConfig.KeyCacheTTL, generation)
+	return &apiKey, nil
+}
+
+// checkKeyValidity enforces the key's lifecycle fields for the presented secret
+func checkKeyValidity(apiKey *models.APIKey, secret string, now time.Time) error {
 	// Check if API key is active
 	if !apiKey.Active {
-		return nil, ErrInactiveAPIKey
+		return ErrInactiveAPIKey
+	}
+
+	if apiKey.NotBefore != nil && now.Before(*apiKey.NotBefore) {
+		return ErrAPIKeyNotYetValid
 	}
 
-	// Update last used timestamp
-	go a.updateLastUsed(apiKey.ID)
+	if apiKey.ExpiresAt != nil && !now.Before(*apiKey.ExpiresAt) {
+		return ErrExpiredAPIKey
+	}
 
-	return &apiKey, nil
+	// A rotated secret only works until its grace window closes
+	if secret != apiKey.Key {
+		if secret != apiKey.PreviousKey || apiKey.PreviousKeyExpiresAt == nil || !now.Before(*apiKey.PreviousKeyExpiresAt) {
+			return ErrExpiredAPIKey
+		}
+	}
+
+	return nil
 }
 
-// updateLastUsed updates the last_used timestamp for an API key
-func (a *AuthService) updateLastUsed(id interface{}) {
+// RotateAPIKey replaces a key's secret. The old secret stays valid for the grace
+// period; a nil grace uses the configured default and zero revokes it immediately.
+// While an earlier rotation's grace window is open only an immediate rotation is
+// allowed, which revokes both old secrets.
+func (a *AuthService) RotateAPIKey(id primitive.ObjectID, grace *time.Duration) (*models.APIKey, error) {
+	gracePeriod := a.authConfig.KeyRotationGracePeriod
+	if grace != nil {
+		gracePeriod = *grace
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	now := time.Now()
-	filter := bson.M{"_id": id}
-	update := bson.M{"$set": bson.M{"last_used": now}}
+	var current models.APIKey
+	if err := a.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&current); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, ErrDatabaseError
+	}
+
+	newSecret, err := generateAPIKeySecret()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	update, err := rotationUpdate(&current, newSecret, gracePeriod, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only rotate if nobody else rotated the key in the meantime
+	filter := bson.M{"_id": id, "key": current.Key}
+	result, err := a.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return nil, ErrDatabaseError
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrKeyRotationConflict
+	}
+
+	a.keyCache.invalidateID(id)
+
+	// The old secret stays valid only with a grace period
+	previousKey := current.Key
+	current.Key = newSecret
+	current.PreviousKey = ""
+	current.PreviousKeyExpiresAt = nil
+	if gracePeriod > 0 {
+		previousExpiresAt := now.Add(gracePeriod)
+		current.PreviousKey = previousKey
+		current.PreviousKeyExpiresAt = &previousExpiresAt
+	}
+	current.RotatedAt = &now
+	return &current, nil
+}
+
+// rotationUpdate builds the update that rotates apiKey to secret with the given grace
+// period, rejecting rotations that would cut an open grace window short
+func rotationUpdate(apiKey *models.APIKey, secret string, grace time.Duration, now time.Time) (bson.M, error) {
+	if grace == 0 {
+		return bson.M{
+			"$set":   bson.M{"key": secret, "rotated_at": now},
+			"$unset": bson.M{"previous_key": "", "previous_key_expires_at": ""},
+		}, nil
+	}
+
+	if apiKey.PreviousKey != "" && apiKey.PreviousKeyExpiresAt != nil && now.Before(*apiKey.PreviousKeyExpiresAt) {
+		return nil, ErrKeyRotationPending
+	}
+
+	return bson.M{"$set": bson.M{
+		"key":                     secret,
+		"previous_key":            apiKey.Key,
+		"previous_key_expires_at": now.Add(grace),
+		"rotated_at":              now,
+	}}, nil
+}
+
+// generateAPIKeySecret generates a cryptographically secure random API key secret
+func generateAPIKeySecret() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// runExpirySweeper periodically deactivates expired keys and drops expired rotated secrets
+func (a *AuthService) runExpirySweeper() {
+	defer a.wg.Done()
+
+	interval := a.authConfig.KeyExpirySweepInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := a.sweepExpiredKeys(); err != nil {
+				logger.GetLogger().Warn("API key expiry sweep failed", zap.Error(err))
+			}
+		case <-a.stopCh:
+			return
+		}
+	}
+}
+
+// sweepExpiredKeys deactivates keys past expires_at and unsets rotated secrets past their grace window
+func (a *AuthService) sweepExpiredKeys() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	log := logger.GetLogger().WithFields(map[string]interface{}{"component": logger.ComponentAuth})
+	now := time.Now().UTC()
+
+	cursor, err := a.collection.Find(ctx,
+		bson.M{"active": true, "expires_at": bson.M{"$lte": now}},
+		options.Find().SetProjection(bson.M{"_id": 1, "name": 1, "expires_at": 1}),
+	)
+	if err != nil {
+		return err
+	}
+
+	var expired []models.APIKey
+	if err := cursor.All(ctx, &expired); err != nil {
+		return err
+	}
+
+	if len(expired) > 0 {
+		ids := make([]primitive.ObjectID, 0, len(expired))
+		for _, apiKey := range expired {
+			ids = append(ids, apiKey.ID)
+		}
+
+		_, err := a.collection.UpdateMany(ctx,
+			bson.M{"_id": bson.M{"$in": ids}, "active": true},
+			bson.M{"$set": bson.M{
+				"active":              false,
+				"deactivated_at":      now,
+				"deactivation_reason": "expired",
+			}},
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, apiKey := range expired {
+			a.keyCache.invalidateID(apiKey.ID)
+			log.Info("Expired API key deactivated",
+				zap.String("audit_event", "key_auto_deactivated"),
+				zap.String("api_key_id", apiKey.ID.Hex()),
+				zap.String("api_key_name", apiKey.Name),
+				zap.Timep("expires_at", apiKey.ExpiresAt),
+			)
+			audit.Record(audit.Event{
+				Type:     audit.EventKeyAutoDeactivated,
+				Outcome:  audit.OutcomeSuccess,
+				TenantID: apiKey.TenantID,
+				Target:   apiKey.ID.Hex(),
+				Details:  map[string]string{"reason": "expired"},
+			})
+		}
+	}
+
+	// Forget rotated secrets whose grace window has closed
+	result, err := a.collection.UpdateMany(ctx,
+		bson.M{"previous_key_expires_at": bson.M{"$lte": now}},
+		bson.M{"$unset": bson.M{"previous_key": "", "previous_key_expires_at": ""}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount > 0 {
+		log.Info("Removed expired rotated API key secrets", zap.Int64("count", result.ModifiedCount))
+	}
+
+	return nil
+}
+
+// recordLastUsed queues a last_used update for the next batch flush
+func (a *AuthService) recordLastUsed(id primitive.ObjectID) {
+	a.lastUsedMutex.Lock()
+	defer a.lastUsedMutex.Unlock()
+
+	a.pendingLastUsed[id] = time.Now()
+}
+
+// flushLastUsed writes all pending last_used timestamps in a single bulk write
+func (a *AuthService) flushLastUsed() error {
+	a.lastUsedMutex.Lock()
+	pending := a.pendingLastUsed
+	a.pendingLastUsed = make(map[primitive.ObjectID]time.Time)
+	a.lastUsedMutex.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(pending))
+	for id, lastUsed := range pending {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id}).
+			SetUpdate(bson.M{"$max": bson.M{"last_used": lastUsed}}))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := a.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	atomic.AddInt64(&a.lastUsedFlushes, 1)
+	return err
+}
+
+// runLastUsedFlusher periodically flushes batched last_used writes
+func (a *AuthService) runLastUsedFlusher() {
+	defer a.wg.Done()
+
+	interval := a.authConfig.LastUsedFlushInterval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := a.flushLastUsed(); err != nil {
+				logger.GetLogger().Warn("Failed to flush API key last_used timestamps", zap.Error(err))
+			}
+		case <-a.stopCh:
+			return
+		}
+	}
+}
+
+// runInvalidation keeps the key cache consistent with the database. It prefers
+// change streams and falls back to polling when they are not supported
+// (e.g. standalone MongoDB servers).
+func (a *AuthService) runInvalidation() {
+	defer a.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-a.stopCh
+		cancel()
+	}()
+
+	log := logger.GetLogger().WithFields(map[string]interface{}{"component": logger.ComponentAuth})
+
+	for {
+		opened, err := a.watchChanges(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if !opened {
+			log.Info("Change streams unavailable, using polling for API key cache invalidation",
+				zap.Error(err),
+				zap.Duration("poll_interval", a.authConfig.KeyCachePollInterval),
+			)
+			a.pollChanges(ctx)
+			return
+		}
+
+		// Stream broke: drop everything we might have missed and reconnect
+		log.Warn("API key change stream interrupted, clearing cache and reconnecting", zap.Error(err))
+		a.keyCache.clear()
+
+		select {
+		case <-time.After(a.authConfig.KeyCachePollInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// watchChanges consumes the change stream until it fails. opened reports whether
+// the stream could be opened at all.
+func (a *AuthService) watchChanges(ctx context.Context) (opened bool, err error) {
+	streamOptions := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	stream, err := a.collection.Watch(ctx, mongo.Pipeline{}, streamOptions)
+	if err != nil {
+		return false, err
+	}
+	defer stream.Close(context.Background())
+
+	a.invalidationMode.Store("change_stream")
+
+	for stream.Next(ctx) {
+		a.applyChangeEvent(stream.Current)
+	}
+
+	return true, stream.Err()
+}
+
+// applyChangeEvent invalidates the cache entries affected by a change stream event
+func (a *AuthService) applyChangeEvent(raw bson.Raw) {
+	var event struct {
+		OperationType string `bson:"operationType"`
+		DocumentKey   struct {
+			ID primitive.ObjectID `bson:"_id"`
+		} `bson:"documentKey"`
+		FullDocument *models.APIKey `bson:"fullDocument"`
+	}
+	if err := bson.Unmarshal(raw, &event); err != nil {
+		return
+	}
+
+	// Ignore our own batched last_used writes
+	if event.OperationType == "update" && a.isLastUsedOnlyUpdate(raw) {
+		return
+	}
+
+	a.keyCache.invalidateID(event.DocumentKey.ID)
+	if event.FullDocument != nil {
+		a.keyCache.invalidateKey(event.FullDocument.Key)
+		if event.FullDocument.PreviousKey != "" {
+			a.keyCache.invalidateKey(event.FullDocument.PreviousKey)
+		}
+	}
+	if event.OperationType == "drop" || event.OperationType == "invalidate" {
+		a.keyCache.clear()
+	}
+}
+
+// isLastUsedOnlyUpdate reports whether an update event only touched last_used
+func (a *AuthService) isLastUsedOnlyUpdate(raw bson.Raw) bool {
+	var event struct {
+		UpdateDescription struct {
+			UpdatedFields bson.M   `bson:"updatedFields"`
+			RemovedFields []string `bson:"removedFields"`
+		} `bson:"updateDescription"`
+	}
+	if err := bson.Unmarshal(raw, &event); err != nil {
+		return false
+	}
+
+	fields := event.UpdateDescription.UpdatedFields
+	_, hasLastUsed := fields["last_used"]
+	return len(fields) == 1 && hasLastUsed && len(event.UpdateDescription.RemovedFields) == 0
+}
+
+// pollChanges periodically re-reads cached keys and invalidates stale entries
+func (a *AuthService) pollChanges(ctx context.Context) {
+	a.invalidationMode.Store("polling")
+
+	interval := a.authConfig.KeyCachePollInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := a.pollOnce(ctx); err != nil && ctx.Err() == nil {
+				logger.GetLogger().Warn("API key cache poll failed", zap.Error(err))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// pollOnce compares cached entries with the database and invalidates differences
+func (a *AuthService) pollOnce(ctx context.Context) error {
+	positive, negative := a.keyCache.snapshot()
+
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if len(positive) > 0 {
+		ids := make([]primitive.ObjectID, 0, len(positive))
+		for id := range positive {
+			ids = append(ids, id)
+		}
+
+		cursor, err := a.collection.Find(queryCtx, bson.M{"_id": bson.M{"$in": ids}})
+		if err != nil {
+			return err
+		}
+
+		var current []models.APIKey
+		if err := cursor.All(queryCtx, &current); err != nil {
+			return err
+		}
+
+		seen := make(map[primitive.ObjectID]bool, len(current))
+		for i := range current {
+			seen[current[i].ID] = true
+			if !sameKeyState(positive[current[i].ID], &current[i]) {
+				a.keyCache.invalidateID(current[i].ID)
+			}
+		}
 
-	a.collection.UpdateOne(ctx, filter, update)
+		// Deleted keys
+		for id := range positive {
+			if !seen[id] {
+				a.keyCache.invalidateID(id)
+			}
+		}
+	}
+
+	if len(negative) > 0 {
+		filter := bson.M{"$or": []bson.M{
+			{"key": bson.M{"$in": negative}},
+			{"previous_key": bson.M{"$in": negative}},
+		}}
+		cursor, err := a.collection.Find(queryCtx, filter,
+			options.Find().SetProjection(bson.M{"key": 1, "previous_key": 1}))
+		if err != nil {
+			return err
+		}
+
+		var created []models.APIKey
+		if err := cursor.All(queryCtx, &created); err != nil {
+			return err
+		}
+
+		// Keys that now exist must not stay negatively cached
+		for _, apiKey := range created {
+			a.keyCache.invalidateKey(apiKey.Key)
+			if apiKey.PreviousKey != "" {
+				a.keyCache.invalidateKey(apiKey.PreviousKey)
+			}
+		}
+	}
+
+	return nil
+}
+
+// sameKeyState compares two key documents, ignoring last_used
+func sameKeyState(cached, current *models.APIKey) bool {
+	if cached == nil || current == nil {
+		return false
+	}
+
+	left, right := *cached, *current
+	left.LastUsed, right.LastUsed = nil, nil
+	left.CreatedAt, right.CreatedAt = left.CreatedAt.UTC(), right.CreatedAt.UTC()
+
+	return reflect.DeepEqual(left, right)
+}
+
+// Database returns the MongoDB database used by the auth service, so other
+// services can share its connection pool
+func (a *AuthService) Database() *mongo.Database {
+	return a.db
+}
+
+// InvalidateCache drops all cached key lookups
+func (a *AuthService) InvalidateCache() {
+	a.keyCache.clear()
+}
+
+// GetCacheStats returns key cache statistics for monitoring
+func (a *AuthService) GetCacheStats() map[string]interface{} {
+	a.lastUsedMutex.Lock()
+	pending := len(a.pendingLastUsed)
+	a.lastUsedMutex.Unlock()
+
+	return map[string]interface{}{
+		"cache_size":              a.keyCache.size(),
+		"cache_max_size":          a.keyCache.maxSize,
+		"cache_hits":              atomic.LoadInt64(&a.keyCache.hits),
+		"cache_misses":            atomic.LoadInt64(&a.keyCache.misses),
+		"cache_hit_ratio_percent": a.keyCache.hitRatio(),
+		"cache_invalidations":     atomic.LoadInt64(&a.keyCache.invalidations),
+		"invalidation_mode":       a.invalidationMode.Load(),
+		"last_used_pending":       pending,
+		"last_used_flushes":       atomic.LoadInt64(&a.lastUsedFlushes),
+	}
 }
 
-// Close closes the MongoDB connection
+// Close stops background routines, flushes pending writes and closes the MongoDB connection
 func (a *AuthService) Close() error {
+	a.stopOnce.Do(func() {
+		close(a.stopCh)
+	})
+	a.wg.Wait()
+
+	if err := a.flushLastUsed(); err != nil {
+		logger.GetLogger().Warn("Failed to flush API key last_used timestamps on close", zap.Error(err))
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"solana-balance-api/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// keyCacheEntry holds a cached lookup result. A nil apiKey is a negative entry
+// (the key does not exist in the database).
+type keyCacheEntry struct {
+	key       string
+	apiKey    *models.APIKey
+	expiresAt time.Time
+	element   *list.Element
+}
+
+// keyCache is a bounded LRU cache of API key lookups with per-entry TTLs.
+// A document can be cached under several secrets while a rotation grace window is open.
+type keyCache struct {
+	entries map[string]*keyCacheEntry
+	byID    map[primitive.ObjectID]map[string]struct{}
+	lru     *list.List
+	maxSize int
+	mutex   sync.Mutex
+
+	// generation counts invalidations. Each invalidated key and document records the
+	// generation of its last invalidation, so a lookup that raced one is not cached
+	// while lookups of other keys are unaffected. Fills started before
+	// invalidatedBefore are dropped; clear and pruning the records advance it.
+	generation        uint64
+	invalidatedKeys   map[string]uint64
+	invalidatedIDs    map[primitive.ObjectID]uint64
+	invalidatedBefore uint64
+
+	hits          int64
+	misses        int64
+	invalidations int64
+}
+
+// newKeyCache creates a new key cache bounded to maxSize entries
+func newKeyCache(maxSize int) *keyCache {
+	return &keyCache{
+		entries:         make(map[string]*keyCacheEntry),
+		byID:            make(map[primitive.ObjectID]map[string]struct{}),
+		lru:             list.New(),
+		maxSize:         maxSize,
+		invalidatedKeys: make(map[string]uint64),
+		invalidatedIDs:  make(map[primitive.ObjectID]uint64),
+	}
+}
+
+// maxInvalidationRecords bounds the per-key and per-ID invalidation records. Past
+// it the records are dropped and every lookup in flight is treated as stale.
+const maxInvalidationRecords = 4096
+
+// get returns the cached lookup for a key. found is false on a miss or expired entry.
+func (kc *keyCache) get(key string) (apiKey *models.APIKey, found bool) {
+	kc.mutex.Lock()
+	defer kc.mutex.Unlock()
+
+	entry, exists := kc.entries[key]
+	if !exists || time.Now().After(entry.expiresAt) {
+		if exists {
+			kc.removeLocked(entry)
+		}
+		atomic.AddInt64(&kc.misses, 1)
+		return nil, false
+	}
+
+	kc.lru.MoveToFront(entry.element)
+	atomic.AddInt64(&kc.hits, 1)
+	return entry.apiKey, true
+}
+
+// currentGeneration returns the invalidation generation, to be passed to fill
+func (kc *keyCache) currentGeneration() uint64 {
+	kc.mutex.Lock()
+	defer kc.mutex.Unlock()
+
+	return kc.generation
+}
+
+// fill stores the result of a lookup started at the given generation, evicting the
+// least recently used entry when full. The result is dropped if its key or document
+// was invalidated since, as it may predate the change.
+func (kc *keyCache) fill(key string, apiKey *models.APIKey, ttl time.Duration, generation uint64) {
+	kc.mutex.Lock()
+	defer kc.mutex.Unlock()
+
+	if generation < kc.invalidatedBefore || kc.invalidatedKeys[key] > generation {
+		return
+	}
+	if apiKey != nil && kc.invalidatedIDs[apiKey.ID] > generation {
+		return
+	}
+	kc.setLocked(key, apiKey, ttl)
+}
+
+// setLocked stores a lookup result. Caller must hold the mutex.
+func (kc *keyCache) setLocked(key string, apiKey *models.APIKey, ttl time.Duration) {
+	if kc.maxSize <= 0 || ttl <= 0 {
+		return
+	}
+
+	if existing, exists := kc.entries[key]; exists {
+		kc.removeLocked(existing)
+	}
+
+	for len(kc.entries) >= kc.maxSize {
+		oldest := kc.lru.Back()
+		if oldest == nil {
+			break
+		}
+		kc.removeLocked(oldest.Value.(*keyCacheEntry))
+	}
+
+	entry := &keyCacheEntry{
+		key:       key,
+		apiKey:    apiKey,
+		expiresAt: time.Now().Add(ttl),
+	}
+	entry.element = kc.lru.PushFront(entry)
+	kc.entries[key] = entry
+
+	if apiKey != nil {
+		if kc.byID[apiKey.ID] == nil {
+			kc.byID[apiKey.ID] = make(map[string]struct{})
+		}
+		kc.byID[apiKey.ID][key] = struct{}{}
+	}
+}
+
+// invalidateID removes every entry for a document ID
+func (kc *keyCache) invalidateID(id primitive.ObjectID) {
+	kc.mutex.Lock()
+	defer kc.mutex.Unlock()
+
+	kc.generation++
+	kc.invalidatedIDs[id] = kc.generation
+	kc.pruneInvalidationsLocked()
+	for key := range kc.byID[id] {
+		if entry, exists := kc.entries[key]; exists {
+			kc.removeLocked(entry)
+			atomic.AddInt64(&kc.invalidations, 1)
+		}
+	}
+}
+
+// invalidateKey removes the entry for a key string
+func (kc *keyCache) invalidateKey(key string) {
+	kc.mutex.Lock()
+	defer kc.mutex.Unlock()
+
+	kc.generation++
+	kc.invalidatedKeys[key] = kc.generation
+	kc.pruneInvalidationsLocked()
+	if entry, exists := kc.entries[key]; exists {
+		kc.removeLocked(entry)
+		atomic.AddInt64(&kc.invalidations, 1)
+	}
+}
+
+// clear removes all entries
+func (kc *keyCache) clear() {
+	kc.mutex.Lock()
+	defer kc.mutex.Unlock()
+
+	kc.generation++
+	kc.invalidatedBefore = kc.generation
+	kc.invalidatedKeys = make(map[string]uint64)
+	kc.invalidatedIDs = make(map[primitive.ObjectID]uint64)
+	kc.entries = make(map[string]*keyCacheEntry)
+	kc.byID = make(map[primitive.ObjectID]map[string]struct{})
+	kc.lru.Init()
+}
+
+// pruneInvalidationsLocked drops the invalidation records once they exceed
+// maxInvalidationRecords. Caller must hold the mutex.
+func (kc *keyCache) pruneInvalidationsLocked() {
+	if len(kc.invalidatedKeys)+len(kc.invalidatedIDs) <= maxInvalidationRecords {
+		return
+	}
+	kc.invalidatedBefore = kc.generation
+	kc.invalidatedKeys = make(map[string]uint64)
+	kc.invalidatedIDs = make(map[primitive.ObjectID]uint64)
+}
+
+// snapshot returns the cached positive entries by ID and the negative keys, for polling
+func (kc *keyCache) snapshot() (positive map[primitive.ObjectID]*models.APIKey, negative []string) {
+	kc.mutex.Lock()
+	defer kc.mutex.Unlock()
+
+	positive = make(map[primitive.ObjectID]*models.APIKey)
+	for _, entry := range kc.entries {
+		if entry.apiKey != nil {
+			positive[entry.apiKey.ID] = entry.apiKey
+		} else {
+			negative = append(negative, entry.key)
+		}
+	}
+	return positive, negative
+}
+
+// size returns the number of cached entries
+func (kc *keyCache) size() int {
+	kc.mutex.Lock()
+	defer kc.mutex.Unlock()
+
+	return len(kc.entries)
+}
+
+// removeLocked removes an entry. Caller must hold the mutex.
+func (kc *keyCache) removeLocked(entry *keyCacheEntry) {
+	kc.lru.Remove(entry.element)
+	delete(kc.entries, entry.key)
+	if entry.apiKey != nil {
+		if keys := kc.byID[entry.apiKey.ID]; keys != nil {
+			delete(keys, entry.key)
+			if len(keys) == 0 {
+				delete(kc.byID, entry.apiKey.ID)
+			}
+		}
+	}
+}
+
+// hitRatio returns the cache hit ratio as a percentage
+func (kc *keyCache) hitRatio() float64 {
+	hits := atomic.LoadInt64(&kc.hits)
+	total := hits + atomic.LoadInt64(&kc.misses)
+	if total == 0 {
+		return 0.0
+	}
+	return float64(hits) / float64(total) * 100.0
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"solana-balance-api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestKeyCache(t *testing.T) {
+	t.Run("PositiveAndNegative", func(t *testing.T) {
+		cache := newKeyCache(10)
+		apiKey := &models.APIKey{ID: primitive.NewObjectID(), Key: "secret"}
+
+		cache.fill("secret", apiKey, time.Minute, cache.currentGeneration())
+		cache.fill("missing", nil, time.Minute, cache.currentGeneration())
+
+		cached, found := cache.get("secret")
+		assert.True(t, found)
+		assert.Equal(t, apiKey, cached)
+
+		cached, found = cache.get("missing")
+		assert.True(t, found)
+		assert.Nil(t, cached)
+
+		_, found = cache.get("unknown")
+		assert.False(t, found)
+		assert.InDelta(t, 66.7, cache.hitRatio(), 0.1)
+	})
+
+	t.Run("Expiry", func(t *testing.T) {
+		cache := newKeyCache(10)
+		cache.fill("secret", &models.APIKey{ID: primitive.NewObjectID()}, time.Millisecond, cache.currentGeneration())
+
+		time.Sleep(5 * time.Millisecond)
+
+		_, found := cache.get("secret")
+		assert.False(t, found)
+		assert.Equal(t, 0, cache.size())
+	})
+
+	t.Run("LRUEviction", func(t *testing.T) {
+		cache := newKeyCache(2)
+		cache.fill("a", nil, time.Minute, cache.currentGeneration())
+		cache.fill("b", nil, time.Minute, cache.currentGeneration())
+		cache.get("a")
+		cache.fill("c", nil, time.Minute, cache.currentGeneration())
+
+		_, found := cache.get("b")
+		assert.False(t, found, "least recently used entry should be evicted")
+		_, found = cache.get("a")
+		assert.True(t, found)
+	})
+
+	t.Run("InvalidateIDCoversRotatedSecrets", func(t *testing.T) {
+		cache := newKeyCache(10)
+		apiKey := &models.APIKey{ID: primitive.NewObjectID(), Key: "new", PreviousKey: "old"}
+		cache.fill("new", apiKey, time.Minute, cache.currentGeneration())
+		cache.fill("old", apiKey, time.Minute, cache.currentGeneration())
+
+		cache.invalidateID(apiKey.ID)
+
+		assert.Equal(t, 0, cache.size())
+		assert.EqualValues(t, 2, cache.invalidations)
+	})
+
+	t.Run("FillRacingInvalidationIsDropped", func(t *testing.T) {
+		cache := newKeyCache(10)
+		apiKey := &models.APIKey{ID: primitive.NewObjectID(), Key: "secret", Active: true}
+
+		// The key is revoked while its lookup is in flight
+		generation := cache.currentGeneration()
+		cache.invalidateID(apiKey.ID)
+		cache.fill("secret", apiKey, time.Minute, generation)
+
+		_, found := cache.get("secret")
+		assert.False(t, found)
+
+		cache.fill("secret", apiKey, time.Minute, cache.currentGeneration())
+		_, found = cache.get("secret")
+		assert.True(t, found)
+
+		generation = cache.currentGeneration()
+		cache.clear()
+		cache.fill("missing", nil, time.Minute, generation)
+		assert.Equal(t, 0, cache.size())
+	})
+
+	t.Run("InvalidationKeepsOtherFills", func(t *testing.T) {
+		cache := newKeyCache(10)
+		apiKey := &models.APIKey{ID: primitive.NewObjectID(), Key: "secret", Active: true}
+		other := &models.APIKey{ID: primitive.NewObjectID(), Key: "other", Active: true}
+
+		// Another key changes while both lookups are in flight
+		generation := cache.currentGeneration()
+		cache.invalidateID(other.ID)
+		cache.invalidateKey("unrelated")
+		cache.fill("secret", apiKey, time.Minute, generation)
+		cache.fill("missing", nil, time.Minute, generation)
+		cache.fill("other", other, time.Minute, generation)
+
+		_, found := cache.get("secret")
+		assert.True(t, found)
+		_, found = cache.get("missing")
+		assert.True(t, found)
+		_, found = cache.get("other")
+		assert.False(t, found)
+
+		// A negative lookup racing the insert of its key is dropped
+		generation = cache.currentGeneration()
+		cache.invalidateKey("new")
+		cache.fill("new", nil, time.Minute, generation)
+		_, found = cache.get("new")
+		assert.False(t, found)
+	})
+
+	t.Run("PrunedRecordsDropRacingFills", func(t *testing.T) {
+		cache := newKeyCache(10)
+		generation := cache.currentGeneration()
+		for i := 0; i <= maxInvalidationRecords; i++ {
+			cache.invalidateID(primitive.NewObjectID())
+		}
+		assert.Empty(t, cache.invalidatedIDs)
+
+		cache.fill("secret", nil, time.Minute, generation)
+		assert.Equal(t, 0, cache.size())
+	})
+}
+
+func TestApplyChangeEvent(t *testing.T) {
+	apiKey := &models.APIKey{ID: primitive.NewObjectID(), Key: "new", PreviousKey: "old", Active: true}
+	event := func(doc bson.M) bson.Raw {
+		raw, err := bson.Marshal(doc)
+		require.NoError(t, err)
+		return raw
+	}
+	newService := func() *AuthService {
+		a := &AuthService{keyCache: newKeyCache(10)}
+		a.keyCache.fill("new", apiKey, time.Minute, a.keyCache.currentGeneration())
+		a.keyCache.fill("old", apiKey, time.Minute, a.keyCache.currentGeneration())
+		a.keyCache.fill("unrelated", nil, time.Minute, a.keyCache.currentGeneration())
+		return a
+	}
+
+	t.Run("LastUsedOnlyUpdateIgnored", func(t *testing.T) {
+		a := newService()
+		a.applyChangeEvent(event(bson.M{
+			"operationType":     "update",
+			"documentKey":       bson.M{"_id": apiKey.ID},
+			"updateDescription": bson.M{"updatedFields": bson.M{"last_used": time.Now()}, "removedFields": bson.A{}},
+		}))
+		assert.Equal(t, 3, a.keyCache.size())
+	})
+
+	t.Run("RevocationInvalidatesEverySecret", func(t *testing.T) {
+		a := newService()
+		a.applyChangeEvent(event(bson.M{
+			"operationType":     "update",
+			"documentKey":       bson.M{"_id": apiKey.ID},
+			"updateDescription": bson.M{"updatedFields": bson.M{"active": false}, "removedFields": bson.A{}},
+		}))
+		assert.Equal(t, 1, a.keyCache.size())
+	})
+
+	t.Run("InsertInvalidatesNegativeEntry", func(t *testing.T) {
+		a := newService()
+		a.applyChangeEvent(event(bson.M{
+			"operationType": "insert",
+			"documentKey":   bson.M{"_id": primitive.NewObjectID()},
+			"fullDocument":  bson.M{"key": "unrelated", "active": true},
+		}))
+		assert.Equal(t, 2, a.keyCache.size())
+	})
+
+	t.Run("DropClearsCache", func(t *testing.T) {
+		a := newService()
+		a.applyChangeEvent(event(bson.M{"operationType": "drop"}))
+		assert.Equal(t, 0, a.keyCache.size())
+	})
+}
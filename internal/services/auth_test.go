@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"solana-balance-api/internal/models"
+	"solana-balance-api/pkg/tracing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCheckKeyValidity(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	t.Run("Active", func(t *testing.T) {
+		apiKey := &models.APIKey{Key: "secret", Active: true}
+		assert.NoError(t, checkKeyValidity(apiKey, "secret", now))
+	})
+
+	t.Run("Inactive", func(t *testing.T) {
+		apiKey := &models.APIKey{Key: "secret", Active: false}
+		assert.Equal(t, ErrInactiveAPIKey, checkKeyValidity(apiKey, "secret", now))
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		apiKey := &models.APIKey{Key: "secret", Active: true, ExpiresAt: &past}
+		assert.Equal(t, ErrExpiredAPIKey, checkKeyValidity(apiKey, "secret", now))
+	})
+
+	t.Run("NotYetValid", func(t *testing.T) {
+		apiKey := &models.APIKey{Key: "secret", Active: true, NotBefore: &future}
+		assert.Equal(t, ErrAPIKeyNotYetValid, checkKeyValidity(apiKey, "secret", now))
+	})
+
+	t.Run("RotatedSecretInGraceWindow", func(t *testing.T) {
+		apiKey := &models.APIKey{Key: "new", PreviousKey: "old", PreviousKeyExpiresAt: &future, Active: true}
+		assert.NoError(t, checkKeyValidity(apiKey, "old", now))
+		assert.NoError(t, checkKeyValidity(apiKey, "new", now))
+	})
+
+	t.Run("RotatedSecretAfterGraceWindow", func(t *testing.T) {
+		apiKey := &models.APIKey{Key: "new", PreviousKey: "old", PreviousKeyExpiresAt: &past, Active: true}
+		assert.Equal(t, ErrExpiredAPIKey, checkKeyValidity(apiKey, "old", now))
+		assert.NoError(t, checkKeyValidity(apiKey, "new", now))
+	})
+}
+
+func TestRotationUpdate(t *testing.T) {
+	now := time.Now()
+	future := now.Add(time.Hour)
+	past := now.Add(-time.Hour)
+
+	t.Run("GraceKeepsPreviousSecret", func(t *testing.T) {
+		update, err := rotationUpdate(&models.APIKey{Key: "old"}, "new", time.Hour, now)
+		assert.NoError(t, err)
+		set := update["$set"].(bson.M)
+		assert.Equal(t, "old", set["previous_key"])
+		assert.Equal(t, future, set["previous_key_expires_at"])
+	})
+
+	t.Run("ZeroGraceRevokesImmediately", func(t *testing.T) {
+		update, err := rotationUpdate(&models.APIKey{Key: "old"}, "new", 0, now)
+		assert.NoError(t, err)
+		assert.NotContains(t, update["$set"], "previous_key")
+		assert.Contains(t, update["$unset"], "previous_key")
+	})
+
+	t.Run("OpenGraceWindow", func(t *testing.T) {
+		apiKey := &models.APIKey{Key: "current", PreviousKey: "old", PreviousKeyExpiresAt: &future}
+		_, err := rotationUpdate(apiKey, "new", time.Hour, now)
+		assert.Equal(t, ErrKeyRotationPending, err)
+
+		// An immediate rotation revokes both old secrets
+		update, err := rotationUpdate(apiKey, "new", 0, now)
+		assert.NoError(t, err)
+		assert.Contains(t, update["$unset"], "previous_key")
+	})
+
+	t.Run("ClosedGraceWindow", func(t *testing.T) {
+		apiKey := &models.APIKey{Key: "current", PreviousKey: "old", PreviousKeyExpiresAt: &past}
+		update, err := rotationUpdate(apiKey, "new", time.Hour, now)
+		assert.NoError(t, err)
+		assert.Equal(t, "current", update["$set"].(bson.M)["previous_key"])
+	})
+}
+
+func TestValidateAPIKeyTracing(t *testing.T) {
+	provider := tracing.NewInMemoryProvider()
+	defer provider.Shutdown(context.Background())
+
+	apiKey := &models.APIKey{ID: primitive.NewObjectID(), Key: "secret", Active: true}
+	a := &AuthService{keyCache: newKeyCache(10), pendingLastUsed: make(map[primitive.ObjectID]time.Time)}
+	a.keyCache.fill("secret", apiKey, time.Minute, a.keyCache.currentGeneration())
+
+	ctx, parent := tracing.Start(context.Background(), "request")
+	validated, err := a.ValidateAPIKey(ctx, "secret")
+	require.NoError(t, err)
+	assert.Equal(t, apiKey.ID, validated.ID)
+	parent.End()
+
+	var found bool
+	for _, span := range provider.Spans() {
+		if span.Name != "AuthService.ValidateAPIKey" {
+			continue
+		}
+		found = true
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent.SpanID(), "the span is a child of the request")
+		for _, kv := range span.Attributes {
+			if kv.Key == "api_key_id" {
+				assert.Equal(t, apiKey.ID.Hex(), kv.Value.AsString())
+			}
+		}
+	}
+	assert.True(t, found)
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"solana-balance-api/internal/models"
+)
+
+// Authentication modes
+const (
+	AuthModeAPIKey = "api_key" // Opaque API keys looked up in MongoDB
+	AuthModeJWT    = "jwt"     // JWTs only (API keys are only accepted by /oauth/token)
+	AuthModeHybrid = "hybrid"  // JWTs and opaque API keys
+)
+
+// MultiModeAuthService dispatches credentials to the API key or JWT service
+// depending on the configured mode and the credential format
+type MultiModeAuthService struct {
+	mode    string
+	apiKeys AuthServiceInterface
+	tokens  AuthServiceInterface
+}
+
+// NewMultiModeAuthService creates an authenticator for the given mode
+func NewMultiModeAuthService(mode string, apiKeys, tokens AuthServiceInterface) (*MultiModeAuthService, error) {
+	switch mode {
+	case AuthModeAPIKey:
+		tokens = nil
+	case AuthModeJWT:
+		apiKeys = nil
+	case AuthModeHybrid:
+	default:
+		return nil, fmt.Errorf("unsupported auth mode %q", mode)
+	}
+
+	if mode != AuthModeAPIKey && tokens == nil {
+		return nil, fmt.Errorf("auth mode %q requires a JWT service", mode)
+	}
+
+	return &MultiModeAuthService{
+		mode:    mode,
+		apiKeys: apiKeys,
+		tokens:  tokens,
+	}, nil
+}
+
+// ValidateAPIKey validates a bearer credential: JWTs go to the token service,
+// anything else to the API key service
+func (m *MultiModeAuthService) ValidateAPIKey(ctx context.Context, credential string) (*models.APIKey, error) {
+	if LooksLikeJWT(credential) {
+		if m.tokens == nil {
+			return nil, ErrInvalidAPIKey
+		}
+		return m.tokens.ValidateAPIKey(ctx, credential)
+	}
+
+	if m.apiKeys == nil {
+		return nil, ErrInvalidToken
+	}
+	return m.apiKeys.ValidateAPIKey(ctx, credential)
+}
+
+// Mode returns the configured authentication mode
+func (m *MultiModeAuthService) Mode() string {
+	return m.mode
+}
+
+// LooksLikeJWT reports whether a credential has the three-segment compact JWS shape
+func LooksLikeJWT(credential string) bool {
+	return strings.Count(credential, ".") == 2
+}
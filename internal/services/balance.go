@@ -1,6 +1,8 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -11,7 +13,9 @@ import (
 	"solana-balance-api/pkg/logger"
 	"solana-balance-api/pkg/metrics"
 	"solana-balance-api/pkg/mutex"
+	"solana-balance-api/pkg/tracing"
 
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
@@ -36,93 +40,120 @@ func NewBalanceService(rpcClient SolanaServiceInterface, cfg *config.Config) *Ba
 }
 
 // GetBalances fetches balances for multiple wallet addresses with caching and concurrency control
-func (bs *BalanceService) GetBalances(addresses []string) (*models.BalanceResponse, error) {
+func (bs *BalanceService) GetBalances(ctx context.Context, addresses []string) (*models.BalanceResponse, error) {
 	startTime := time.Now()
-	bs.metrics.RecordRequest()
 
-	log := logger.GetLogger()
+	ctx, span := tracing.Start(ctx, "BalanceService.GetBalances", attribute.Int("wallet_count", len(addresses)))
+	defer span.End()
+
+	log := logger.GetLogger().WithContext(ctx)
 
 	if len(addresses) == 0 {
 		log.Debug("Empty addresses array provided")
-		bs.metrics.RecordRequestComplete(time.Since(startTime), true)
 		return &models.BalanceResponse{
 			Balances: []models.WalletBalance{},
 			Cached:   false,
 		}, nil
 	}
 
+	// Duplicates are fetched once and copied to each of their positions
+	positions := make(map[string][]int, len(addresses))
+	unique := make([]string, 0, len(addresses))
+	for i, address := range addresses {
+		if _, seen := positions[address]; !seen {
+			unique = append(unique, address)
+		}
+		positions[address] = append(positions[address], i)
+	}
+	span.SetAttributes(attribute.Int("unique_wallet_count", len(unique)))
+
 	log.Info("Processing balance request for multiple addresses",
 		zap.Int("address_count", len(addresses)),
+		zap.Int("unique_address_count", len(unique)),
 	)
 
 	balances := make([]models.WalletBalance, len(addresses))
 	allCached := true
-	var mu sync.Mutex // Protect allCached variable
+	rpcFetches := 0
+	failed := 0
+	var mu sync.Mutex // Protect allCached, rpcFetches and failed variables
 
 	// Use a wait group to handle concurrent processing
 	var wg sync.WaitGroup
 
-	for i, address := range addresses {
+	for _, address := range unique {
 		wg.Add(1)
-		go func(index int, addr string) {
+		go func(addr string) {
 			defer wg.Done()
 
-			walletBalance, cached := bs.getBalanceWithCache(addr)
-			balances[index] = *walletBalance
+			walletBalance, cached := bs.getBalanceWithCache(ctx, addr)
+			for _, index := range positions[addr] {
+				balances[index] = *walletBalance
+			}
 
+			mu.Lock()
 			if !cached {
-				mu.Lock()
 				allCached = false
-				mu.Unlock()
+				rpcFetches++
 			}
-		}(i, address)
+			if walletBalance.Status == models.WalletStatusError {
+				failed++
+			}
+			mu.Unlock()
+		}(address)
 	}
 
 	wg.Wait()
 
-	success := true
-	for _, balance := range balances {
-		if balance.Error != "" {
-			success = false
-			break
-		}
-	}
-
-	bs.metrics.RecordRequestComplete(time.Since(startTime), success)
+	span.SetAttributes(
+		attribute.Int("rpc_fetches", rpcFetches),
+		attribute.Bool("all_cached", allCached),
+		attribute.Int("failed_wallets", failed),
+	)
 
 	log.Info("Completed balance request for multiple addresses",
 		zap.Int("address_count", len(addresses)),
 		zap.Bool("all_cached", allCached),
+		zap.Int("rpc_fetches", rpcFetches),
+		zap.Int("failed_wallets", failed),
 		zap.Duration("duration", time.Since(startTime)),
 	)
 
 	return &models.BalanceResponse{
-		Balances: balances,
-		Cached:   allCached,
+		Balances:   balances,
+		Cached:     allCached,
+		Partial:    failed > 0,
+		RPCFetches: rpcFetches,
+		CacheHits:  len(unique) - rpcFetches,
 	}, nil
 }
 
 // GetBalance fetches balance for a single wallet address
-func (bs *BalanceService) GetBalance(address string) (*models.WalletBalance, error) {
-	walletBalance, _ := bs.getBalanceWithCache(address)
+func (bs *BalanceService) GetBalance(ctx context.Context, address string) (*models.WalletBalance, error) {
+	walletBalance, _ := bs.getBalanceWithCache(ctx, address)
 	return walletBalance, nil
 }
 
-// getBalanceWithCache handles the core logic for fetching balance with caching and mutex control
-func (bs *BalanceService) getBalanceWithCache(address string) (*models.WalletBalance, bool) {
-	log := logger.GetLogger().WithFields(map[string]interface{}{
+// getBalanceWithCache handles the core logic for fetching balance with caching and mutex control.
+// The span records whether the cache was hit and how long the per-wallet mutex was awaited.
+func (bs *BalanceService) getBalanceWithCache(ctx context.Context, address string) (*models.WalletBalance, bool) {
+	ctx, span := tracing.Start(ctx, "BalanceService.getBalanceWithCache")
+	defer span.End()
+
+	log := logger.GetLogger().WithContext(ctx).WithFields(map[string]interface{}{
 		"wallet_address": address,
-		"component":      "balance_service",
+		"component":      logger.ComponentBalanceService,
 	})
 
 	// First, check if we have a cached result
-	if cachedBalance, found := bs.cache.Get(address); found {
+	lookupStartTime := time.Now()
+	cached, found := bs.cache.GetEntry(address)
+	bs.metrics.RecordCacheLookup(time.Since(lookupStartTime))
+	span.SetAttributes(attribute.Bool("cache.hit", found))
+	if found {
 		log.Debug("Cache hit for wallet balance")
 		bs.metrics.RecordCacheHit()
-		return &models.WalletBalance{
-			Address: address,
-			Balance: cachedBalance,
-		}, true
+		return bs.cachedWalletBalance(address, cached), true
 	}
 
 	log.Debug("Cache miss, acquiring mutex for wallet")
@@ -134,39 +165,51 @@ func (bs *BalanceService) getBalanceWithCache(address string) (*models.WalletBal
 	addressMutex.Lock()
 	defer addressMutex.Unlock()
 
-	// Record mutex wait time if it took longer than 1ms
-	if time.Since(mutexStartTime) > time.Millisecond {
-		bs.metrics.RecordMutexWait()
-	}
+	// Record mutex wait time (waits longer than 1ms are also counted)
+	mutexWait := time.Since(mutexStartTime)
+	bs.metrics.RecordMutexWaitTime(mutexWait)
+	span.SetAttributes(attribute.Float64("mutex.wait_ms", float64(mutexWait)/float64(time.Millisecond)))
 
 	// Double-check cache after acquiring mutex (another goroutine might have fetched it)
-	if cachedBalance, found := bs.cache.Get(address); found {
+	cached, found = bs.cache.GetEntry(address)
+	span.SetAttributes(attribute.Bool("cache.hit_after_wait", found))
+	if found {
 		log.Debug("Cache hit after mutex acquisition (populated by concurrent request)")
 		bs.metrics.RecordCacheHit()
-		return &models.WalletBalance{
-			Address: address,
-			Balance: cachedBalance,
-		}, true
+		return bs.cachedWalletBalance(address, cached), true
 	}
 
 	log.Debug("Fetching balance from RPC client")
 
 	// Fetch from RPC client
 	rpcStartTime := time.Now()
-	balance, err := bs.rpcClient.GetBalance(address)
+	balance, err := bs.rpcClient.GetBalance(ctx, address)
 	rpcDuration := time.Since(rpcStartTime)
 
-	bs.metrics.RecordRPCCall(rpcDuration, err == nil)
+	// A missing account is a definitive answer, not a failed call
+	notFound := errors.Is(err, ErrAccountNotFound)
+	bs.metrics.RecordRPCMethodCall("getAccountInfo", rpcDuration, err == nil || notFound)
+
+	if notFound {
+		log.Debug("Account does not exist, caching result", zap.Duration("rpc_duration", rpcDuration))
+		bs.cache.SetNotFound(address)
+		return bs.withCacheWindow(notFoundWalletBalance(address), time.Now()), false
+	}
 
 	if err != nil {
+		tracing.RecordError(span, err)
 		log.Error("Failed to fetch balance from RPC client",
 			zap.Error(err),
 			zap.Duration("rpc_duration", rpcDuration),
 		)
+		code := walletErrorCode(err)
 		return &models.WalletBalance{
-			Address: address,
-			Balance: 0,
-			Error:   fmt.Sprintf("Failed to fetch balance: %v", err),
+			Address:   address,
+			Balance:   0,
+			Status:    models.WalletStatusError,
+			ErrorCode: code,
+			Retryable: code.Retryable(),
+			Error:     fmt.Sprintf("Failed to fetch balance: %v", err),
 		}, false
 	}
 
@@ -178,10 +221,52 @@ func (bs *BalanceService) getBalanceWithCache(address string) (*models.WalletBal
 	// Cache the result
 	bs.cache.Set(address, balance)
 
-	return &models.WalletBalance{
+	return bs.withCacheWindow(&models.WalletBalance{
 		Address: address,
 		Balance: balance,
-	}, false
+		Status:  models.WalletStatusOK,
+	}, time.Now()), false
+}
+
+// cachedWalletBalance builds the balance of a wallet served from the cache
+func (bs *BalanceService) cachedWalletBalance(address string, entry cache.CacheEntry) *models.WalletBalance {
+	if entry.NotFound {
+		return bs.withCacheWindow(notFoundWalletBalance(address), entry.Timestamp)
+	}
+	return bs.withCacheWindow(&models.WalletBalance{
+		Address: address,
+		Balance: entry.Balance,
+		Status:  models.WalletStatusOK,
+	}, entry.Timestamp)
+}
+
+// withCacheWindow records when a balance was fetched and until when it is cached
+func (bs *BalanceService) withCacheWindow(balance *models.WalletBalance, fetchedAt time.Time) *models.WalletBalance {
+	balance.FetchedAt = fetchedAt
+	balance.ExpiresAt = fetchedAt.Add(bs.cache.TTL())
+	return balance
+}
+
+// notFoundWalletBalance builds the balance of a wallet without an account
+func notFoundWalletBalance(address string) *models.WalletBalance {
+	return &models.WalletBalance{
+		Address:   address,
+		Balance:   0,
+		Status:    models.WalletStatusNotFound,
+		ErrorCode: models.ErrorCodeAccountNotFound,
+	}
+}
+
+// walletErrorCode classifies an RPC client error
+func walletErrorCode(err error) models.ErrorCode {
+	switch {
+	case errors.Is(err, ErrInvalidWalletAddress):
+		return models.ErrorCodeInvalidWallet
+	case errors.Is(err, context.DeadlineExceeded):
+		return models.ErrorCodeRPCTimeout
+	default:
+		return models.ErrorCodeRPCUnavailable
+	}
 }
 
 // GetCacheStats returns cache statistics for monitoring
@@ -189,7 +274,7 @@ func (bs *BalanceService) GetCacheStats() map[string]interface{} {
 	return map[string]interface{}{
 		"cache_size":   bs.cache.Size(),
 		"mutex_count":  bs.requestMutex.Size(),
-		"cache_ttl_ms": bs.config.Cache.TTL.Milliseconds(),
+		"cache_ttl_ms": bs.cache.TTL().Milliseconds(),
 	}
 }
 
@@ -219,11 +304,17 @@ func (bs *BalanceService) GetPerformanceStats() map[string]interface{} {
 		"average_rpc_time_ms":      metrics.AverageRPCTime.Milliseconds(),
 		"active_requests":          metrics.ActiveRequests,
 		"mutex_waits":              metrics.MutexWaits,
+		"latency_percentiles":      metrics.Latency,
 		"cache_size":               bs.cache.Size(),
 		"mutex_count":              bs.requestMutex.Size(),
 	}
 }
 
+// SetCacheTTL changes how long balances stay cached, e.g. after a configuration reload
+func (bs *BalanceService) SetCacheTTL(ttl time.Duration) {
+	bs.cache.SetTTL(ttl)
+}
+
 // ClearCache clears all cached entries
 func (bs *BalanceService) ClearCache() {
 	bs.cache.Clear()
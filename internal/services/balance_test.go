@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"solana-balance-api/internal/config"
+	"solana-balance-api/internal/models"
+	"solana-balance-api/pkg/metrics"
+	"solana-balance-api/pkg/tracing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// stubSolanaClient returns a fixed balance for every address
+type stubSolanaClient struct{}
+
+func (stubSolanaClient) GetBalance(ctx context.Context, address string) (float64, error) {
+	return 1.5, nil
+}
+
+func (stubSolanaClient) GetBalances(ctx context.Context, addresses []string) (map[string]float64, error) {
+	result := make(map[string]float64, len(addresses))
+	for _, address := range addresses {
+		result[address] = 1.5
+	}
+	return result, nil
+}
+
+// outcomeSolanaClient answers each address with a fixed error, counting calls
+type outcomeSolanaClient struct {
+	errs  map[string]error
+	calls map[string]int
+	mutex sync.Mutex
+}
+
+func (c *outcomeSolanaClient) GetBalance(ctx context.Context, address string) (float64, error) {
+	c.mutex.Lock()
+	c.calls[address]++
+	c.mutex.Unlock()
+	if err := c.errs[address]; err != nil {
+		return 0, err
+	}
+	return 0, nil
+}
+
+func (c *outcomeSolanaClient) GetBalances(ctx context.Context, addresses []string) (map[string]float64, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestGetBalancesWalletStatus(t *testing.T) {
+	client := &outcomeSolanaClient{
+		errs: map[string]error{
+			"missing": ErrAccountNotFound,
+			"down":    errors.New("connection refused"),
+			"slow":    fmt.Errorf("request failed: %w", context.DeadlineExceeded),
+			"bad":     fmt.Errorf("%w: invalid length", ErrInvalidWalletAddress),
+		},
+		calls: make(map[string]int),
+	}
+	cfg := &config.Config{Cache: config.CacheConfig{TTL: time.Minute, CleanupInterval: time.Minute}}
+	service := NewBalanceService(client, cfg)
+	defer service.Stop()
+
+	t.Run("Statuses", func(t *testing.T) {
+		response, err := service.GetBalances(context.Background(), []string{"empty", "missing", "down", "slow", "bad"})
+		require.NoError(t, err)
+		assert.True(t, response.Partial)
+
+		byAddress := make(map[string]models.WalletBalance)
+		for _, balance := range response.Balances {
+			byAddress[balance.Address] = balance
+		}
+
+		assert.Equal(t, models.WalletStatusOK, byAddress["empty"].Status)
+		assert.Empty(t, byAddress["empty"].ErrorCode)
+		assert.WithinDuration(t, byAddress["empty"].FetchedAt.Add(time.Minute), byAddress["empty"].ExpiresAt, 0)
+
+		assert.Equal(t, models.WalletStatusNotFound, byAddress["missing"].Status)
+		assert.Equal(t, models.ErrorCodeAccountNotFound, byAddress["missing"].ErrorCode)
+		assert.False(t, byAddress["missing"].Retryable)
+		assert.Empty(t, byAddress["missing"].Error)
+
+		assert.Equal(t, models.WalletStatusError, byAddress["down"].Status)
+		assert.Equal(t, models.ErrorCodeRPCUnavailable, byAddress["down"].ErrorCode)
+		assert.True(t, byAddress["down"].Retryable)
+		assert.Contains(t, byAddress["down"].Error, "Failed to fetch balance")
+		assert.True(t, byAddress["down"].ExpiresAt.IsZero())
+
+		assert.Equal(t, models.ErrorCodeRPCTimeout, byAddress["slow"].ErrorCode)
+		assert.True(t, byAddress["slow"].Retryable)
+
+		assert.Equal(t, models.ErrorCodeInvalidWallet, byAddress["bad"].ErrorCode)
+		assert.False(t, byAddress["bad"].Retryable)
+	})
+
+	t.Run("DuplicatesFetchedOnce", func(t *testing.T) {
+		response, err := service.GetBalances(context.Background(), []string{"dup", "empty", "dup"})
+		require.NoError(t, err)
+		require.Len(t, response.Balances, 3)
+		assert.Equal(t, []string{"dup", "empty", "dup"}, []string{
+			response.Balances[0].Address, response.Balances[1].Address, response.Balances[2].Address,
+		})
+		assert.Equal(t, 1, client.calls["dup"])
+		assert.Equal(t, 1, response.RPCFetches)
+		assert.Equal(t, 1, response.CacheHits)
+	})
+
+	t.Run("NotFoundIsCached", func(t *testing.T) {
+		response, err := service.GetBalances(context.Background(), []string{"missing", "empty"})
+		require.NoError(t, err)
+		assert.False(t, response.Partial)
+		assert.True(t, response.Cached)
+		assert.Equal(t, models.WalletStatusNotFound, response.Balances[0].Status)
+		assert.Equal(t, 1, client.calls["missing"])
+	})
+}
+
+func TestGetBalancesTracing(t *testing.T) {
+	provider := tracing.NewInMemoryProvider()
+	defer provider.Shutdown(context.Background())
+
+	cfg := &config.Config{Cache: config.CacheConfig{TTL: time.Minute, CleanupInterval: time.Minute}}
+	service := NewBalanceService(stubSolanaClient{}, cfg)
+	defer service.Stop()
+
+	ctx, parent := tracing.Start(context.Background(), "request")
+	_, err := service.GetBalances(ctx, []string{"wallet-a"})
+	require.NoError(t, err)
+	_, err = service.GetBalances(ctx, []string{"wallet-a"})
+	require.NoError(t, err)
+	parent.End()
+
+	var cacheHits []bool
+	for _, span := range provider.Spans() {
+		if span.Name != "BalanceService.getBalanceWithCache" {
+			continue
+		}
+		assert.Equal(t, parent.SpanContext().TraceID(), span.SpanContext.TraceID())
+		for _, kv := range span.Attributes {
+			if kv.Key == attribute.Key("cache.hit") {
+				cacheHits = append(cacheHits, kv.Value.AsBool())
+			}
+		}
+	}
+	assert.Equal(t, []bool{false, true}, cacheHits)
+}
+
+func TestGetBalancesRecordsRPCMethod(t *testing.T) {
+	cfg := &config.Config{Cache: config.CacheConfig{TTL: time.Minute, CleanupInterval: time.Minute}}
+	service := NewBalanceService(stubSolanaClient{}, cfg)
+	defer service.Stop()
+
+	_, err := service.GetBalances(context.Background(), []string{"wallet-a"})
+	require.NoError(t, err)
+
+	var out strings.Builder
+	require.NoError(t, service.GetMetricsCollector().WriteExposition(&out, metrics.FormatPrometheus))
+	assert.Contains(t, out.String(), `solana_api_rpc_duration_seconds_count{method="getAccountInfo"} 1`)
+}
@@ -1,20 +1,48 @@
 package services
 
-import "solana-balance-api/internal/models"
+import (
+	"context"
+	"time"
+
+	"solana-balance-api/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 // AuthServiceInterface defines the interface for authentication services
 type AuthServiceInterface interface {
-	ValidateAPIKey(key string) (*models.APIKey, error)
+	ValidateAPIKey(ctx context.Context, key string) (*models.APIKey, error)
+}
+
+// APIKeyManagerInterface defines the interface for API key lifecycle management
+type APIKeyManagerInterface interface {
+	RotateAPIKey(id primitive.ObjectID, grace *time.Duration) (*models.APIKey, error)
+}
+
+// OrganizationServiceInterface defines the interface for tenant organizations and their usage
+type OrganizationServiceInterface interface {
+	GetOrganization(tenantID string) (*models.Organization, error)
+	GetUsage(tenantID string) (*models.OrganizationUsage, error)
+	RecordUsage(tenantID string, requests, wallets, rpcCalls int)
+	CheckQuota(org *models.Organization) error
+	TierLimit(org *models.Organization) int
+}
+
+// UsageServiceInterface defines the interface for per-key usage metering
+type UsageServiceInterface interface {
+	RecordUsage(keyID primitive.ObjectID, tenantID string, counters models.UsageCounters)
+	Query(query models.UsageQuery) (*models.UsageReport, error)
 }
 
-// SolanaServiceInterface defines the interface for Solana RPC operations
+// SolanaServiceInterface defines the interface for Solana RPC operations.
+// GetBalances omits addresses whose account does not exist.
 type SolanaServiceInterface interface {
-	GetBalance(address string) (float64, error)
-	GetBalances(addresses []string) (map[string]float64, error)
+	GetBalance(ctx context.Context, address string) (float64, error)
+	GetBalances(ctx context.Context, addresses []string) (map[string]float64, error)
 }
 
 // BalanceServiceInterface defines the interface for balance operations
 type BalanceServiceInterface interface {
-	GetBalances(addresses []string) (*models.BalanceResponse, error)
-	GetBalance(address string) (*models.WalletBalance, error)
+	GetBalances(ctx context.Context, addresses []string) (*models.BalanceResponse, error)
+	GetBalance(ctx context.Context, address string) (*models.WalletBalance, error)
 }
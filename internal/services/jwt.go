@@ -0,0 +1,297 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"solana-balance-api/internal/config"
+	"solana-balance-api/internal/models"
+	"solana-balance-api/pkg/jwks"
+	"solana-balance-api/pkg/tracing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token has expired")
+)
+
+// allowedSigningMethods lists the asymmetric algorithms accepted for incoming tokens
+var allowedSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// JWTAuthService validates JWTs from a configured issuer and mints short-lived
+// tokens for API keys through the client-credentials grant
+type JWTAuthService struct {
+	config     *config.AuthConfig
+	keySet     *jwks.KeySet
+	signingKey crypto.Signer
+	signingJWK jwks.JWK
+	method     jwt.SigningMethod
+}
+
+// NewJWTAuthService creates a JWT authentication service. Keys for external tokens are
+// loaded from the JWKS URL or file; locally issued tokens are signed with the key from
+// SigningKeyFile, or an ephemeral Ed25519 key when none is configured.
+func NewJWTAuthService(cfg *config.AuthConfig) (*JWTAuthService, error) {
+	keySet, err := jwks.New(cfg.JWKSURL, cfg.JWKSFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load JWKS: %w", err)
+	}
+
+	signingKey, err := loadSigningKey(cfg.SigningKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	signingJWK, err := jwks.FromPublicKey(signingKey.Public())
+	if err != nil {
+		return nil, fmt.Errorf("failed to build signing JWK: %w", err)
+	}
+
+	method, err := signingMethodFor(signingKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JWTAuthService{
+		config:     cfg,
+		keySet:     keySet,
+		signingKey: signingKey,
+		signingJWK: signingJWK,
+		method:     method,
+	}, nil
+}
+
+// ValidateAPIKey validates a JWT bearer token and maps its claims to an API key identity
+func (j *JWTAuthService) ValidateAPIKey(ctx context.Context, token string) (*models.APIKey, error) {
+	_, span := tracing.Start(ctx, "JWTAuthService.ValidateAPIKey")
+	defer span.End()
+
+	apiKey, err := j.validateToken(token)
+	tracing.RecordError(span, err)
+	return apiKey, err
+}
+
+// validateToken implements ValidateAPIKey
+func (j *JWTAuthService) validateToken(token string) (*models.APIKey, error) {
+	if token == "" {
+		return nil, ErrInvalidToken
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(allowedSigningMethods),
+		jwt.WithLeeway(j.config.ClockSkew),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	}
+	if j.config.Audience != "" {
+		options = append(options, jwt.WithAudience(j.config.Audience))
+	}
+
+	parsed, err := jwt.Parse(token, j.keyFunc, options...)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok || !parsed.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	return j.claimsToAPIKey(claims)
+}
+
+// keyFunc selects the verification key based on the issuer and key ID.
+// Local keys only verify locally issued tokens; JWKS keys only verify the external issuer.
+func (j *JWTAuthService) keyFunc(token *jwt.Token) (interface{}, error) {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	issuer, _ := claims.GetIssuer()
+	kid, _ := token.Header["kid"].(string)
+
+	switch {
+	case issuer == j.config.TokenIssuer && kid == j.signingJWK.Kid:
+		return j.signingKey.Public(), nil
+	case j.config.JWTIssuer != "" && issuer == j.config.JWTIssuer:
+		return j.keySet.Key(kid)
+	default:
+		return nil, fmt.Errorf("untrusted issuer %q", issuer)
+	}
+}
+
+// claimsToAPIKey maps verified claims to the identity used by the rest of the service
+func (j *JWTAuthService) claimsToAPIKey(claims jwt.MapClaims) (*models.APIKey, error) {
+	subject, _ := claims.GetSubject()
+	if subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	}
+
+	apiKey := &models.APIKey{
+		Name:         subject,
+		Active:       true,
+		Scopes:       stringListClaim(claims[j.config.ScopeClaim]),
+		AllowedCIDRs: stringListClaim(claims["allowed_cidrs"]),
+		FromToken:    true,
+	}
+
+	if id, err := primitive.ObjectIDFromHex(subject); err == nil {
+		apiKey.ID = id
+	}
+	if name, ok := claims["name"].(string); ok && name != "" {
+		apiKey.Name = name
+	}
+	if tenantID, ok := claims[j.config.TenantClaim].(string); ok {
+		apiKey.TenantID = tenantID
+	}
+	if issuedAt, err := claims.GetIssuedAt(); err == nil && issuedAt != nil {
+		apiKey.CreatedAt = issuedAt.Time
+	}
+
+	return apiKey, nil
+}
+
+// IssueToken mints a short-lived JWT for a validated API key with the granted scopes
+func (j *JWTAuthService) IssueToken(apiKey *models.APIKey, scopes []string) (string, time.Duration, error) {
+	now := time.Now()
+	ttl := j.config.TokenTTL
+
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", 0, fmt.Errorf("failed to generate token ID: %w", err)
+	}
+
+	claims := jwt.MapClaims{
+		"iss":               j.config.TokenIssuer,
+		"sub":               apiKey.ID.Hex(),
+		"iat":               now.Unix(),
+		"nbf":               now.Unix(),
+		"exp":               now.Add(ttl).Unix(),
+		"jti":               hex.EncodeToString(jti),
+		"name":              apiKey.Name,
+		j.config.ScopeClaim: strings.Join(scopes, " "),
+	}
+	if j.config.Audience != "" {
+		claims["aud"] = j.config.Audience
+	}
+	if apiKey.TenantID != "" {
+		claims[j.config.TenantClaim] = apiKey.TenantID
+	}
+	if len(apiKey.AllowedCIDRs) > 0 {
+		claims["allowed_cidrs"] = apiKey.AllowedCIDRs
+	}
+
+	token := jwt.NewWithClaims(j.method, claims)
+	token.Header["kid"] = j.signingJWK.Kid
+
+	signed, err := token.SignedString(j.signingKey)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return signed, ttl, nil
+}
+
+// PublicJWKS returns the JWK Set for locally issued tokens
+func (j *JWTAuthService) PublicJWKS() jwks.Document {
+	return jwks.Document{Keys: []jwks.JWK{j.signingJWK}}
+}
+
+// KeySet returns the external key set for background refresh
+func (j *JWTAuthService) KeySet() *jwks.KeySet {
+	return j.keySet
+}
+
+// loadSigningKey reads a PEM private key, or generates an ephemeral Ed25519 key
+func loadSigningKey(path string) (crypto.Signer, error) {
+	if path == "" {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate signing key: %w", err)
+		}
+		return key, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("signing key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	return nil, errors.New("unsupported signing key format")
+}
+
+// signingMethodFor returns the JWT algorithm for a private key
+func signingMethodFor(key crypto.Signer) (jwt.SigningMethod, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PrivateKey:
+		switch k.Curve.Params().BitSize {
+		case 256:
+			return jwt.SigningMethodES256, nil
+		case 384:
+			return jwt.SigningMethodES384, nil
+		case 521:
+			return jwt.SigningMethodES512, nil
+		}
+	case ed25519.PrivateKey:
+		return jwt.SigningMethodEdDSA, nil
+	}
+	return nil, fmt.Errorf("unsupported signing key type %T", key)
+}
+
+// stringListClaim reads a claim that is either a space-separated string or a string array
+func stringListClaim(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		result := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				result = append(result, s)
+			}
+		}
+		return result
+	case []string:
+		return v
+	default:
+		return nil
+	}
+}
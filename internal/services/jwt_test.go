@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"solana-balance-api/internal/config"
+	"solana-balance-api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestJWTAuthService(t *testing.T) {
+	cfg := &config.AuthConfig{
+		Audience:    "solana-balance-api",
+		ScopeClaim:  "scope",
+		TenantClaim: "tenant_id",
+		ClockSkew:   time.Second,
+		TokenIssuer: "solana-balance-api",
+		TokenTTL:    time.Minute,
+	}
+
+	service, err := NewJWTAuthService(cfg)
+	require.NoError(t, err)
+
+	apiKey := &models.APIKey{
+		ID:       primitive.NewObjectID(),
+		Name:     "Test Key",
+		Active:   true,
+		TenantID: "team-a",
+	}
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		token, ttl, err := service.IssueToken(apiKey, []string{"balance:read"})
+		require.NoError(t, err)
+		assert.Equal(t, time.Minute, ttl)
+
+		validated, err := service.ValidateAPIKey(context.Background(), token)
+		require.NoError(t, err)
+		assert.Equal(t, apiKey.ID, validated.ID)
+		assert.Equal(t, "Test Key", validated.Name)
+		assert.Equal(t, []string{"balance:read"}, validated.Scopes)
+		assert.Equal(t, "team-a", validated.TenantID)
+	})
+
+	t.Run("ScopelessTokenGrantsNothing", func(t *testing.T) {
+		token, _, err := service.IssueToken(apiKey, nil)
+		require.NoError(t, err)
+
+		validated, err := service.ValidateAPIKey(context.Background(), token)
+		require.NoError(t, err)
+		assert.Empty(t, validated.EffectiveScopes())
+		assert.False(t, validated.HasScope(models.ScopeBalanceRead))
+	})
+
+	t.Run("ExpiredToken", func(t *testing.T) {
+		expiredCfg := *cfg
+		expiredCfg.TokenTTL = -time.Minute
+		expired := *service
+		expired.config = &expiredCfg
+
+		token, _, err := expired.IssueToken(apiKey, nil)
+		require.NoError(t, err)
+
+		_, err = service.ValidateAPIKey(context.Background(), token)
+		assert.ErrorIs(t, err, ErrExpiredToken)
+	})
+
+	t.Run("ForeignSigningKey", func(t *testing.T) {
+		other, err := NewJWTAuthService(cfg)
+		require.NoError(t, err)
+
+		token, _, err := other.IssueToken(apiKey, nil)
+		require.NoError(t, err)
+
+		_, err = service.ValidateAPIKey(context.Background(), token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("MultiModeDispatch", func(t *testing.T) {
+		auth, err := NewMultiModeAuthService(AuthModeJWT, nil, service)
+		require.NoError(t, err)
+
+		_, err = auth.ValidateAPIKey(context.Background(), "opaque-api-key")
+		assert.ErrorIs(t, err, ErrInvalidToken)
+
+		token, _, err := service.IssueToken(apiKey, nil)
+		require.NoError(t, err)
+		_, err = auth.ValidateAPIKey(context.Background(), token)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,344 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"solana-balance-api/internal/config"
+	"solana-balance-api/internal/models"
+	"solana-balance-api/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrQuotaExceeded        = errors.New("organization quota exceeded")
+)
+
+// orgCacheEntry holds a cached organization lookup. A nil org is a negative entry.
+type orgCacheEntry struct {
+	org       *models.Organization
+	expiresAt time.Time
+}
+
+// usageKey identifies usage counters for a tenant in a billing period
+type usageKey struct {
+	tenantID string
+	period   string
+}
+
+// usageTotals holds persisted usage loaded from MongoDB
+type usageTotals struct {
+	usage    models.OrganizationUsage
+	loadedAt time.Time
+}
+
+// OrganizationService manages tenant organizations, their quotas and rolled-up usage.
+// Usage is counted in memory and flushed to MongoDB in batches.
+type OrganizationService struct {
+	collection      *mongo.Collection
+	usageCollection *mongo.Collection
+	config          *config.TenantConfig
+
+	cache      map[string]*orgCacheEntry
+	cacheMutex sync.RWMutex
+
+	persisted  map[usageKey]*usageTotals
+	pending    map[usageKey]*models.OrganizationUsage
+	inFlight   map[usageKey]*models.OrganizationUsage // Taken by a flush whose write has not landed yet
+	usageMutex sync.Mutex
+
+	// writeMutex is held by flushes while writing and shared by usage reloads while
+	// reading, so a reload either includes a flushed write or still counts it in flight
+	writeMutex sync.RWMutex
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewOrganizationService creates a new organization service on an existing database
+func NewOrganizationService(db *mongo.Database, mongoCfg *config.MongoDBConfig, cfg *config.TenantConfig) *OrganizationService {
+	s := &OrganizationService{
+		collection:      db.Collection(mongoCfg.OrganizationCollection),
+		usageCollection: db.Collection(mongoCfg.OrganizationUsageCollection),
+		config:          cfg,
+		cache:           make(map[string]*orgCacheEntry),
+		persisted:       make(map[usageKey]*usageTotals),
+		pending:         make(map[usageKey]*models.OrganizationUsage),
+		inFlight:        make(map[usageKey]*models.OrganizationUsage),
+		stopCh:          make(chan struct{}),
+	}
+
+	// Start background usage flushing
+	s.wg.Add(1)
+	go s.runUsageFlusher()
+
+	return s
+}
+
+// GetOrganization returns the organization for a tenant ID, using a short-lived cache
+func (s *OrganizationService) GetOrganization(tenantID string) (*models.Organization, error) {
+	s.cacheMutex.RLock()
+	entry, exists := s.cache[tenantID]
+	s.cacheMutex.RUnlock()
+
+	if !exists || time.Now().After(entry.expiresAt) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		var org models.Organization
+		err := s.collection.FindOne(ctx, bson.M{"tenant_id": tenantID}).Decode(&org)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return nil, ErrDatabaseError
+		}
+
+		entry = &orgCacheEntry{expiresAt: time.Now().Add(s.config.CacheTTL)}
+		if err == nil {
+			entry.org = &org
+		}
+
+		s.cacheMutex.Lock()
+		s.cache[tenantID] = entry
+		s.cacheMutex.Unlock()
+	}
+
+	if entry.org == nil {
+		return nil, ErrOrganizationNotFound
+	}
+
+	org := *entry.org
+	return &org, nil
+}
+
+// TierLimit returns the rate limit units per window for an organization's rate tier.
+// A nil organization or unknown tier uses the default tier.
+func (s *OrganizationService) TierLimit(org *models.Organization) int {
+	if org != nil {
+		if limit, exists := s.config.RateTiers[org.RateTier]; exists {
+			return limit
+		}
+	}
+	return s.config.RateTiers[s.config.DefaultTier]
+}
+
+// RecordUsage adds usage for a tenant in the current billing period
+func (s *OrganizationService) RecordUsage(tenantID string, requests, wallets, rpcCalls int) {
+	if tenantID == "" {
+		return
+	}
+
+	key := usageKey{tenantID: tenantID, period: models.UsagePeriod(time.Now())}
+
+	s.usageMutex.Lock()
+	defer s.usageMutex.Unlock()
+
+	pending, exists := s.pending[key]
+	if !exists {
+		pending = &models.OrganizationUsage{TenantID: key.tenantID, Period: key.period}
+		s.pending[key] = pending
+	}
+	pending.Requests += int64(requests)
+	pending.Wallets += int64(wallets)
+	pending.RPCCalls += int64(rpcCalls)
+}
+
+// GetUsage returns the tenant's usage in the current billing period, including
+// usage not yet flushed to MongoDB or whose flush is in flight
+func (s *OrganizationService) GetUsage(tenantID string) (*models.OrganizationUsage, error) {
+	key := usageKey{tenantID: tenantID, period: models.UsagePeriod(time.Now())}
+
+	s.usageMutex.Lock()
+	totals, exists := s.persisted[key]
+	s.usageMutex.Unlock()
+
+	// Reload periodically so usage from other instances is picked up
+	if !exists || time.Since(totals.loadedAt) > s.config.CacheTTL {
+		loaded, err := s.loadUsage(key)
+		if err != nil {
+			return nil, err
+		}
+		totals = loaded
+	}
+
+	s.usageMutex.Lock()
+	defer s.usageMutex.Unlock()
+
+	usage := totals.usage
+	for _, unflushed := range []map[usageKey]*models.OrganizationUsage{s.pending, s.inFlight} {
+		if counters, exists := unflushed[key]; exists {
+			usage.Requests += counters.Requests
+			usage.Wallets += counters.Wallets
+			usage.RPCCalls += counters.RPCCalls
+		}
+	}
+	return &usage, nil
+}
+
+// loadUsage reloads a tenant's persisted usage, so usage from other instances is
+// picked up. It does not overlap a flush write.
+func (s *OrganizationService) loadUsage(key usageKey) (*usageTotals, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s.writeMutex.RLock()
+	defer s.writeMutex.RUnlock()
+
+	loaded := &usageTotals{
+		usage:    models.OrganizationUsage{TenantID: key.tenantID, Period: key.period},
+		loadedAt: time.Now(),
+	}
+	err := s.usageCollection.FindOne(ctx, bson.M{"tenant_id": key.tenantID, "period": key.period}).Decode(&loaded.usage)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, ErrDatabaseError
+	}
+
+	s.usageMutex.Lock()
+	s.persisted[key] = loaded
+	s.usageMutex.Unlock()
+	return loaded, nil
+}
+
+// CheckQuota returns ErrQuotaExceeded when the organization has used up a monthly quota
+func (s *OrganizationService) CheckQuota(org *models.Organization) error {
+	if org.Quotas.MonthlyRequests == 0 && org.Quotas.MonthlyWallets == 0 {
+		return nil
+	}
+
+	usage, err := s.GetUsage(org.TenantID)
+	if err != nil {
+		return err
+	}
+
+	if org.Quotas.MonthlyRequests > 0 && usage.Requests >= org.Quotas.MonthlyRequests {
+		return ErrQuotaExceeded
+	}
+	if org.Quotas.MonthlyWallets > 0 && usage.Wallets >= org.Quotas.MonthlyWallets {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// flushUsage writes pending usage to MongoDB with upserted $inc updates
+func (s *OrganizationService) flushUsage() error {
+	s.usageMutex.Lock()
+	pending := s.pending
+	s.pending = make(map[usageKey]*models.OrganizationUsage)
+	s.inFlight = pending
+	s.usageMutex.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	keys := make([]usageKey, 0, len(pending))
+	writes := make([]mongo.WriteModel, 0, len(pending))
+	for key, usage := range pending {
+		keys = append(keys, key)
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"tenant_id": key.tenantID, "period": key.period}).
+			SetUpdate(bson.M{
+				"$inc": bson.M{
+					"requests":  usage.Requests,
+					"wallets":   usage.Wallets,
+					"rpc_calls": usage.RPCCalls,
+				},
+				"$set": bson.M{"updated_at": now},
+			}).
+			SetUpsert(true))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	s.writeMutex.Lock()
+	defer s.writeMutex.Unlock()
+
+	writeStarted := time.Now()
+	_, err := s.usageCollection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+
+	s.usageMutex.Lock()
+	defer s.usageMutex.Unlock()
+
+	s.completeFlushLocked(pending, keys, failedWrites(err, len(writes)), writeStarted)
+	return err
+}
+
+// completeFlushLocked ends a flush of pending, whose write of keys started at
+// writeStarted. Usage whose write failed is kept for the next flush; written usage
+// is counted locally until the next reload, unless the totals were reloaded after
+// the write started and already include it. Caller must hold usageMutex.
+func (s *OrganizationService) completeFlushLocked(pending map[usageKey]*models.OrganizationUsage, keys []usageKey, failed []int, writeStarted time.Time) {
+	s.inFlight = make(map[usageKey]*models.OrganizationUsage)
+
+	failedKeys := make(map[usageKey]bool, len(failed))
+	for _, i := range failed {
+		failedKeys[keys[i]] = true
+		s.mergePendingLocked(keys[i], pending[keys[i]])
+	}
+
+	for key, usage := range pending {
+		if failedKeys[key] {
+			continue
+		}
+
+		if totals, exists := s.persisted[key]; exists && totals.loadedAt.Before(writeStarted) {
+			totals.usage.Requests += usage.Requests
+			totals.usage.Wallets += usage.Wallets
+			totals.usage.RPCCalls += usage.RPCCalls
+		}
+	}
+}
+
+// mergePendingLocked adds usage back to the pending counters. Caller must hold usageMutex.
+func (s *OrganizationService) mergePendingLocked(key usageKey, usage *models.OrganizationUsage) {
+	existing, exists := s.pending[key]
+	if !exists {
+		s.pending[key] = usage
+		return
+	}
+	existing.Requests += usage.Requests
+	existing.Wallets += usage.Wallets
+	existing.RPCCalls += usage.RPCCalls
+}
+
+// runUsageFlusher periodically flushes usage counters
+func (s *OrganizationService) runUsageFlusher() {
+	defer s.wg.Done()
+
+	interval := s.config.UsageFlushInterval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.flushUsage(); err != nil {
+				logger.GetLogger().Warn("Failed to flush organization usage", zap.Error(err))
+			}
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// Close stops the usage flusher and writes any pending usage
+func (s *OrganizationService) Close() error {
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.wg.Wait()
+
+	return s.flushUsage()
+}
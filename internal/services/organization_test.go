@@ -0,0 +1,129 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"solana-balance-api/internal/config"
+	"solana-balance-api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestOrganizationService returns a service without MongoDB whose caches hold
+// the given organizations and persisted usage for the current period
+func newTestOrganizationService(orgs []*models.Organization, persisted map[string]models.OrganizationUsage) *OrganizationService {
+	s := &OrganizationService{
+		config: &config.TenantConfig{
+			CacheTTL:    time.Minute,
+			RateTiers:   map[string]int{models.RateTierFree: 10, models.RateTierStandard: 100},
+			DefaultTier: models.RateTierFree,
+		},
+		cache:     make(map[string]*orgCacheEntry),
+		persisted: make(map[usageKey]*usageTotals),
+		pending:   make(map[usageKey]*models.OrganizationUsage),
+		inFlight:  make(map[usageKey]*models.OrganizationUsage),
+	}
+
+	for _, org := range orgs {
+		s.cache[org.TenantID] = &orgCacheEntry{org: org, expiresAt: time.Now().Add(time.Minute)}
+	}
+	period := models.UsagePeriod(time.Now())
+	for tenantID, usage := range persisted {
+		s.persisted[usageKey{tenantID: tenantID, period: period}] = &usageTotals{usage: usage, loadedAt: time.Now()}
+	}
+	return s
+}
+
+func TestOrganizationService(t *testing.T) {
+	org := &models.Organization{
+		TenantID: "team-a",
+		Active:   true,
+		RateTier: models.RateTierStandard,
+		Quotas:   models.OrganizationQuotas{MonthlyRequests: 10, MonthlyWallets: 100},
+	}
+	s := newTestOrganizationService([]*models.Organization{org}, map[string]models.OrganizationUsage{
+		"team-a": {TenantID: "team-a", Requests: 8, Wallets: 50},
+	})
+
+	t.Run("GetOrganization", func(t *testing.T) {
+		loaded, err := s.GetOrganization("team-a")
+		require.NoError(t, err)
+		assert.Equal(t, models.RateTierStandard, loaded.RateTier)
+
+		s.cache["unknown"] = &orgCacheEntry{expiresAt: time.Now().Add(time.Minute)}
+		_, err = s.GetOrganization("unknown")
+		assert.Equal(t, ErrOrganizationNotFound, err)
+	})
+
+	t.Run("TierLimit", func(t *testing.T) {
+		assert.Equal(t, 100, s.TierLimit(org))
+		assert.Equal(t, 10, s.TierLimit(nil))
+		assert.Equal(t, 10, s.TierLimit(&models.Organization{RateTier: "unknown"}))
+	})
+
+	t.Run("QuotaCountsPendingUsage", func(t *testing.T) {
+		assert.NoError(t, s.CheckQuota(org))
+
+		s.RecordUsage("team-a", 2, 4, 1)
+		usage, err := s.GetUsage("team-a")
+		require.NoError(t, err)
+		assert.EqualValues(t, 10, usage.Requests)
+		assert.EqualValues(t, 54, usage.Wallets)
+		assert.Equal(t, ErrQuotaExceeded, s.CheckQuota(org))
+	})
+
+	t.Run("WalletQuota", func(t *testing.T) {
+		walletsOnly := &models.Organization{TenantID: "team-a", Quotas: models.OrganizationQuotas{MonthlyWallets: 54}}
+		assert.Equal(t, ErrQuotaExceeded, s.CheckQuota(walletsOnly))
+	})
+
+	t.Run("NoQuotas", func(t *testing.T) {
+		assert.NoError(t, s.CheckQuota(&models.Organization{TenantID: "team-a"}))
+	})
+}
+
+func TestOrganizationUsageFlush(t *testing.T) {
+	period := models.UsagePeriod(time.Now())
+	keyA := usageKey{tenantID: "team-a", period: period}
+	keyB := usageKey{tenantID: "team-b", period: period}
+	keyC := usageKey{tenantID: "team-c", period: period}
+
+	s := newTestOrganizationService(nil, map[string]models.OrganizationUsage{
+		"team-a": {TenantID: "team-a", Requests: 5},
+		"team-b": {TenantID: "team-b", Requests: 5},
+		"team-c": {TenantID: "team-c", Requests: 5},
+	})
+	s.RecordUsage("team-a", 1, 0, 0)
+	s.RecordUsage("team-b", 2, 0, 0)
+	s.RecordUsage("team-c", 3, 0, 0)
+
+	// A flush takes the pending usage; it still counts while the write is in flight
+	pending := s.pending
+	s.pending = make(map[usageKey]*models.OrganizationUsage)
+	s.inFlight = pending
+	usage, err := s.GetUsage("team-a")
+	require.NoError(t, err)
+	assert.EqualValues(t, 6, usage.Requests)
+
+	// team-b's totals are reloaded after the write started and already include it;
+	// team-c's write fails
+	writeStarted := time.Now()
+	s.persisted[keyA].loadedAt = writeStarted.Add(-time.Second)
+	s.persisted[keyB] = &usageTotals{usage: models.OrganizationUsage{TenantID: "team-b", Requests: 7}, loadedAt: writeStarted.Add(time.Second)}
+	s.persisted[keyC].loadedAt = writeStarted.Add(-time.Second)
+
+	keys := []usageKey{keyA, keyB, keyC}
+	s.usageMutex.Lock()
+	s.completeFlushLocked(pending, keys, []int{2}, writeStarted)
+	s.usageMutex.Unlock()
+
+	assert.Empty(t, s.inFlight)
+	for tenantID, expected := range map[string]int64{"team-a": 6, "team-b": 7, "team-c": 8} {
+		usage, err := s.GetUsage(tenantID)
+		require.NoError(t, err)
+		assert.Equal(t, expected, usage.Requests, tenantID)
+	}
+	assert.EqualValues(t, 3, s.pending[keyC].Requests)
+}
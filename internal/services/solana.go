@@ -2,25 +2,51 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	"solana-balance-api/internal/config"
+	"solana-balance-api/pkg/logger"
+	"solana-balance-api/pkg/secrets"
+	"solana-balance-api/pkg/tracing"
 
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
 )
 
+// Errors returned by SolanaClient.GetBalance
+var (
+	ErrInvalidWalletAddress = errors.New("invalid wallet address")
+	// ErrAccountNotFound means the address is valid but no account exists, unlike an
+	// existing account holding zero lamports
+	ErrAccountNotFound = errors.New("account not found")
+)
+
+// zeroLength requests no account data; only the lamports are needed
+var zeroLength uint64
+
 // SolanaClient wraps the Solana RPC client with configuration
 type SolanaClient struct {
-	client *rpc.Client
-	config *config.RPCConfig
+	client   *rpc.Client
+	endpoint string
+	url      string       // Endpoint with the API key applied; never logged
+	mutex    sync.RWMutex // Guards client, endpoint and url, which change on configuration reload
+	config   *config.RPCConfig
 }
 
 // NewSolanaClient creates a new Solana RPC client with optimized configuration
 func NewSolanaClient(cfg *config.RPCConfig) *SolanaClient {
-	// Create RPC client with the endpoint
-	client := rpc.New(cfg.Endpoint)
+	rpcURL := withAPIKey(cfg.Endpoint, cfg.APIKey)
+	client := newRPCClient(rpcURL)
 
 	// Note: The gagliardetto/solana-go library doesn't directly expose HTTP client configuration.
 	// For production use with custom HTTP transport optimizations, consider implementing
@@ -32,40 +58,163 @@ func NewSolanaClient(cfg *config.RPCConfig) *SolanaClient {
 	// - HTTP/2 support (ForceAttemptHTTP2)
 
 	return &SolanaClient{
-		client: client,
-		config: cfg,
+		client:   client,
+		endpoint: cfg.Endpoint,
+		url:      rpcURL,
+		config:   cfg,
+	}
+}
+
+// withAPIKey adds the configured API key to the endpoint as the api-key query
+// parameter, unless the endpoint already carries one
+func withAPIKey(endpoint, apiKey string) string {
+	if apiKey == "" {
+		return endpoint
+	}
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return endpoint
+	}
+	query := parsed.Query()
+	if query.Get("api-key") != "" {
+		return endpoint
+	}
+	query.Set("api-key", apiKey)
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
+
+// newRPCClient creates an RPC client for the endpoint. The HTTP transport propagates
+// the trace context and correlation ID of each call to the RPC provider.
+func newRPCClient(endpoint string) *rpc.Client {
+	httpClient := &http.Client{Transport: tracing.NewTransport(nil)}
+	return rpc.NewWithCustomRPCClient(jsonrpc.NewClientWithOpts(endpoint, &jsonrpc.RPCClientOpts{
+		HTTPClient: httpClient,
+	}))
+}
+
+// SetEndpoint switches subsequent calls to a new RPC endpoint. Calls already in
+// flight finish against the previous endpoint.
+func (s *SolanaClient) SetEndpoint(endpoint string) {
+	rpcURL := withAPIKey(endpoint, s.config.APIKey)
+	client := newRPCClient(rpcURL)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.client = client
+	s.endpoint = endpoint
+	s.url = rpcURL
+}
+
+// Endpoint returns the RPC endpoint currently in use. It may contain credentials;
+// use secrets.RedactURL before logging or exposing it.
+func (s *SolanaClient) Endpoint() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.endpoint
+}
+
+// rpcClient returns the RPC client for the current endpoint
+func (s *SolanaClient) rpcClient() *rpc.Client {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.client
+}
+
+// redactedError is an RPC error whose message had endpoint credentials removed.
+// The original error is kept for errors.Is and errors.As.
+type redactedError struct {
+	message string
+	err     error
+}
+
+func (e *redactedError) Error() string { return e.message }
+func (e *redactedError) Unwrap() error { return e.err }
+
+// redactError removes the endpoint's credentials from an RPC error. Transport
+// errors embed the request URL, which may carry an API key.
+func (s *SolanaClient) redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	s.mutex.RLock()
+	rpcURL := s.url
+	s.mutex.RUnlock()
+
+	message := err.Error()
+	if rpcURL == "" || !strings.Contains(message, rpcURL) {
+		return err
+	}
+	return &redactedError{
+		message: strings.ReplaceAll(message, rpcURL, secrets.RedactURL(rpcURL)),
+		err:     err,
 	}
 }
 
-// GetBalance fetches the balance for a single Solana wallet address with retry logic
-func (s *SolanaClient) GetBalance(address string) (float64, error) {
+// GetBalance fetches the balance for a single Solana wallet address with retry logic.
+// Each attempt is traced as its own span. Accounts that do not exist return
+// ErrAccountNotFound rather than a zero balance.
+func (s *SolanaClient) GetBalance(ctx context.Context, address string) (float64, error) {
 	// Parse the wallet address
 	pubKey, err := solana.PublicKeyFromBase58(address)
 	if err != nil {
-		return 0, fmt.Errorf("invalid wallet address: %w", err)
+		return 0, fmt.Errorf("%w: %v", ErrInvalidWalletAddress, err)
+	}
+
+	// getAccountInfo, unlike getBalance, tells missing accounts apart from empty ones
+	opts := &rpc.GetAccountInfoOpts{
+		Encoding:   solana.EncodingBase64,
+		Commitment: rpc.CommitmentFinalized,
+		DataSlice:  &rpc.DataSlice{Offset: &zeroLength, Length: &zeroLength},
 	}
 
+	log := s.rpcLogger(ctx)
+
 	// Retry logic
 	var lastErr error
 	for attempt := 0; attempt <= s.config.MaxRetries; attempt++ {
 		// Create context with timeout for each attempt
-		ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout)
+		attemptCtx, span := s.startSpan(ctx, "getAccountInfo", attempt)
+		attemptCtx, cancel := context.WithTimeout(attemptCtx, s.config.Timeout)
 
 		// Get balance from RPC
-		balance, err := s.client.GetBalance(ctx, pubKey, rpc.CommitmentFinalized)
+		account, err := s.rpcClient().GetAccountInfoWithOpts(attemptCtx, pubKey, opts)
 		cancel()
+		if errors.Is(err, rpc.ErrNotFound) {
+			span.SetAttributes(attribute.Bool("rpc.account_found", false))
+			span.End()
+			return 0, ErrAccountNotFound
+		}
+		err = s.redactError(err)
+		tracing.RecordError(span, err)
+		span.End()
 
 		if err == nil {
 			// Success - convert lamports to SOL (1 SOL = 1,000,000,000 lamports)
-			solBalance := float64(balance.Value) / 1e9
+			solBalance := float64(account.Value.Lamports) / 1e9
 			return solBalance, nil
 		}
 
 		lastErr = err
-
-		// Don't retry on the last attempt
+		log.Debug("RPC attempt failed",
+			zap.String("rpc_method", "getAccountInfo"),
+			zap.Int("attempt", attempt+1),
+			zap.Error(err),
+		)
+
+		// Don't retry on the last attempt, or once the caller has gone away
+		if ctx.Err() != nil {
+			break
+		}
 		if attempt < s.config.MaxRetries {
-			time.Sleep(s.config.RetryDelay * time.Duration(attempt+1)) // Exponential backoff
+			select {
+			case <-time.After(s.config.RetryDelay * time.Duration(attempt+1)): // Exponential backoff
+			case <-ctx.Done():
+			}
 		}
 	}
 
@@ -73,15 +222,17 @@ func (s *SolanaClient) GetBalance(address string) (float64, error) {
 }
 
 // GetBalances fetches balances for multiple wallet addresses
-// For better performance with large batches, consider using GetBalancesBatch
-func (s *SolanaClient) GetBalances(addresses []string) (map[string]float64, error) {
+// For better performance with large batches, consider using GetBalancesBatch.
+// Addresses with no account are omitted from the result, so a missing key means
+// the account was not found while a zero value is a real zero balance.
+func (s *SolanaClient) GetBalances(ctx context.Context, addresses []string) (map[string]float64, error) {
 	if len(addresses) == 0 {
 		return make(map[string]float64), nil
 	}
 
 	// For small batches, use the batch method
 	if len(addresses) <= 100 {
-		return s.getBalancesBatch(addresses)
+		return s.getBalancesBatch(ctx, addresses)
 	}
 
 	// For larger batches, process in chunks to avoid RPC limits
@@ -95,7 +246,7 @@ func (s *SolanaClient) GetBalances(addresses []string) (map[string]float64, erro
 		}
 
 		chunk := addresses[i:end]
-		chunkBalances, err := s.getBalancesBatch(chunk)
+		chunkBalances, err := s.getBalancesBatch(ctx, chunk)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get balances for chunk starting at %d: %w", i, err)
 		}
@@ -110,41 +261,77 @@ func (s *SolanaClient) GetBalances(addresses []string) (map[string]float64, erro
 }
 
 // getBalancesBatch handles batch requests for up to 100 addresses
-func (s *SolanaClient) getBalancesBatch(addresses []string) (map[string]float64, error) {
+func (s *SolanaClient) getBalancesBatch(ctx context.Context, addresses []string) (map[string]float64, error) {
 	// Parse all addresses first to validate them
 	pubKeys := make([]solana.PublicKey, len(addresses))
 	for i, address := range addresses {
 		pubKey, err := solana.PublicKeyFromBase58(address)
 		if err != nil {
-			return nil, fmt.Errorf("invalid wallet address %s: %w", address, err)
+			return nil, fmt.Errorf("%w %s: %v", ErrInvalidWalletAddress, address, err)
 		}
 		pubKeys[i] = pubKey
 	}
 
 	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout)
+	ctx, span := s.startSpan(ctx, "getMultipleAccounts", 0)
+	defer span.End()
+	span.SetAttributes(attribute.Int("rpc.account_count", len(pubKeys)))
+
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
 	defer cancel()
 
 	// Get multiple balances using batch request
-	balances, err := s.client.GetMultipleAccounts(ctx, pubKeys...)
-	if err != nil {
+	log := s.rpcLogger(ctx)
+	startTime := time.Now()
+	balances, err := s.rpcClient().GetMultipleAccounts(ctx, pubKeys...)
+	if err = s.redactError(err); err != nil {
+		tracing.RecordError(span, err)
+		log.Debug("RPC request failed",
+			zap.String("rpc_method", "getMultipleAccounts"),
+			zap.Int("account_count", len(pubKeys)),
+			zap.Duration("duration", time.Since(startTime)),
+			zap.Error(err),
+		)
 		return nil, fmt.Errorf("failed to get balances from RPC: %w", err)
 	}
+	log.Debug("RPC request completed",
+		zap.String("rpc_method", "getMultipleAccounts"),
+		zap.Int("account_count", len(pubKeys)),
+		zap.Duration("duration", time.Since(startTime)),
+	)
+
+	return accountBalances(addresses, balances.Value), nil
+}
 
-	// Process results
+// accountBalances converts getMultipleAccounts results to SOL balances keyed by
+// address. Accounts that don't exist are left out rather than reported as a zero
+// balance.
+func accountBalances(addresses []string, accounts []*rpc.Account) map[string]float64 {
 	result := make(map[string]float64, len(addresses))
 	for i, address := range addresses {
-		if i < len(balances.Value) && balances.Value[i] != nil {
+		if i < len(accounts) && accounts[i] != nil {
 			// Convert lamports to SOL
-			solBalance := float64(balances.Value[i].Lamports) / 1e9
-			result[address] = solBalance
-		} else {
-			// Account doesn't exist or has no balance
-			result[address] = 0.0
+			result[address] = float64(accounts[i].Lamports) / 1e9
 		}
 	}
+	return result
+}
 
-	return result, nil
+// rpcLogger returns a logger for the rpc component, so its level can be overridden
+func (s *SolanaClient) rpcLogger(ctx context.Context) *logger.Logger {
+	return logger.GetLogger().WithContext(ctx).WithFields(map[string]interface{}{"component": logger.ComponentRPC})
+}
+
+// startSpan starts a client span for one RPC attempt
+func (s *SolanaClient) startSpan(ctx context.Context, method string, attempt int) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "SolanaClient."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "solana"),
+			attribute.String("rpc.method", method),
+			attribute.Int("rpc.attempt", attempt+1),
+		),
+	)
 }
 
 // GetBalanceWithCommitment fetches balance with specific commitment level
@@ -152,7 +339,7 @@ func (s *SolanaClient) GetBalanceWithCommitment(address string, commitment rpc.C
 	// Parse the wallet address
 	pubKey, err := solana.PublicKeyFromBase58(address)
 	if err != nil {
-		return 0, fmt.Errorf("invalid wallet address: %w", err)
+		return 0, fmt.Errorf("%w: %v", ErrInvalidWalletAddress, err)
 	}
 
 	// Create context with timeout
@@ -160,8 +347,8 @@ func (s *SolanaClient) GetBalanceWithCommitment(address string, commitment rpc.C
 	defer cancel()
 
 	// Get balance from RPC with specific commitment
-	balance, err := s.client.GetBalance(ctx, pubKey, commitment)
-	if err != nil {
+	balance, err := s.rpcClient().GetBalance(ctx, pubKey, commitment)
+	if err = s.redactError(err); err != nil {
 		return 0, fmt.Errorf("failed to get balance from RPC: %w", err)
 	}
 
@@ -177,8 +364,8 @@ func (s *SolanaClient) IsHealthy() error {
 	defer cancel()
 
 	// Try to get the latest blockhash as a health check
-	_, err := s.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-	if err != nil {
+	_, err := s.rpcClient().GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+	if err = s.redactError(err); err != nil {
 		return fmt.Errorf("RPC health check failed: %w", err)
 	}
 
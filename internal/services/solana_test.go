@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"solana-balance-api/internal/config"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSolanaClientCredentials(t *testing.T) {
+	client := NewSolanaClient(&config.RPCConfig{
+		Endpoint: "https://rpc.example.com/",
+		APIKey:   "secret-key",
+	})
+
+	t.Run("APIKeyApplied", func(t *testing.T) {
+		assert.Equal(t, "https://rpc.example.com/?api-key=secret-key", client.url)
+		assert.Equal(t, "https://rpc.example.com/", client.Endpoint())
+	})
+
+	t.Run("ErrorsRedacted", func(t *testing.T) {
+		cause := fmt.Errorf("Post %q: %w", client.url, context.DeadlineExceeded)
+
+		err := client.redactError(cause)
+		assert.NotContains(t, err.Error(), "secret-key")
+		assert.Contains(t, err.Error(), "api-key=REDACTED")
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+
+	t.Run("EndpointSwitch", func(t *testing.T) {
+		client.SetEndpoint("https://other.example.com/?api-key=inline")
+		assert.Equal(t, "https://other.example.com/?api-key=inline", client.url, "an inline key is kept")
+	})
+}
+
+func TestAccountBalancesOmitsMissingAccounts(t *testing.T) {
+	accounts := []*rpc.Account{{Lamports: 1500000000}, {Lamports: 0}, nil}
+
+	balances := accountBalances([]string{"funded", "empty", "missing", "truncated"}, accounts)
+	assert.Equal(t, map[string]float64{"funded": 1.5, "empty": 0}, balances)
+
+	_, found := balances["missing"]
+	assert.False(t, found, "a missing account is not reported as a zero balance")
+}
@@ -0,0 +1,338 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"solana-balance-api/internal/config"
+	"solana-balance-api/internal/models"
+	"solana-balance-api/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidUsageRange       = errors.New("invalid usage range")
+	ErrInvalidUsageGranularity = errors.New("invalid usage granularity")
+)
+
+// bucketKey identifies a key's usage counters for one hour
+type bucketKey struct {
+	keyID  primitive.ObjectID
+	bucket time.Time
+}
+
+// pendingBucket holds usage counted in memory and not yet flushed
+type pendingBucket struct {
+	tenantID string
+	counters models.UsageCounters
+}
+
+// recordKey groups buckets into report rows
+type recordKey struct {
+	period time.Time
+	keyID  primitive.ObjectID
+}
+
+// UsageService meters per-key usage into hourly buckets. Counters are kept in
+// memory on the hot path and flushed to MongoDB in batches.
+type UsageService struct {
+	collection *mongo.Collection
+	config     *config.UsageConfig
+
+	pending  map[bucketKey]*pendingBucket
+	inFlight map[bucketKey]*pendingBucket // Taken by a flush whose write has not landed yet
+	mutex    sync.Mutex
+
+	// writeMutex is held by flushes while writing and shared by queries while
+	// reading, so a query sees every bucket exactly once
+	writeMutex sync.RWMutex
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewUsageService creates a new usage service on an existing database
+func NewUsageService(db *mongo.Database, mongoCfg *config.MongoDBConfig, cfg *config.UsageConfig) *UsageService {
+	s := &UsageService{
+		collection: db.Collection(mongoCfg.UsageCollection),
+		config:     cfg,
+		pending:    make(map[bucketKey]*pendingBucket),
+		inFlight:   make(map[bucketKey]*pendingBucket),
+		stopCh:     make(chan struct{}),
+	}
+
+	// Start background flushing
+	s.wg.Add(1)
+	go s.runFlusher()
+
+	return s
+}
+
+// RecordUsage adds usage for an API key to the current hourly bucket
+func (s *UsageService) RecordUsage(keyID primitive.ObjectID, tenantID string, counters models.UsageCounters) {
+	if keyID.IsZero() {
+		return
+	}
+
+	key := bucketKey{keyID: keyID, bucket: time.Now().UTC().Truncate(time.Hour)}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.mergePendingLocked(key, &pendingBucket{tenantID: tenantID, counters: counters})
+}
+
+// Query returns usage aggregated by granularity, including usage not yet flushed
+func (s *UsageService) Query(query models.UsageQuery) (*models.UsageReport, error) {
+	switch query.Granularity {
+	case models.GranularityHour, models.GranularityDay, models.GranularityMonth:
+	default:
+		return nil, ErrInvalidUsageGranularity
+	}
+	if !query.To.After(query.From) {
+		return nil, ErrInvalidUsageRange
+	}
+	if s.config.MaxQueryRange > 0 && query.To.Sub(query.From) > s.config.MaxQueryRange {
+		return nil, ErrInvalidUsageRange
+	}
+
+	from := query.From.UTC().Truncate(time.Hour)
+	to := query.To.UTC()
+
+	filter := bson.M{"bucket": bson.M{"$gte": from, "$lt": to}}
+	if !query.KeyID.IsZero() {
+		filter["key_id"] = query.KeyID
+	}
+	if query.TenantID != "" {
+		filter["tenant_id"] = query.TenantID
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	s.writeMutex.RLock()
+	defer s.writeMutex.RUnlock()
+
+	cursor, err := s.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "bucket", Value: 1}}))
+	if err != nil {
+		return nil, ErrDatabaseError
+	}
+	var buckets []models.UsageBucket
+	if err := cursor.All(ctx, &buckets); err != nil {
+		return nil, ErrDatabaseError
+	}
+
+	// Include buckets that have not been flushed yet
+	buckets = append(buckets, s.unflushedBuckets(query, from, to)...)
+
+	report := &models.UsageReport{
+		From:        query.From.UTC(),
+		To:          to,
+		Granularity: query.Granularity,
+		Records:     aggregateBuckets(buckets, query.Granularity, query.PerKey),
+	}
+	if !query.KeyID.IsZero() {
+		report.KeyID = query.KeyID.Hex()
+	}
+	for _, record := range report.Records {
+		report.Totals.Add(record.UsageCounters)
+	}
+
+	return report, nil
+}
+
+// unflushedBuckets returns the pending and in-flight buckets matching the query
+func (s *UsageService) unflushedBuckets(query models.UsageQuery, from, to time.Time) []models.UsageBucket {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var buckets []models.UsageBucket
+	for _, unflushed := range []map[bucketKey]*pendingBucket{s.pending, s.inFlight} {
+		for key, pending := range unflushed {
+			if key.bucket.Before(from) || !key.bucket.Before(to) {
+				continue
+			}
+			if !query.KeyID.IsZero() && key.keyID != query.KeyID {
+				continue
+			}
+			if query.TenantID != "" && pending.tenantID != query.TenantID {
+				continue
+			}
+			buckets = append(buckets, models.UsageBucket{
+				KeyID:         key.keyID,
+				TenantID:      pending.tenantID,
+				Bucket:        key.bucket,
+				UsageCounters: pending.counters,
+			})
+		}
+	}
+	return buckets
+}
+
+// aggregateBuckets rolls hourly buckets up into report rows ordered by period
+func aggregateBuckets(buckets []models.UsageBucket, granularity string, perKey bool) []models.UsageRecord {
+	index := make(map[recordKey]int)
+	records := make([]models.UsageRecord, 0)
+
+	for _, bucket := range buckets {
+		key := recordKey{period: models.TruncateToGranularity(bucket.Bucket, granularity)}
+		if perKey {
+			key.keyID = bucket.KeyID
+		}
+
+		i, exists := index[key]
+		if !exists {
+			record := models.UsageRecord{PeriodStart: key.period}
+			if perKey {
+				record.KeyID = bucket.KeyID.Hex()
+				record.TenantID = bucket.TenantID
+			}
+			records = append(records, record)
+			i = len(records) - 1
+			index[key] = i
+		}
+		records[i].Add(bucket.UsageCounters)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		if !records[i].PeriodStart.Equal(records[j].PeriodStart) {
+			return records[i].PeriodStart.Before(records[j].PeriodStart)
+		}
+		return records[i].KeyID < records[j].KeyID
+	})
+
+	return records
+}
+
+// flush writes pending buckets to MongoDB with upserted $inc updates. Buckets whose
+// write failed are kept for the next flush. Flushes run one at a time: from the
+// flusher, then from Close.
+func (s *UsageService) flush() error {
+	s.mutex.Lock()
+	pending := s.pending
+	s.pending = make(map[bucketKey]*pendingBucket)
+	s.inFlight = pending
+	s.mutex.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	keys := make([]bucketKey, 0, len(pending))
+	writes := make([]mongo.WriteModel, 0, len(pending))
+	for key, bucket := range pending {
+		keys = append(keys, key)
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"key_id": key.keyID, "bucket": key.bucket}).
+			SetUpdate(bson.M{
+				"$inc": bson.M{
+					"requests":     bucket.counters.Requests,
+					"wallets":      bucket.counters.Wallets,
+					"cache_hits":   bucket.counters.CacheHits,
+					"cache_misses": bucket.counters.CacheMisses,
+					"rpc_calls":    bucket.counters.RPCCalls,
+				},
+				"$set": bson.M{"tenant_id": bucket.tenantID, "updated_at": now},
+			}).
+			SetUpsert(true))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	s.writeMutex.Lock()
+	defer s.writeMutex.Unlock()
+
+	_, err := s.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.inFlight = make(map[bucketKey]*pendingBucket)
+	for _, i := range failedWrites(err, len(writes)) {
+		s.mergePendingLocked(keys[i], pending[keys[i]])
+	}
+
+	return err
+}
+
+// failedWrites returns the indexes of the writes of an unordered bulk write that
+// did not apply. A bulk write exception lists them; after any other error none
+// are known to have applied.
+func failedWrites(err error, count int) []int {
+	if err == nil {
+		return nil
+	}
+
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) {
+		failed := make([]int, 0, len(bulkErr.WriteErrors))
+		for _, writeErr := range bulkErr.WriteErrors {
+			failed = append(failed, writeErr.Index)
+		}
+		return failed
+	}
+
+	failed := make([]int, count)
+	for i := range failed {
+		failed[i] = i
+	}
+	return failed
+}
+
+// mergePendingLocked adds usage to the pending counters. Caller must hold mutex.
+func (s *UsageService) mergePendingLocked(key bucketKey, bucket *pendingBucket) {
+	existing, exists := s.pending[key]
+	if !exists {
+		s.pending[key] = bucket
+		return
+	}
+	existing.counters.Add(bucket.counters)
+	if bucket.tenantID != "" {
+		existing.tenantID = bucket.tenantID
+	}
+}
+
+// runFlusher periodically flushes usage counters
+func (s *UsageService) runFlusher() {
+	defer s.wg.Done()
+
+	interval := s.config.FlushInterval
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.flush(); err != nil {
+				logger.GetLogger().Warn("Failed to flush API key usage", zap.Error(err))
+			}
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// Close stops the flusher and writes any pending usage
+func (s *UsageService) Close() error {
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.wg.Wait()
+
+	return s.flush()
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"solana-balance-api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestAggregateBuckets(t *testing.T) {
+	keyA := primitive.NewObjectID()
+	keyB := primitive.NewObjectID()
+	day := time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)
+
+	buckets := []models.UsageBucket{
+		{KeyID: keyA, Bucket: day.Add(1 * time.Hour), UsageCounters: models.UsageCounters{Requests: 1, Wallets: 10, CacheHits: 4, CacheMisses: 6, RPCCalls: 6}},
+		{KeyID: keyA, Bucket: day.Add(2 * time.Hour), UsageCounters: models.UsageCounters{Requests: 2, Wallets: 5}},
+		{KeyID: keyB, Bucket: day.Add(2 * time.Hour), UsageCounters: models.UsageCounters{Requests: 3, Wallets: 1}},
+		{KeyID: keyA, Bucket: day.Add(25 * time.Hour), UsageCounters: models.UsageCounters{Requests: 4}},
+	}
+
+	t.Run("Hourly", func(t *testing.T) {
+		records := aggregateBuckets(buckets, models.GranularityHour, false)
+		assert.Len(t, records, 3)
+		assert.Equal(t, int64(5), records[1].Requests)
+		assert.Equal(t, int64(6), records[1].Wallets)
+		assert.Empty(t, records[1].KeyID)
+	})
+
+	t.Run("Daily", func(t *testing.T) {
+		records := aggregateBuckets(buckets, models.GranularityDay, false)
+		assert.Len(t, records, 2)
+		assert.Equal(t, day, records[0].PeriodStart)
+		assert.Equal(t, int64(6), records[0].Requests)
+		assert.Equal(t, int64(6), records[0].RPCCalls)
+		assert.Equal(t, int64(4), records[1].Requests)
+	})
+
+	t.Run("MonthlyPerKey", func(t *testing.T) {
+		records := aggregateBuckets(buckets, models.GranularityMonth, true)
+		assert.Len(t, records, 2)
+		for _, record := range records {
+			assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), record.PeriodStart)
+			switch record.KeyID {
+			case keyA.Hex():
+				assert.Equal(t, int64(7), record.Requests)
+				assert.Equal(t, int64(15), record.Wallets)
+			case keyB.Hex():
+				assert.Equal(t, int64(3), record.Requests)
+			default:
+				t.Fatalf("unexpected key %s", record.KeyID)
+			}
+		}
+	})
+}
+
+func TestFailedWrites(t *testing.T) {
+	assert.Empty(t, failedWrites(nil, 3))
+
+	// Unordered bulk writes report the writes that failed; the others applied
+	bulkErr := mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{
+		{WriteError: mongo.WriteError{Index: 1, Code: 11000}},
+	}}
+	assert.Equal(t, []int{1}, failedWrites(bulkErr, 3))
+	assert.Equal(t, []int{1}, failedWrites(fmt.Errorf("flush: %w", bulkErr), 3))
+
+	assert.Equal(t, []int{0, 1, 2}, failedWrites(errors.New("connection reset"), 3))
+}
+
+func TestUnflushedBuckets(t *testing.T) {
+	keyA := primitive.NewObjectID()
+	keyB := primitive.NewObjectID()
+	hour := time.Date(2026, 3, 14, 10, 0, 0, 0, time.UTC)
+
+	s := &UsageService{
+		pending: map[bucketKey]*pendingBucket{
+			{keyID: keyA, bucket: hour}:                     {tenantID: "team-a", counters: models.UsageCounters{Requests: 1}},
+			{keyID: keyA, bucket: hour.Add(-2 * time.Hour)}: {tenantID: "team-a", counters: models.UsageCounters{Requests: 5}},
+		},
+		// A flush has taken these buckets but its write has not landed yet
+		inFlight: map[bucketKey]*pendingBucket{
+			{keyID: keyA, bucket: hour}: {tenantID: "team-a", counters: models.UsageCounters{Requests: 2}},
+			{keyID: keyB, bucket: hour}: {tenantID: "team-b", counters: models.UsageCounters{Requests: 4}},
+		},
+	}
+
+	sum := func(buckets []models.UsageBucket) (total int64) {
+		for _, bucket := range buckets {
+			total += bucket.Requests
+		}
+		return total
+	}
+
+	from, to := hour.Add(-time.Hour), hour.Add(time.Hour)
+	assert.EqualValues(t, 7, sum(s.unflushedBuckets(models.UsageQuery{}, from, to)))
+	assert.EqualValues(t, 3, sum(s.unflushedBuckets(models.UsageQuery{KeyID: keyA}, from, to)))
+	assert.EqualValues(t, 4, sum(s.unflushedBuckets(models.UsageQuery{TenantID: "team-b"}, from, to)))
+}
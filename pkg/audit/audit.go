@@ -0,0 +1,535 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"solana-balance-api/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// Event types
+const (
+	EventAuthSuccess         = "auth_success"
+	EventAuthFailure         = "auth_failure"
+	EventIPDenied            = "ip_denied"
+	EventIPNotAllowed        = "ip_not_allowed"
+	EventScopeDenied         = "scope_denied"
+	EventTenantInactive      = "tenant_inactive"
+	EventTokenIssued         = "token_issued"
+	EventTokenClientRejected = "token_client_rejected"
+	EventKeyCreated          = "key_created"
+	EventKeyRotated          = "key_rotated"
+	EventKeyAutoDeactivated  = "key_auto_deactivated"
+	EventAdminAction         = "admin_action"
+	EventLogLevelChanged     = "log_level_changed"
+	EventRateLimitExceeded   = "rate_limit_exceeded"
+)
+
+// Event outcomes
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// maxAppendAttempts bounds retries when another writer extends the chain concurrently
+const maxAppendAttempts = 5
+
+// Event is a single audit record. Sequence, PrevHash and Hash are assigned when the
+// event is appended to the chain.
+type Event struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Sequence      int64              `bson:"sequence" json:"sequence"`
+	Timestamp     time.Time          `bson:"timestamp" json:"timestamp"`
+	Type          string             `bson:"type" json:"type"`
+	Outcome       string             `bson:"outcome" json:"outcome"`
+	ActorKeyID    string             `bson:"actor_key_id,omitempty" json:"actor_key_id,omitempty"`
+	TenantID      string             `bson:"tenant_id,omitempty" json:"tenant_id,omitempty"`
+	ClientIP      string             `bson:"client_ip,omitempty" json:"client_ip,omitempty"`
+	CorrelationID string             `bson:"correlation_id,omitempty" json:"correlation_id,omitempty"`
+	Target        string             `bson:"target,omitempty" json:"target,omitempty"`
+	Details       map[string]string  `bson:"details,omitempty" json:"details,omitempty"`
+	PrevHash      string             `bson:"prev_hash" json:"prev_hash"`
+	Hash          string             `bson:"hash" json:"hash"`
+}
+
+// Config holds audit logger configuration
+type Config struct {
+	BufferSize    int           // Events queued before new events are dropped
+	BatchSize     int           // Maximum events appended in one write
+	FlushInterval time.Duration // Maximum time an event waits in the queue
+	SkipTypes     []string      // Event types that are not recorded
+
+	// Failed batches are retried MaxRetries times, RetryBackoff apart and doubling,
+	// then appended to SpillFile (JSON lines) and replayed after the next successful
+	// write. Without a spill file they are dropped.
+	MaxRetries   int
+	RetryBackoff time.Duration
+	SpillFile    string
+}
+
+// Logger appends audit events to a hash-chained, append-only MongoDB collection.
+// Events are queued and written by a single background writer so recording never
+// blocks the request path.
+type Logger struct {
+	collection *mongo.Collection
+	config     Config
+	queue      chan Event
+	skip       map[string]bool
+
+	// appendEvents appends a batch; Append unless replaced in tests
+	appendEvents func(ctx context.Context, events ...Event) error
+	// spillPending is set while the spill file may hold events. Only the writer uses it.
+	spillPending bool
+
+	// Chain tail, guarded by chainMutex
+	chainMutex sync.Mutex
+	loaded     bool
+	lastSeq    int64
+	lastHash   string
+
+	recorded int64
+	written  int64
+	dropped  int64
+	failed   int64
+	retried  int64
+	spilled  int64
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewLogger creates an audit logger writing to collection and starts its writer
+func NewLogger(collection *mongo.Collection, cfg Config) *Logger {
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 10000
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 500 * time.Millisecond
+	}
+
+	l := &Logger{
+		collection: collection,
+		config:     cfg,
+		queue:      make(chan Event, cfg.BufferSize),
+		skip:       make(map[string]bool, len(cfg.SkipTypes)),
+		stopCh:     make(chan struct{}),
+	}
+	for _, eventType := range cfg.SkipTypes {
+		l.skip[eventType] = true
+	}
+	l.appendEvents = l.Append
+
+	// Events spilled before a restart are replayed when the writer starts
+	if info, err := os.Stat(cfg.SpillFile); err == nil && info.Size() > 0 {
+		l.spillPending = true
+	}
+
+	l.wg.Add(1)
+	go l.run()
+
+	return l
+}
+
+// Record queues an event for appending. Events are dropped, and counted, when the
+// queue is full.
+func (l *Logger) Record(event Event) {
+	if l.skip[event.Type] {
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	if event.Outcome == "" {
+		event.Outcome = OutcomeSuccess
+	}
+
+	select {
+	case l.queue <- event:
+		atomic.AddInt64(&l.recorded, 1)
+	default:
+		atomic.AddInt64(&l.dropped, 1)
+		logger.GetLogger().Error("Audit queue full, event dropped",
+			zap.String("audit_event", event.Type),
+			zap.String("api_key_id", event.ActorKeyID),
+		)
+	}
+}
+
+// Append synchronously appends events to the chain in order
+func (l *Logger) Append(ctx context.Context, events ...Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	l.chainMutex.Lock()
+	defer l.chainMutex.Unlock()
+
+	remaining := events
+	for attempt := 0; attempt < maxAppendAttempts; attempt++ {
+		if !l.loaded {
+			if err := l.loadTailLocked(ctx); err != nil {
+				return err
+			}
+		}
+
+		docs := l.chainLocked(remaining)
+		_, err := l.collection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(true))
+		if err == nil {
+			last := remaining[len(remaining)-1]
+			l.lastSeq, l.lastHash = last.Sequence, last.Hash
+			return nil
+		}
+
+		// Another writer extended the chain; reload the tail and re-chain what is left
+		l.loaded = false
+		if !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+
+		remaining, err = l.unwritten(ctx, remaining)
+		if err != nil {
+			return err
+		}
+		if len(remaining) == 0 {
+			return nil
+		}
+	}
+
+	return errors.New("audit chain append conflicted repeatedly")
+}
+
+// chainLocked assigns sequence numbers and hashes continuing from the chain tail.
+// Caller must hold chainMutex.
+func (l *Logger) chainLocked(events []Event) []interface{} {
+	docs := make([]interface{}, len(events))
+	seq, prev := l.lastSeq, l.lastHash
+	for i := range events {
+		event := &events[i]
+		if event.ID.IsZero() {
+			event.ID = primitive.NewObjectID()
+		}
+		// MongoDB stores milliseconds; hash exactly what will be read back
+		event.Timestamp = event.Timestamp.UTC().Truncate(time.Millisecond)
+		if len(event.Details) == 0 {
+			event.Details = nil
+		}
+
+		seq++
+		event.Sequence = seq
+		event.PrevHash = prev
+		event.Hash = ComputeHash(*event)
+		prev = event.Hash
+
+		docs[i] = *event
+	}
+	return docs
+}
+
+// loadTailLocked reads the last sequence number and hash. Caller must hold chainMutex.
+func (l *Logger) loadTailLocked(ctx context.Context) error {
+	var tail Event
+	err := l.collection.FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.D{{Key: "sequence", Value: -1}})).Decode(&tail)
+	switch err {
+	case nil:
+		l.lastSeq, l.lastHash = tail.Sequence, tail.Hash
+	case mongo.ErrNoDocuments:
+		l.lastSeq, l.lastHash = 0, ""
+	default:
+		return err
+	}
+	l.loaded = true
+	return nil
+}
+
+// unwritten returns the events of a failed ordered insert that were not stored
+func (l *Logger) unwritten(ctx context.Context, events []Event) ([]Event, error) {
+	ids := make([]primitive.ObjectID, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+
+	cursor, err := l.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var stored []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &stored); err != nil {
+		return nil, err
+	}
+
+	written := make(map[primitive.ObjectID]bool, len(stored))
+	for _, doc := range stored {
+		written[doc.ID] = true
+	}
+
+	remaining := make([]Event, 0, len(events))
+	for _, event := range events {
+		if !written[event.ID] {
+			remaining = append(remaining, event)
+		}
+	}
+	return remaining, nil
+}
+
+// run drains the queue in batches
+func (l *Logger) run() {
+	defer l.wg.Done()
+
+	// Replay events spilled before a restart ahead of new ones
+	l.replaySpilled()
+
+	ticker := time.NewTicker(l.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Event, 0, l.config.BatchSize)
+	for {
+		select {
+		case event := <-l.queue:
+			batch = append(batch, event)
+			if len(batch) >= l.config.BatchSize {
+				batch = l.write(batch)
+			}
+		case <-ticker.C:
+			batch = l.write(batch)
+		case <-l.stopCh:
+			// Drain whatever is still queued
+			for {
+				select {
+				case event := <-l.queue:
+					batch = append(batch, event)
+				default:
+					l.write(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// write appends a batch and returns the emptied batch for reuse. Failed batches
+// are retried with backoff, then spilled to the spill file or dropped. Spilled
+// events are older, so they are replayed first to keep the chain in order; while
+// they cannot be, the batch is spilled behind them.
+func (l *Logger) write(batch []Event) []Event {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	err := errSpillPending
+	if l.replaySpilled() {
+		err = l.appendWithRetry(batch)
+		if err == nil {
+			atomic.AddInt64(&l.written, int64(len(batch)))
+			return batch[:0]
+		}
+	}
+
+	if l.config.SpillFile != "" {
+		spillErr := l.spill(batch)
+		if spillErr == nil {
+			atomic.AddInt64(&l.spilled, int64(len(batch)))
+			logger.GetLogger().Warn("Failed to append audit events, spilled to file",
+				zap.Error(err),
+				zap.Int("events", len(batch)),
+				zap.String("spill_file", l.config.SpillFile),
+			)
+			return batch[:0]
+		}
+		err = fmt.Errorf("%w (spilling failed: %v)", err, spillErr)
+	}
+
+	atomic.AddInt64(&l.failed, int64(len(batch)))
+	logger.GetLogger().Error("Failed to append audit events",
+		zap.Error(err),
+		zap.Int("events", len(batch)),
+	)
+	return batch[:0]
+}
+
+// appendWithRetry appends events, retrying failures with exponential backoff.
+// Retries are safe: events keep their IDs, and stored ones are skipped.
+func (l *Logger) appendWithRetry(events []Event) error {
+	backoff := l.config.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := l.appendEvents(ctx, events...)
+		cancel()
+
+		if err == nil || attempt >= l.config.MaxRetries {
+			return err
+		}
+
+		atomic.AddInt64(&l.retried, 1)
+		logger.GetLogger().Warn("Failed to append audit events, retrying",
+			zap.Error(err),
+			zap.Int("events", len(events)),
+			zap.Duration("backoff", backoff),
+		)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// spill appends events to the spill file as JSON lines
+func (l *Logger) spill(events []Event) error {
+	file, err := os.OpenFile(l.config.SpillFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(file)
+	encoder := json.NewEncoder(w)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	l.spillPending = true
+	return file.Close()
+}
+
+// replaySpilled appends the events of the spill file and removes it, reporting
+// whether no spilled events remain. The file is kept for the next attempt if
+// reading or appending fails. A file that cannot be parsed is moved aside so it
+// does not block newer events.
+func (l *Logger) replaySpilled() bool {
+	if !l.spillPending {
+		return true
+	}
+
+	log := logger.GetLogger()
+
+	file, err := os.Open(l.config.SpillFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			l.spillPending = false
+			return true
+		}
+		log.Error("Failed to read audit spill file", zap.Error(err))
+		return false
+	}
+
+	var events []Event
+	decoder := json.NewDecoder(file)
+	for decoder.More() {
+		var event Event
+		if err := decoder.Decode(&event); err != nil {
+			file.Close()
+			corrupt := l.config.SpillFile + ".corrupt"
+			log.Error("Failed to parse audit spill file, moving it aside",
+				zap.Error(err),
+				zap.String("spill_file", l.config.SpillFile),
+				zap.String("moved_to", corrupt),
+			)
+			if err := os.Rename(l.config.SpillFile, corrupt); err != nil {
+				log.Error("Failed to move audit spill file", zap.Error(err))
+				return false
+			}
+			l.spillPending = false
+			return true
+		}
+		events = append(events, event)
+	}
+	file.Close()
+
+	if err := l.appendWithRetry(events); err != nil {
+		log.Warn("Failed to replay spilled audit events", zap.Error(err), zap.Int("events", len(events)))
+		return false
+	}
+	if err := os.Remove(l.config.SpillFile); err != nil {
+		// The events are stored; a later replay skips them by ID
+		log.Error("Failed to remove audit spill file", zap.Error(err))
+	} else {
+		l.spillPending = false
+	}
+
+	atomic.AddInt64(&l.written, int64(len(events)))
+	log.Info("Replayed spilled audit events", zap.Int("events", len(events)))
+	return true
+}
+
+// GetStats returns audit logger statistics for monitoring
+func (l *Logger) GetStats() map[string]interface{} {
+	return map[string]interface{}{
+		"recorded": atomic.LoadInt64(&l.recorded),
+		"written":  atomic.LoadInt64(&l.written),
+		"dropped":  atomic.LoadInt64(&l.dropped),
+		"failed":   atomic.LoadInt64(&l.failed),
+		"retried":  atomic.LoadInt64(&l.retried),
+		"spilled":  atomic.LoadInt64(&l.spilled),
+		"queued":   len(l.queue),
+	}
+}
+
+// Close stops the writer after appending all queued events
+func (l *Logger) Close() error {
+	l.stopOnce.Do(func() {
+		close(l.stopCh)
+	})
+	l.wg.Wait()
+	return nil
+}
+
+// errSpillPending is reported for batches spilled behind events that could not be
+// replayed yet
+var errSpillPending = errors.New("earlier audit events are still in the spill file")
+
+// Default audit logger used by the package-level Record; nil disables auditing
+var (
+	defaultLogger *Logger
+	defaultMutex  sync.RWMutex
+)
+
+// SetDefault sets the audit logger used by the package-level Record functions
+func SetDefault(l *Logger) {
+	defaultMutex.Lock()
+	defer defaultMutex.Unlock()
+	defaultLogger = l
+}
+
+// Default returns the default audit logger, or nil when auditing is disabled
+func Default() *Logger {
+	defaultMutex.RLock()
+	defer defaultMutex.RUnlock()
+	return defaultLogger
+}
+
+// Record queues an event on the default audit logger. It is a no-op when no
+// default logger is set.
+func Record(event Event) {
+	if l := Default(); l != nil {
+		l.Record(event)
+	}
+}
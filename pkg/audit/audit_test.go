@@ -0,0 +1,201 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// buildChain chains events the way Logger.Append does, without MongoDB
+func buildChain(n int) []Event {
+	l := &Logger{}
+	events := make([]Event, n)
+	for i := range events {
+		events[i] = Event{
+			Timestamp:  time.Now(),
+			Type:       EventAuthSuccess,
+			Outcome:    OutcomeSuccess,
+			ActorKeyID: "key",
+			Details:    map[string]string{"path": "/api/get-balance", "method": "POST"},
+		}
+	}
+	l.chainLocked(events)
+	return events
+}
+
+func TestVerifyEvents(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		events := buildChain(5)
+		result := VerifyEvents(events)
+		assert.True(t, result.Valid())
+		assert.Equal(t, int64(5), result.Records)
+		assert.Equal(t, int64(5), result.LastSequence)
+		assert.Equal(t, events[4].Hash, result.LastHash)
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		events := buildChain(5)
+		events[2].Outcome = OutcomeFailure
+		result := VerifyEvents(events)
+		assert.Len(t, result.Issues, 1)
+		assert.Equal(t, IssueHashMismatch, result.Issues[0].Kind)
+		assert.Equal(t, int64(3), result.Issues[0].Sequence)
+	})
+
+	t.Run("Rehashed", func(t *testing.T) {
+		// Recomputing the tampered record's hash breaks the link to the next record
+		events := buildChain(5)
+		events[2].Details["path"] = "/api/admin/usage"
+		events[2].Hash = ComputeHash(events[2])
+		result := VerifyEvents(events)
+		assert.Len(t, result.Issues, 1)
+		assert.Equal(t, IssueChainBroken, result.Issues[0].Kind)
+		assert.Equal(t, int64(4), result.Issues[0].Sequence)
+	})
+
+	t.Run("Deleted", func(t *testing.T) {
+		events := buildChain(5)
+		events = append(events[:2], events[3:]...)
+		result := VerifyEvents(events)
+		kinds := []string{}
+		for _, issue := range result.Issues {
+			kinds = append(kinds, issue.Kind)
+		}
+		assert.Equal(t, []string{IssueGap, IssueChainBroken}, kinds)
+	})
+
+	t.Run("TruncatedHead", func(t *testing.T) {
+		events := buildChain(5)
+		result := VerifyEvents(events[2:])
+		assert.Len(t, result.Issues, 1)
+		assert.Equal(t, IssueGap, result.Issues[0].Kind)
+	})
+}
+
+func TestComputeHashIgnoresEmptyDetails(t *testing.T) {
+	event := Event{Sequence: 1, Timestamp: time.Now(), Type: EventKeyCreated}
+	withEmpty := event
+	withEmpty.Details = map[string]string{}
+	assert.Equal(t, ComputeHash(event), ComputeHash(withEmpty))
+}
+
+// flakyAppender fails the first failures calls and records appended events
+type flakyAppender struct {
+	failures int
+	calls    int
+	appended []Event
+}
+
+func (f *flakyAppender) append(ctx context.Context, events ...Event) error {
+	f.calls++
+	if f.calls <= f.failures {
+		return errors.New("connection reset")
+	}
+	f.appended = append(f.appended, events...)
+	return nil
+}
+
+func newTestLogger(cfg Config, appender *flakyAppender) *Logger {
+	l := &Logger{config: cfg}
+	l.appendEvents = appender.append
+	return l
+}
+
+func testEvents(types ...string) []Event {
+	events := make([]Event, len(types))
+	for i, eventType := range types {
+		events[i] = Event{ID: primitive.NewObjectID(), Type: eventType, Timestamp: time.Now()}
+	}
+	return events
+}
+
+func TestWriteRetriesAndSpills(t *testing.T) {
+	t.Run("RetriesTransientErrors", func(t *testing.T) {
+		appender := &flakyAppender{failures: 2}
+		l := newTestLogger(Config{MaxRetries: 2, RetryBackoff: time.Millisecond}, appender)
+
+		l.write(testEvents(EventKeyCreated))
+		assert.Len(t, appender.appended, 1)
+		assert.EqualValues(t, 1, l.written)
+		assert.EqualValues(t, 2, l.retried)
+		assert.Zero(t, l.failed)
+	})
+
+	t.Run("DropsWithoutSpillFile", func(t *testing.T) {
+		appender := &flakyAppender{failures: 10}
+		l := newTestLogger(Config{MaxRetries: 1, RetryBackoff: time.Millisecond}, appender)
+
+		l.write(testEvents(EventKeyCreated, EventKeyRotated))
+		assert.Equal(t, 2, appender.calls)
+		assert.EqualValues(t, 2, l.failed)
+	})
+
+	t.Run("SpillsAndReplays", func(t *testing.T) {
+		spillFile := filepath.Join(t.TempDir(), "audit.spill")
+		appender := &flakyAppender{failures: 2}
+		l := newTestLogger(Config{MaxRetries: 1, RetryBackoff: time.Millisecond, SpillFile: spillFile}, appender)
+
+		spilled := testEvents(EventKeyCreated, EventKeyRotated)
+		l.write(spilled)
+		assert.EqualValues(t, 2, l.spilled)
+		assert.Zero(t, l.failed)
+		assert.FileExists(t, spillFile)
+
+		// The next write replays the older spilled events first and removes the file
+		l.write(testEvents(EventAdminAction))
+		require.Len(t, appender.appended, 3)
+		assert.Equal(t, spilled[0].ID, appender.appended[0].ID)
+		assert.Equal(t, spilled[1].ID, appender.appended[1].ID)
+		assert.Equal(t, EventAdminAction, appender.appended[2].Type)
+		assert.NoFileExists(t, spillFile)
+		assert.EqualValues(t, 3, l.written)
+	})
+
+	t.Run("SpillsBehindPendingEvents", func(t *testing.T) {
+		spillFile := filepath.Join(t.TempDir(), "audit.spill")
+		appender := &flakyAppender{failures: 1}
+		l := newTestLogger(Config{SpillFile: spillFile}, appender)
+
+		spilled := testEvents(EventKeyCreated)
+		l.write(spilled)
+
+		// The replay fails, so the newer batch goes behind the spilled events
+		appender.failures = 2
+		newer := testEvents(EventKeyRotated)
+		l.write(newer)
+		assert.Empty(t, appender.appended)
+		assert.EqualValues(t, 2, l.spilled)
+
+		l.write(testEvents(EventAdminAction))
+		require.Len(t, appender.appended, 3)
+		assert.Equal(t, spilled[0].ID, appender.appended[0].ID)
+		assert.Equal(t, newer[0].ID, appender.appended[1].ID)
+		assert.Equal(t, EventAdminAction, appender.appended[2].Type)
+	})
+
+	t.Run("ReplaysOnStartup", func(t *testing.T) {
+		spillFile := filepath.Join(t.TempDir(), "audit.spill")
+		spilled := testEvents(EventKeyCreated)
+		require.NoError(t, newTestLogger(Config{SpillFile: spillFile}, &flakyAppender{}).spill(spilled))
+
+		appender := &flakyAppender{}
+		l := newTestLogger(Config{SpillFile: spillFile, FlushInterval: time.Hour}, appender)
+		l.spillPending = true
+		l.queue = make(chan Event, 1)
+		l.stopCh = make(chan struct{})
+		l.wg.Add(1)
+		go l.run()
+		close(l.stopCh)
+		l.wg.Wait()
+
+		require.Len(t, appender.appended, 1)
+		assert.Equal(t, spilled[0].ID, appender.appended[0].ID)
+		assert.NoFileExists(t, spillFile)
+	})
+}
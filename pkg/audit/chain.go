@@ -0,0 +1,152 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Verification issue kinds
+const (
+	IssueGap          = "gap"           // Sequence numbers are missing
+	IssueDuplicate    = "duplicate"     // A sequence number appears more than once
+	IssueChainBroken  = "chain_broken"  // prev_hash does not match the previous record's hash
+	IssueHashMismatch = "hash_mismatch" // The record's contents do not match its hash
+)
+
+// hashedEvent is the canonical form of an event covered by its hash. Field order is
+// fixed and map keys are sorted by encoding/json, so the encoding is deterministic.
+type hashedEvent struct {
+	Sequence      int64             `json:"sequence"`
+	Timestamp     string            `json:"timestamp"`
+	Type          string            `json:"type"`
+	Outcome       string            `json:"outcome"`
+	ActorKeyID    string            `json:"actor_key_id"`
+	TenantID      string            `json:"tenant_id"`
+	ClientIP      string            `json:"client_ip"`
+	CorrelationID string            `json:"correlation_id"`
+	Target        string            `json:"target"`
+	Details       map[string]string `json:"details,omitempty"`
+	PrevHash      string            `json:"prev_hash"`
+}
+
+// ComputeHash returns the hex SHA-256 of the event's canonical form, which includes
+// the previous record's hash
+func ComputeHash(event Event) string {
+	canonical, _ := json.Marshal(hashedEvent{
+		Sequence:      event.Sequence,
+		Timestamp:     event.Timestamp.UTC().Truncate(time.Millisecond).Format(time.RFC3339Nano),
+		Type:          event.Type,
+		Outcome:       event.Outcome,
+		ActorKeyID:    event.ActorKeyID,
+		TenantID:      event.TenantID,
+		ClientIP:      event.ClientIP,
+		CorrelationID: event.CorrelationID,
+		Target:        event.Target,
+		Details:       event.Details,
+		PrevHash:      event.PrevHash,
+	})
+
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:])
+}
+
+// Issue describes a problem found while verifying the chain
+type Issue struct {
+	Sequence int64  `json:"sequence"`
+	Kind     string `json:"kind"`
+	Message  string `json:"message"`
+}
+
+// VerifyResult summarizes a chain verification
+type VerifyResult struct {
+	Records      int64   `json:"records"`
+	LastSequence int64   `json:"last_sequence"`
+	LastHash     string  `json:"last_hash"`
+	Issues       []Issue `json:"issues"`
+}
+
+// Valid reports whether the chain verified without issues
+func (r *VerifyResult) Valid() bool {
+	return len(r.Issues) == 0
+}
+
+// verifier checks records one at a time in sequence order
+type verifier struct {
+	result  VerifyResult
+	started bool
+}
+
+// check verifies a record against the previous one
+func (v *verifier) check(event Event) {
+	v.result.Records++
+
+	expectedSeq, expectedPrev := int64(1), ""
+	if v.started {
+		expectedSeq, expectedPrev = v.result.LastSequence+1, v.result.LastHash
+	}
+
+	switch {
+	case event.Sequence < expectedSeq:
+		v.addIssue(event.Sequence, IssueDuplicate, "sequence number repeated")
+	case event.Sequence > expectedSeq:
+		v.addIssue(event.Sequence, IssueGap, fmt.Sprintf("records %d-%d are missing", expectedSeq, event.Sequence-1))
+	}
+
+	if event.PrevHash != expectedPrev && (v.started || event.Sequence == 1) {
+		v.addIssue(event.Sequence, IssueChainBroken, "prev_hash does not match the previous record")
+	}
+
+	if ComputeHash(event) != event.Hash {
+		v.addIssue(event.Sequence, IssueHashMismatch, "record contents do not match its hash")
+	}
+
+	v.started = true
+	v.result.LastSequence = event.Sequence
+	v.result.LastHash = event.Hash
+}
+
+// addIssue records a verification issue
+func (v *verifier) addIssue(sequence int64, kind, message string) {
+	v.result.Issues = append(v.result.Issues, Issue{Sequence: sequence, Kind: kind, Message: message})
+}
+
+// VerifyEvents verifies a chain given as events ordered by sequence
+func VerifyEvents(events []Event) *VerifyResult {
+	v := &verifier{}
+	for _, event := range events {
+		v.check(event)
+	}
+	return &v.result
+}
+
+// Verify walks the audit collection in sequence order and reports gaps, duplicates,
+// broken links and records whose contents no longer match their hash
+func Verify(ctx context.Context, collection *mongo.Collection) (*VerifyResult, error) {
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "sequence", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	v := &verifier{}
+	for cursor.Next(ctx) {
+		var event Event
+		if err := cursor.Decode(&event); err != nil {
+			return nil, err
+		}
+		v.check(event)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	return &v.result, nil
+}
@@ -0,0 +1,31 @@
+package audit
+
+import (
+	"solana-balance-api/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestEvent builds an event populated from the request: the authenticated key,
+// tenant, client IP and correlation ID
+func RequestEvent(c *gin.Context, eventType, outcome string) Event {
+	return Event{
+		Type:          eventType,
+		Outcome:       outcome,
+		ActorKeyID:    c.GetString("api_key_id"),
+		TenantID:      c.GetString("tenant_id"),
+		ClientIP:      c.ClientIP(),
+		CorrelationID: logger.GetCorrelationIDFromContext(c.Request.Context()),
+	}
+}
+
+// RecordRequest queues an event for the request on the default audit logger
+func RecordRequest(c *gin.Context, eventType, outcome string, details map[string]string) {
+	if Default() == nil {
+		return
+	}
+
+	event := RequestEvent(c, eventType, outcome)
+	event.Details = details
+	Record(event)
+}
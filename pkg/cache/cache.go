@@ -9,6 +9,7 @@ import (
 type CacheEntry struct {
 	Balance   float64
 	Timestamp time.Time
+	NotFound  bool // The account does not exist; Balance is zero
 }
 
 // Cache provides thread-safe caching with TTL support
@@ -51,6 +52,18 @@ func (c *Cache) Get(key string) (float64, bool) {
 	return entry.Balance, true
 }
 
+// GetEntry retrieves an unexpired entry, including whether the account was missing
+func (c *Cache) GetEntry(key string) (CacheEntry, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	entry, exists := c.data[key]
+	if !exists || time.Since(entry.Timestamp) > c.ttl {
+		return CacheEntry{}, false
+	}
+	return *entry, true
+}
+
 // Set stores a value in the cache with the current timestamp
 func (c *Cache) Set(key string, balance float64) {
 	c.mutex.Lock()
@@ -62,6 +75,17 @@ func (c *Cache) Set(key string, balance float64) {
 	}
 }
 
+// SetNotFound records that the account for key does not exist
+func (c *Cache) SetNotFound(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.data[key] = &CacheEntry{
+		Timestamp: time.Now(),
+		NotFound:  true,
+	}
+}
+
 // Delete removes a key from the cache
 func (c *Cache) Delete(key string) {
 	c.mutex.Lock()
@@ -78,6 +102,23 @@ func (c *Cache) Clear() {
 	c.data = make(map[string]*CacheEntry)
 }
 
+// TTL returns how long entries stay fresh
+func (c *Cache) TTL() time.Duration {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.ttl
+}
+
+// SetTTL changes how long entries stay fresh. Existing entries are judged against
+// the new TTL; the cleanup interval is unchanged.
+func (c *Cache) SetTTL(ttl time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.ttl = ttl
+}
+
 // Size returns the number of entries in the cache
 func (c *Cache) Size() int {
 	c.mutex.RLock()
@@ -0,0 +1,137 @@
+package clientip
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Supported sources for the real client IP
+const (
+	// HeaderNone uses the TCP peer address and ignores all forwarding headers
+	HeaderNone = ""
+	// HeaderXForwardedFor trusts X-Forwarded-For from trusted proxies
+	HeaderXForwardedFor = "X-Forwarded-For"
+	// HeaderXRealIP trusts X-Real-IP from trusted proxies
+	HeaderXRealIP = "X-Real-IP"
+	// HeaderCFConnectingIP trusts CF-Connecting-IP from trusted proxies (Cloudflare)
+	HeaderCFConnectingIP = "CF-Connecting-IP"
+	// HeaderProxyProtocol reads the client address from a PROXY protocol v1/v2 preamble
+	HeaderProxyProtocol = "PROXY"
+)
+
+// Config describes which proxies are trusted and how the client IP is forwarded
+type Config struct {
+	TrustedProxies []string `json:"trusted_proxies"`
+	Header         string   `json:"header"`
+}
+
+// Validate checks that the header mode is supported and all proxies are valid IPs or CIDRs
+func (c Config) Validate() error {
+	switch c.Header {
+	case HeaderNone, HeaderXForwardedFor, HeaderXRealIP, HeaderCFConnectingIP, HeaderProxyProtocol:
+	default:
+		return fmt.Errorf("unsupported client IP header %q", c.Header)
+	}
+
+	if _, err := ParseCIDRs(c.TrustedProxies); err != nil {
+		return err
+	}
+
+	if c.Header != HeaderNone && len(c.TrustedProxies) == 0 {
+		return fmt.Errorf("client IP header %q requires at least one trusted proxy", c.Header)
+	}
+
+	return nil
+}
+
+// Configure applies the trusted proxy configuration to a Gin engine so that
+// c.ClientIP() returns the real client address everywhere it is used
+// (rate limiting, auth logs and access logs).
+func Configure(engine *gin.Engine, cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	// Never trust the platform shortcut headers; they bypass the proxy check
+	engine.TrustedPlatform = ""
+
+	switch cfg.Header {
+	case HeaderNone, HeaderProxyProtocol:
+		// The peer address is authoritative (PROXY protocol rewrites it at the listener)
+		engine.ForwardedByClientIP = false
+		return engine.SetTrustedProxies(nil)
+	default:
+		engine.ForwardedByClientIP = true
+		engine.RemoteIPHeaders = []string{cfg.Header}
+		return engine.SetTrustedProxies(cfg.TrustedProxies)
+	}
+}
+
+// Listen opens a TCP listener, wrapping it with PROXY protocol support when configured
+func Listen(addr string, cfg Config) (net.Listener, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Header != HeaderProxyProtocol {
+		return ln, nil
+	}
+
+	trusted, err := ParseCIDRs(cfg.TrustedProxies)
+	if err != nil {
+		ln.Close()
+		return nil, err
+	}
+
+	return NewProxyListener(ln, trusted), nil
+}
+
+// ParseCIDRs parses a list of CIDRs; bare IP addresses are treated as single-host networks
+func ParseCIDRs(values []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", value)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", value, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// containsIP reports whether the IP is inside any of the networks
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
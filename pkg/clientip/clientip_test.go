@@ -0,0 +1,110 @@
+package clientip
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newEngine := func(cfg Config) *gin.Engine {
+		engine := gin.New()
+		require.NoError(t, Configure(engine, cfg))
+		engine.GET("/ip", func(c *gin.Context) {
+			c.String(http.StatusOK, c.ClientIP())
+		})
+		return engine
+	}
+
+	request := func(engine *gin.Engine, remoteAddr string, headers map[string]string) string {
+		req := httptest.NewRequest("GET", "/ip", nil)
+		req.RemoteAddr = remoteAddr
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	t.Run("NoTrustedProxiesIgnoresHeaders", func(t *testing.T) {
+		engine := newEngine(Config{})
+		ip := request(engine, "203.0.113.7:1234", map[string]string{"X-Forwarded-For": "1.2.3.4"})
+		assert.Equal(t, "203.0.113.7", ip)
+	})
+
+	t.Run("TrustedProxyHeaderHonored", func(t *testing.T) {
+		engine := newEngine(Config{TrustedProxies: []string{"10.0.0.0/8"}, Header: HeaderXForwardedFor})
+		ip := request(engine, "10.1.2.3:1234", map[string]string{"X-Forwarded-For": "1.2.3.4"})
+		assert.Equal(t, "1.2.3.4", ip)
+	})
+
+	t.Run("UntrustedPeerCannotSpoof", func(t *testing.T) {
+		engine := newEngine(Config{TrustedProxies: []string{"10.0.0.0/8"}, Header: HeaderXForwardedFor})
+		ip := request(engine, "203.0.113.7:1234", map[string]string{"X-Forwarded-For": "1.2.3.4"})
+		assert.Equal(t, "203.0.113.7", ip)
+	})
+
+	t.Run("OnlyConfiguredHeaderHonored", func(t *testing.T) {
+		engine := newEngine(Config{TrustedProxies: []string{"10.0.0.1"}, Header: HeaderCFConnectingIP})
+		ip := request(engine, "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "1.2.3.4"})
+		assert.Equal(t, "10.0.0.1", ip)
+
+		ip = request(engine, "10.0.0.1:1234", map[string]string{"CF-Connecting-IP": "5.6.7.8"})
+		assert.Equal(t, "5.6.7.8", ip)
+	})
+
+	t.Run("InvalidConfig", func(t *testing.T) {
+		assert.Error(t, Config{Header: "X-Custom"}.Validate())
+		assert.Error(t, Config{Header: HeaderXRealIP}.Validate())
+		assert.Error(t, Config{TrustedProxies: []string{"not-an-ip"}}.Validate())
+	})
+}
+
+func TestReadProxyHeader(t *testing.T) {
+	t.Run("V1TCP4", func(t *testing.T) {
+		r := bufio.NewReader(bytes.NewBufferString("PROXY TCP4 1.2.3.4 5.6.7.8 4321 443\r\nGET / HTTP/1.1\r\n"))
+		addr, err := readProxyHeader(r)
+		require.NoError(t, err)
+		assert.Equal(t, "1.2.3.4:4321", addr.String())
+
+		rest, _ := r.ReadString('\n')
+		assert.Equal(t, "GET / HTTP/1.1\r\n", rest)
+	})
+
+	t.Run("V1Unknown", func(t *testing.T) {
+		addr, err := readProxyHeader(bufio.NewReader(bytes.NewBufferString("PROXY UNKNOWN\r\n")))
+		require.NoError(t, err)
+		assert.Nil(t, addr)
+	})
+
+	t.Run("V2TCP4", func(t *testing.T) {
+		var buf bytes.Buffer
+		buf.Write(proxyV2Signature)
+		buf.Write([]byte{0x21, 0x11})
+		binary.Write(&buf, binary.BigEndian, uint16(12))
+		buf.Write(net.ParseIP("9.8.7.6").To4())
+		buf.Write(net.ParseIP("1.1.1.1").To4())
+		binary.Write(&buf, binary.BigEndian, uint16(5555))
+		binary.Write(&buf, binary.BigEndian, uint16(443))
+
+		addr, err := readProxyHeader(bufio.NewReader(&buf))
+		require.NoError(t, err)
+		assert.Equal(t, "9.8.7.6:5555", addr.String())
+	})
+
+	t.Run("MissingHeader", func(t *testing.T) {
+		_, err := readProxyHeader(bufio.NewReader(bytes.NewBufferString("GET / HTTP/1.1\r\n\r\n")))
+		assert.ErrorIs(t, err, ErrInvalidProxyHeader)
+	})
+}
@@ -0,0 +1,213 @@
+package clientip
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PROXY protocol constants
+const (
+	proxyV1Prefix    = "PROXY "
+	proxyV1MaxLength = 107
+	proxyV2HeaderLen = 16
+)
+
+// proxyV2Signature is the fixed 12-byte preamble of a PROXY protocol v2 header
+var proxyV2Signature = []byte{0x0D, 0x0A, 0x0D, 0x0A, 0x00, 0x0D, 0x0A, 0x51, 0x55, 0x49, 0x54, 0x0A}
+
+// ErrInvalidProxyHeader is returned when a trusted peer sends a malformed PROXY header
+var ErrInvalidProxyHeader = errors.New("invalid PROXY protocol header")
+
+// ProxyListener wraps a listener and reads PROXY protocol headers from trusted peers.
+// Connections from untrusted peers are passed through unchanged, so they cannot spoof
+// their address by sending a PROXY header.
+type ProxyListener struct {
+	net.Listener
+	trusted       []*net.IPNet
+	HeaderTimeout time.Duration
+}
+
+// NewProxyListener creates a new PROXY protocol listener trusting the given networks
+func NewProxyListener(inner net.Listener, trusted []*net.IPNet) *ProxyListener {
+	return &ProxyListener{
+		Listener:      inner,
+		trusted:       trusted,
+		HeaderTimeout: 5 * time.Second,
+	}
+}
+
+// Accept waits for the next connection and wraps it when the peer is a trusted proxy
+func (l *ProxyListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	tcpAddr, ok := conn.RemoteAddr().(*net.TCPAddr)
+	if !ok || !containsIP(l.trusted, tcpAddr.IP) {
+		return conn, nil
+	}
+
+	return &proxyConn{
+		Conn:    conn,
+		reader:  bufio.NewReader(conn),
+		timeout: l.HeaderTimeout,
+	}, nil
+}
+
+// proxyConn lazily parses the PROXY header on first Read or RemoteAddr call.
+// Parsing is deferred so a slow proxy cannot block the accept loop.
+type proxyConn struct {
+	net.Conn
+	reader  *bufio.Reader
+	timeout time.Duration
+	once    sync.Once
+	remote  net.Addr
+	err     error
+}
+
+// init reads the PROXY header exactly once
+func (c *proxyConn) init() {
+	c.once.Do(func() {
+		if c.timeout > 0 {
+			c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+			defer c.Conn.SetReadDeadline(time.Time{})
+		}
+		c.remote, c.err = readProxyHeader(c.reader)
+	})
+}
+
+// Read reads from the connection after the PROXY header
+func (c *proxyConn) Read(b []byte) (int, error) {
+	c.init()
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.reader.Read(b)
+}
+
+// RemoteAddr returns the client address announced by the proxy
+func (c *proxyConn) RemoteAddr() net.Addr {
+	c.init()
+	if c.remote != nil {
+		return c.remote
+	}
+	return c.Conn.RemoteAddr()
+}
+
+// readProxyHeader parses a v1 or v2 PROXY header. A nil address with nil error means
+// the proxy sent a LOCAL/UNKNOWN header and the peer address should be used.
+func readProxyHeader(r *bufio.Reader) (net.Addr, error) {
+	prefix, err := r.Peek(len(proxyV1Prefix))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProxyHeader, err)
+	}
+
+	if string(prefix) == proxyV1Prefix {
+		return readProxyV1(r)
+	}
+
+	signature, err := r.Peek(len(proxyV2Signature))
+	if err != nil || !bytes.Equal(signature, proxyV2Signature) {
+		return nil, fmt.Errorf("%w: missing PROXY preamble", ErrInvalidProxyHeader)
+	}
+
+	return readProxyV2(r)
+}
+
+// readProxyV1 parses the human-readable v1 header, e.g. "PROXY TCP4 1.2.3.4 5.6.7.8 1234 443\r\n"
+func readProxyV1(r *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for len(line) < proxyV1MaxLength {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidProxyHeader, err)
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, fmt.Errorf("%w: v1 header not terminated", ErrInvalidProxyHeader)
+	}
+
+	fields := strings.Fields(string(line[:len(line)-2]))
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, fmt.Errorf("%w: malformed v1 header", ErrInvalidProxyHeader)
+	}
+
+	ip := net.ParseIP(fields[2])
+	port, err := strconv.Atoi(fields[4])
+	if ip == nil || err != nil || port < 0 || port > 65535 {
+		return nil, fmt.Errorf("%w: invalid v1 source address", ErrInvalidProxyHeader)
+	}
+
+	return &net.TCPAddr{IP: ip, Port: port}, nil
+}
+
+// readProxyV2 parses the binary v2 header
+func readProxyV2(r *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, proxyV2HeaderLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProxyHeader, err)
+	}
+
+	version := header[12] >> 4
+	command := header[12] & 0x0F
+	family := header[13] >> 4
+	length := int(binary.BigEndian.Uint16(header[14:16]))
+
+	if version != 2 {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidProxyHeader, version)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProxyHeader, err)
+	}
+
+	// LOCAL command: health checks from the proxy itself
+	if command == 0x0 {
+		return nil, nil
+	}
+	if command != 0x1 {
+		return nil, fmt.Errorf("%w: unsupported command %d", ErrInvalidProxyHeader, command)
+	}
+
+	switch family {
+	case 0x1: // AF_INET
+		if length < 12 {
+			return nil, fmt.Errorf("%w: short IPv4 address block", ErrInvalidProxyHeader)
+		}
+		return &net.TCPAddr{
+			IP:   net.IP(payload[0:4]),
+			Port: int(binary.BigEndian.Uint16(payload[8:10])),
+		}, nil
+	case 0x2: // AF_INET6
+		if length < 36 {
+			return nil, fmt.Errorf("%w: short IPv6 address block", ErrInvalidProxyHeader)
+		}
+		return &net.TCPAddr{
+			IP:   net.IP(payload[0:16]),
+			Port: int(binary.BigEndian.Uint16(payload[32:34])),
+		}, nil
+	default:
+		// AF_UNSPEC or AF_UNIX: keep the peer address
+		return nil, nil
+	}
+}
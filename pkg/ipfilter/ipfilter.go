@@ -0,0 +1,250 @@
+package ipfilter
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"solana-balance-api/pkg/clientip"
+)
+
+// Set is an immutable set of networks used for allow and deny checks
+type Set struct {
+	networks []*net.IPNet
+}
+
+// NewSet parses a list of CIDRs (bare IPs are treated as single hosts) into a Set
+func NewSet(cidrs []string) (*Set, error) {
+	networks, err := clientip.ParseCIDRs(cidrs)
+	if err != nil {
+		return nil, err
+	}
+	return &Set{networks: networks}, nil
+}
+
+// Contains reports whether the IP is inside any network of the set
+func (s *Set) Contains(ip net.IP) bool {
+	if s == nil || ip == nil {
+		return false
+	}
+	for _, network := range s.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of networks in the set
+func (s *Set) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.networks)
+}
+
+// maxCachedAllowlists bounds the allowlist cache; it is emptied when full
+const maxCachedAllowlists = 4096
+
+// parsedAllowlist is a cached NewSet result
+type parsedAllowlist struct {
+	set *Set
+	err error
+}
+
+// allowlists caches parsed allowlists by their entries, so each key's allowlist
+// is parsed once rather than on every request
+var allowlists = struct {
+	sets  map[string]parsedAllowlist
+	mutex sync.RWMutex
+}{sets: make(map[string]parsedAllowlist)}
+
+// IsAllowed checks an IP against an allowlist. An empty allowlist allows every IP.
+// Invalid allowlist entries fail closed and are reported as an error.
+func IsAllowed(allowlist []string, ip string) (bool, error) {
+	if len(allowlist) == 0 {
+		return true, nil
+	}
+
+	parsed := parseAllowlist(allowlist)
+	if parsed.err != nil {
+		return false, parsed.err
+	}
+
+	return parsed.set.Contains(net.ParseIP(ip)), nil
+}
+
+// parseAllowlist returns the cached Set for an allowlist, parsing it on first use
+func parseAllowlist(allowlist []string) parsedAllowlist {
+	// Entries cannot contain newlines, so joining them is an unambiguous key
+	key := strings.Join(allowlist, "\n")
+
+	allowlists.mutex.RLock()
+	parsed, ok := allowlists.sets[key]
+	allowlists.mutex.RUnlock()
+	if ok {
+		return parsed
+	}
+
+	parsed.set, parsed.err = NewSet(allowlist)
+
+	allowlists.mutex.Lock()
+	if len(allowlists.sets) >= maxCachedAllowlists {
+		allowlists.sets = make(map[string]parsedAllowlist)
+	}
+	allowlists.sets[key] = parsed
+	allowlists.mutex.Unlock()
+
+	return parsed
+}
+
+// Denylist is a hot-reloadable global IP denylist built from static CIDRs and an optional file
+type Denylist struct {
+	set     *Set
+	static  []string
+	file    string
+	modTime time.Time
+	mutex   sync.RWMutex
+}
+
+// NewDenylist creates a denylist from static CIDRs and an optional file with one CIDR per line
+func NewDenylist(static []string, file string) (*Denylist, error) {
+	d := &Denylist{
+		static: static,
+		file:   file,
+	}
+
+	if err := d.Reload(); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// IsDenied reports whether the IP is on the denylist
+func (d *Denylist) IsDenied(ip string) bool {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return d.set.Contains(net.ParseIP(ip))
+}
+
+// Size returns the number of networks on the denylist
+func (d *Denylist) Size() int {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return d.set.Len()
+}
+
+// Update replaces the static CIDRs and rebuilds the denylist
+func (d *Denylist) Update(static []string) error {
+	d.mutex.Lock()
+	d.static = static
+	d.mutex.Unlock()
+
+	return d.Reload()
+}
+
+// Reload re-reads the denylist file and rebuilds the set.
+// The previous set stays active if the new configuration is invalid.
+func (d *Denylist) Reload() error {
+	d.mutex.RLock()
+	cidrs := append([]string{}, d.static...)
+	file := d.file
+	d.mutex.RUnlock()
+
+	var modTime time.Time
+	if file != "" {
+		info, err := os.Stat(file)
+		if err != nil {
+			return fmt.Errorf("failed to stat denylist file: %w", err)
+		}
+		modTime = info.ModTime()
+
+		fileCIDRs, err := readCIDRFile(file)
+		if err != nil {
+			return err
+		}
+		cidrs = append(cidrs, fileCIDRs...)
+	}
+
+	set, err := NewSet(cidrs)
+	if err != nil {
+		return fmt.Errorf("invalid denylist: %w", err)
+	}
+
+	d.mutex.Lock()
+	d.set = set
+	d.modTime = modTime
+	d.mutex.Unlock()
+
+	return nil
+}
+
+// Watch polls the denylist file and reloads it when it changes, until stopCh is closed.
+// Reload errors are passed to onError and the previous denylist is kept.
+func (d *Denylist) Watch(interval time.Duration, stopCh <-chan struct{}, onError func(error)) {
+	if d.file == "" || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			info, err := os.Stat(d.file)
+			if err != nil {
+				if onError != nil {
+					onError(err)
+				}
+				continue
+			}
+
+			d.mutex.RLock()
+			changed := !info.ModTime().Equal(d.modTime)
+			d.mutex.RUnlock()
+
+			if changed {
+				if err := d.Reload(); err != nil && onError != nil {
+					onError(err)
+				}
+			}
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// readCIDRFile reads one CIDR per line, ignoring blank lines and # comments
+func readCIDRFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open denylist file: %w", err)
+	}
+	defer file.Close()
+
+	var cidrs []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			cidrs = append(cidrs, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read denylist file: %w", err)
+	}
+
+	return cidrs, nil
+}
@@ -0,0 +1,91 @@
+package ipfilter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsAllowed(t *testing.T) {
+	allowed, err := IsAllowed(nil, "203.0.113.7")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowlist := []string{"10.0.0.0/8", "2001:db8::1"}
+	for ip, want := range map[string]bool{
+		"10.1.2.3":    true,
+		"2001:db8::1": true,
+		"2001:db8::2": false,
+		"203.0.113.7": false,
+		"not-an-ip":   false,
+	} {
+		allowed, err := IsAllowed(allowlist, ip)
+		require.NoError(t, err)
+		assert.Equal(t, want, allowed, ip)
+	}
+
+	// Parsed allowlists are reused
+	first := parseAllowlist(allowlist)
+	assert.Same(t, first.set, parseAllowlist([]string{"10.0.0.0/8", "2001:db8::1"}).set)
+
+	// Invalid entries fail closed, also once cached
+	for i := 0; i < 2; i++ {
+		allowed, err = IsAllowed([]string{"10.0.0.0/8", "bogus"}, "10.1.2.3")
+		assert.Error(t, err)
+		assert.False(t, allowed)
+	}
+}
+
+func TestDenylist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "denylist")
+	require.NoError(t, os.WriteFile(path, []byte("# abuse\n198.51.100.0/24\n\n192.0.2.1 # scanner\n"), 0o600))
+
+	denylist, err := NewDenylist([]string{"10.0.0.0/8"}, path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, denylist.Size())
+	assert.True(t, denylist.IsDenied("10.9.9.9"))
+	assert.True(t, denylist.IsDenied("198.51.100.20"))
+	assert.True(t, denylist.IsDenied("192.0.2.1"))
+	assert.False(t, denylist.IsDenied("192.0.2.2"))
+
+	t.Run("InvalidReloadKeepsPreviousList", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte("bogus\n"), 0o600))
+		assert.Error(t, denylist.Reload())
+		assert.True(t, denylist.IsDenied("198.51.100.20"))
+
+		assert.Error(t, denylist.Update([]string{"bogus"}))
+		assert.True(t, denylist.IsDenied("10.9.9.9"))
+	})
+
+	t.Run("WatchReloadsUntilStopped", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte("203.0.113.0/24\n"), 0o600))
+		require.NoError(t, denylist.Update(nil))
+		assert.Equal(t, 1, denylist.Size())
+
+		stopCh := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			denylist.Watch(10*time.Millisecond, stopCh, nil)
+			close(done)
+		}()
+
+		// Bump the modification time so the change is seen on coarse clocks
+		require.NoError(t, os.WriteFile(path, []byte("192.0.2.0/24\n"), 0o600))
+		later := time.Now().Add(time.Second)
+		require.NoError(t, os.Chtimes(path, later, later))
+
+		assert.Eventually(t, func() bool { return denylist.IsDenied("192.0.2.9") }, time.Second, 10*time.Millisecond)
+		assert.False(t, denylist.IsDenied("203.0.113.1"))
+
+		close(stopCh)
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("Watch did not return after stopCh was closed")
+		}
+	})
+}
@@ -0,0 +1,319 @@
+package jwks
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrKeyNotFound is returned when no key matches the requested key ID
+var ErrKeyNotFound = errors.New("signing key not found")
+
+// JWK represents a single JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// Document represents a JWK Set document
+type Document struct {
+	Keys []JWK `json:"keys"`
+}
+
+// KeySet holds public keys loaded from a JWKS URL or file, refreshed periodically
+type KeySet struct {
+	keys        map[string]crypto.PublicKey
+	local       map[string]crypto.PublicKey // Added with Add; kept across refreshes
+	mutex       sync.RWMutex
+	url         string
+	file        string
+	client      *http.Client
+	minInterval time.Duration
+	lastRefresh time.Time
+}
+
+// New creates a KeySet from a JWKS URL or local file and performs the initial load.
+// Either source may be empty, in which case the set starts empty.
+func New(url, file string) (*KeySet, error) {
+	ks := &KeySet{
+		keys:        make(map[string]crypto.PublicKey),
+		local:       make(map[string]crypto.PublicKey),
+		url:         url,
+		file:        file,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		minInterval: 30 * time.Second,
+	}
+
+	if url != "" || file != "" {
+		if err := ks.Refresh(); err != nil {
+			return nil, err
+		}
+	}
+
+	return ks, nil
+}
+
+// Key returns the public key for the key ID. On a miss the set is refreshed
+// (at most once per minimum interval) to pick up rotated keys.
+func (ks *KeySet) Key(kid string) (crypto.PublicKey, error) {
+	ks.mutex.RLock()
+	key, exists := ks.keys[kid]
+	canRefresh := (ks.url != "" || ks.file != "") && time.Since(ks.lastRefresh) > ks.minInterval
+	ks.mutex.RUnlock()
+
+	if exists {
+		return key, nil
+	}
+
+	if canRefresh {
+		if err := ks.Refresh(); err != nil {
+			return nil, err
+		}
+
+		ks.mutex.RLock()
+		key, exists = ks.keys[kid]
+		ks.mutex.RUnlock()
+
+		if exists {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: kid %q", ErrKeyNotFound, kid)
+}
+
+// Add registers a public key under the key ID (used for locally issued tokens)
+func (ks *KeySet) Add(kid string, key crypto.PublicKey) {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	ks.local[kid] = key
+	ks.keys[kid] = key
+}
+
+// Size returns the number of keys in the set
+func (ks *KeySet) Size() int {
+	ks.mutex.RLock()
+	defer ks.mutex.RUnlock()
+
+	return len(ks.keys)
+}
+
+// Refresh replaces the keys with those from the configured source, keeping locally
+// added keys. Keys removed from the source are no longer trusted.
+func (ks *KeySet) Refresh() error {
+	data, err := ks.fetch()
+	if err != nil {
+		return err
+	}
+
+	keys, err := Parse(data)
+	if err != nil {
+		return err
+	}
+
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	for kid, key := range ks.local {
+		keys[kid] = key
+	}
+	ks.keys = keys
+	ks.lastRefresh = time.Now()
+
+	return nil
+}
+
+// StartRefresh refreshes the key set on the given interval until stopCh is closed
+func (ks *KeySet) StartRefresh(interval time.Duration, stopCh <-chan struct{}, onError func(error)) {
+	if (ks.url == "" && ks.file == "") || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := ks.Refresh(); err != nil && onError != nil {
+				onError(err)
+			}
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// fetch reads the raw JWKS document from the URL or file
+func (ks *KeySet) fetch() ([]byte, error) {
+	if ks.file != "" {
+		data, err := os.ReadFile(ks.file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+		}
+		return data, nil
+	}
+
+	resp, err := ks.client.Get(ks.url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: unexpected status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS response: %w", err)
+	}
+	return data, nil
+}
+
+// Parse decodes a JWK Set document into public keys indexed by key ID.
+// Keys with unsupported types or non-signature use are skipped.
+func Parse(data []byte) (map[string]crypto.PublicKey, error) {
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid JWKS document: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(doc.Keys))
+	for _, jwk := range doc.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.PublicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	return keys, nil
+}
+
+// PublicKey converts the JWK into a crypto public key
+func (j JWK) PublicKey() (crypto.PublicKey, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := decodeBigInt(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(j.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported EC curve %q", j.Crv)
+		}
+		x, err := decodeBigInt(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported OKP curve %q", j.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", j.Kty)
+	}
+}
+
+// FromPublicKey builds a JWK for a public key, deriving the key ID from its thumbprint
+func FromPublicKey(key crypto.PublicKey) (JWK, error) {
+	var jwk JWK
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		jwk = JWK{
+			Kty: "RSA",
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		jwk = JWK{
+			Kty: "EC",
+			Crv: k.Curve.Params().Name,
+			X:   base64.RawURLEncoding.EncodeToString(k.X.FillBytes(make([]byte, size))),
+			Y:   base64.RawURLEncoding.EncodeToString(k.Y.FillBytes(make([]byte, size))),
+		}
+		if jwk.Crv == "P-256" {
+			jwk.Alg = "ES256"
+		}
+	case ed25519.PublicKey:
+		jwk = JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			Alg: "EdDSA",
+			X:   base64.RawURLEncoding.EncodeToString(k),
+		}
+	default:
+		return JWK{}, fmt.Errorf("unsupported public key type %T", key)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return JWK{}, err
+	}
+	sum := sha256.Sum256(der)
+	jwk.Kid = base64.RawURLEncoding.EncodeToString(sum[:12])
+	jwk.Use = "sig"
+
+	return jwk, nil
+}
+
+// decodeBigInt decodes a base64url-encoded big-endian integer
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64url value: %w", err)
+	}
+	return new(big.Int).SetBytes(data), nil
+}
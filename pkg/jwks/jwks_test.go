@@ -0,0 +1,130 @@
+package jwks
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jwksServer serves a mutable JWK Set and counts fetches
+type jwksServer struct {
+	*httptest.Server
+	fetches atomic.Int32
+	mutex   sync.Mutex
+	body    []byte
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	s := &jwksServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		w.Write(s.body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) serve(t *testing.T, jwks ...JWK) {
+	body, err := json.Marshal(Document{Keys: jwks})
+	require.NoError(t, err)
+	s.setBody(body)
+}
+
+func (s *jwksServer) setBody(body []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.body = body
+}
+
+func newJWK(t *testing.T) JWK {
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	jwk, err := FromPublicKey(public)
+	require.NoError(t, err)
+	return jwk
+}
+
+func TestKeySetRefresh(t *testing.T) {
+	server := newJWKSServer(t)
+	first, second := newJWK(t), newJWK(t)
+	server.serve(t, first, second)
+
+	ks, err := New(server.URL, "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, ks.Size())
+
+	local, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	ks.Add("local", local)
+
+	t.Run("RemovesRevokedKeys", func(t *testing.T) {
+		server.serve(t, second)
+		require.NoError(t, ks.Refresh())
+
+		_, err := ks.Key(first.Kid)
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+		_, err = ks.Key(second.Kid)
+		assert.NoError(t, err)
+
+		// Locally added keys survive refreshes
+		key, err := ks.Key("local")
+		require.NoError(t, err)
+		assert.Equal(t, local, key)
+		assert.Equal(t, 2, ks.Size())
+	})
+
+	t.Run("FetchesUnknownKid", func(t *testing.T) {
+		rotated := newJWK(t)
+		server.serve(t, second, rotated)
+
+		// Misses within the minimum interval do not refetch
+		fetches := server.fetches.Load()
+		_, err := ks.Key(rotated.Kid)
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+		assert.Equal(t, fetches, server.fetches.Load())
+
+		ks.minInterval = 0
+		_, err = ks.Key(rotated.Kid)
+		assert.NoError(t, err)
+		assert.Equal(t, fetches+1, server.fetches.Load())
+
+		_, err = ks.Key("unknown")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	})
+
+	t.Run("ParseErrorKeepsKeys", func(t *testing.T) {
+		server.setBody([]byte(`{"keys": [`))
+		assert.Error(t, ks.Refresh())
+
+		_, err := ks.Key(second.Kid)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, ks.Size())
+	})
+}
+
+func TestParse(t *testing.T) {
+	jwk := newJWK(t)
+	encryption := newJWK(t)
+	encryption.Use = "enc"
+
+	data, err := json.Marshal(Document{Keys: []JWK{jwk, encryption, {Kty: "oct", Kid: "symmetric"}}})
+	require.NoError(t, err)
+
+	keys, err := Parse(data)
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+	assert.Contains(t, keys, jwk.Kid)
+
+	_, err = Parse([]byte("not json"))
+	assert.Error(t, err)
+}
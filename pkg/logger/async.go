@@ -0,0 +1,207 @@
+package logger
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Drop policies applied when an async writer's buffer is full
+const (
+	DropNewest = "drop_newest" // Discard the entry being written
+	DropOldest = "drop_oldest" // Discard the oldest buffered entry to make room
+	DropNone   = "block"       // Wait for room; logging calls slow down instead of losing entries
+)
+
+// DropPolicies lists every valid drop policy
+var DropPolicies = []string{DropNewest, DropOldest, DropNone}
+
+// Async writer defaults
+const (
+	DefaultAsyncBufferSize    = 1024
+	DefaultAsyncFlushInterval = time.Second
+)
+
+// AsyncConfig configures buffered, asynchronous writes to a sink
+type AsyncConfig struct {
+	Enabled       bool
+	BufferSize    int           // Entries buffered before the drop policy applies
+	FlushInterval time.Duration // How often the underlying writer is synced
+	DropPolicy    string
+}
+
+// asyncDropped counts entries dropped by every async writer
+var asyncDropped int64
+
+// AsyncDropped returns the number of log entries dropped because a buffer was full
+func AsyncDropped() int64 {
+	return atomic.LoadInt64(&asyncDropped)
+}
+
+// AsyncWriter buffers writes in memory and writes them to the underlying writer on
+// a background goroutine. Sync waits until every buffered entry has been written.
+// After Close, writes go directly to the underlying writer so late log entries,
+// e.g. from shutdown code, are not lost.
+type AsyncWriter struct {
+	out    zapcore.WriteSyncer
+	policy string
+	queue  chan []byte
+	syncCh chan chan error
+	stopCh chan struct{}
+	done   chan struct{}
+
+	mutex     sync.RWMutex // Held for writing while closing
+	closed    bool
+	closeOnce sync.Once
+	dropped   int64
+}
+
+// NewAsyncWriter starts an async writer in front of out
+func NewAsyncWriter(out zapcore.WriteSyncer, cfg AsyncConfig) *AsyncWriter {
+	bufferSize := cfg.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = DefaultAsyncBufferSize
+	}
+	flushInterval := cfg.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = DefaultAsyncFlushInterval
+	}
+	policy := cfg.DropPolicy
+	if policy == "" {
+		policy = DropNewest
+	}
+
+	w := &AsyncWriter{
+		out:    out,
+		policy: policy,
+		queue:  make(chan []byte, bufferSize),
+		syncCh: make(chan chan error),
+		stopCh: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go w.run(flushInterval)
+	return w
+}
+
+// Write buffers a copy of p, applying the drop policy when the buffer is full.
+// Dropped entries are not reported as errors.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	if w.closed {
+		return w.out.Write(p)
+	}
+
+	entry := make([]byte, len(p))
+	copy(entry, p)
+
+	switch w.policy {
+	case DropNone:
+		w.queue <- entry
+	case DropOldest:
+		for {
+			select {
+			case w.queue <- entry:
+				return len(p), nil
+			default:
+			}
+			select {
+			case <-w.queue:
+				w.drop()
+			default:
+			}
+		}
+	default:
+		select {
+		case w.queue <- entry:
+		default:
+			w.drop()
+		}
+	}
+	return len(p), nil
+}
+
+// drop records a dropped entry
+func (w *AsyncWriter) drop() {
+	atomic.AddInt64(&w.dropped, 1)
+	atomic.AddInt64(&asyncDropped, 1)
+}
+
+// Dropped returns the number of entries this writer dropped
+func (w *AsyncWriter) Dropped() int64 {
+	return atomic.LoadInt64(&w.dropped)
+}
+
+// Sync writes every buffered entry and syncs the underlying writer
+func (w *AsyncWriter) Sync() error {
+	w.mutex.RLock()
+	if w.closed {
+		w.mutex.RUnlock()
+		return w.out.Sync()
+	}
+
+	result := make(chan error, 1)
+	w.syncCh <- result
+	w.mutex.RUnlock()
+	return <-result
+}
+
+// Close writes every buffered entry, stops the background goroutine and closes the
+// underlying writer if it is closable
+func (w *AsyncWriter) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		w.mutex.Lock()
+		close(w.stopCh)
+		<-w.done
+		w.closed = true
+		w.mutex.Unlock()
+
+		err = w.out.Sync()
+		if closer, ok := w.out.(io.Closer); ok {
+			if closeErr := closer.Close(); closeErr != nil {
+				err = closeErr
+			}
+		}
+	})
+	return err
+}
+
+// run writes buffered entries until the writer is closed
+func (w *AsyncWriter) run(flushInterval time.Duration) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case entry := <-w.queue:
+			_, _ = w.out.Write(entry)
+		case result := <-w.syncCh:
+			w.drain()
+			result <- w.out.Sync()
+		case <-ticker.C:
+			_ = w.out.Sync()
+		case <-w.stopCh:
+			w.drain()
+			return
+		}
+	}
+}
+
+// drain writes every entry currently buffered
+func (w *AsyncWriter) drain() {
+	for {
+		select {
+		case entry := <-w.queue:
+			_, _ = w.out.Write(entry)
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Components whose level can be overridden at runtime. Loggers are assigned to a
+// component by their "component" field.
+const (
+	ComponentBalanceService = "balance_service"
+	ComponentAuth           = "auth"
+	ComponentRPC            = "rpc"
+)
+
+// Components lists every component with a level override
+var Components = []string{ComponentBalanceService, ComponentAuth, ComponentRPC}
+
+// ErrUnknownComponent is returned when overriding the level of an unknown component
+var ErrUnknownComponent = errors.New("unknown log component")
+
+// LevelSetting is a level and, for temporary changes, when and to what it reverts
+type LevelSetting struct {
+	Level     string     `json:"level"`
+	RevertsAt *time.Time `json:"reverts_at,omitempty"`
+	RevertsTo string     `json:"reverts_to,omitempty"` // Empty when a component override is removed on revert
+}
+
+// LevelState describes the global level and the per-component overrides
+type LevelState struct {
+	LevelSetting
+	Components map[string]LevelSetting `json:"components"`
+}
+
+// levelRevert is a pending restore of a level changed temporarily
+type levelRevert struct {
+	timer *time.Timer
+	at    time.Time
+	to    *zapcore.Level // Nil removes a component override
+}
+
+// levelController holds the global level and the per-component overrides.
+// Overrides are read on every log call, so they are replaced as a whole rather
+// than modified in place.
+type levelController struct {
+	global     zap.AtomicLevel
+	components atomic.Value            // map[string]zapcore.Level
+	reverts    map[string]*levelRevert // Keyed by component; "" is the global level
+	mutex      sync.Mutex              // Serializes changes
+}
+
+// newLevelController creates a controller at the info level without overrides
+func newLevelController() *levelController {
+	c := &levelController{
+		global:  zap.NewAtomicLevel(),
+		reverts: make(map[string]*levelRevert),
+	}
+	c.components.Store(map[string]zapcore.Level{})
+	return c
+}
+
+// levels controls the level of the global logger and every logger derived from it
+var levels = newLevelController()
+
+// level returns the effective level for component
+func (c *levelController) level(component string) zapcore.Level {
+	if component != "" {
+		if override, ok := c.components.Load().(map[string]zapcore.Level)[component]; ok {
+			return override
+		}
+	}
+	return c.global.Level()
+}
+
+// override returns the level set for component: its override, or the global level
+// for "". Nil means component has no override.
+func (c *levelController) override(component string) *zapcore.Level {
+	if component == "" {
+		level := c.global.Level()
+		return &level
+	}
+	if override, ok := c.components.Load().(map[string]zapcore.Level)[component]; ok {
+		return &override
+	}
+	return nil
+}
+
+// set changes the level of component, "" being the global level; a nil level
+// removes a component override. With a positive ttl the previous level is restored
+// once it expires; otherwise the change is permanent and cancels a pending revert.
+func (c *levelController) set(component string, level *zapcore.Level, ttl time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	previous := c.override(component)
+	if pending := c.reverts[component]; pending != nil {
+		pending.timer.Stop()
+		delete(c.reverts, component)
+		// Extending a temporary change still reverts to the level from before it
+		previous = pending.to
+	}
+
+	c.apply(component, level)
+	if ttl > 0 {
+		revert := &levelRevert{at: time.Now().Add(ttl), to: previous}
+		revert.timer = time.AfterFunc(ttl, func() { c.revert(component, revert) })
+		c.reverts[component] = revert
+	}
+}
+
+// revert restores the level from before a temporary change, unless the change has
+// since been replaced
+func (c *levelController) revert(component string, revert *levelRevert) {
+	c.mutex.Lock()
+	if c.reverts[component] != revert {
+		c.mutex.Unlock()
+		return
+	}
+	delete(c.reverts, component)
+	c.apply(component, revert.to)
+	c.mutex.Unlock()
+
+	restored := "unset"
+	if revert.to != nil {
+		restored = revert.to.String()
+	}
+	GetLogger().Info("Log level reverted",
+		zap.String("log_component", component),
+		zap.String("log_level", restored),
+	)
+}
+
+// apply sets a level without touching pending reverts; the caller holds the mutex
+func (c *levelController) apply(component string, level *zapcore.Level) {
+	if component == "" {
+		if level != nil {
+			c.global.SetLevel(*level)
+		}
+		return
+	}
+
+	current := c.components.Load().(map[string]zapcore.Level)
+	updated := make(map[string]zapcore.Level, len(current)+1)
+	for name, override := range current {
+		updated[name] = override
+	}
+	if level != nil {
+		updated[component] = *level
+	} else {
+		delete(updated, component)
+	}
+	c.components.Store(updated)
+}
+
+// reset sets the global level, removing every override and pending revert
+func (c *levelController) reset(level zapcore.Level) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for component, pending := range c.reverts {
+		pending.timer.Stop()
+		delete(c.reverts, component)
+	}
+	c.components.Store(map[string]zapcore.Level{})
+	c.global.SetLevel(level)
+}
+
+// state describes the current levels
+func (c *levelController) state() LevelState {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	setting := func(component string, level zapcore.Level) LevelSetting {
+		result := LevelSetting{Level: level.String()}
+		if pending := c.reverts[component]; pending != nil {
+			at := pending.at
+			result.RevertsAt = &at
+			if pending.to != nil {
+				result.RevertsTo = pending.to.String()
+			}
+		}
+		return result
+	}
+
+	overrides := c.components.Load().(map[string]zapcore.Level)
+	state := LevelState{
+		LevelSetting: setting("", c.global.Level()),
+		Components:   make(map[string]LevelSetting, len(overrides)),
+	}
+	for component, level := range overrides {
+		state.Components[component] = setting(component, level)
+	}
+	return state
+}
+
+// parseLevel parses a level name such as "debug"
+func parseLevel(level string) (zapcore.Level, error) {
+	parsed, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return parsed, fmt.Errorf("invalid log level: %w", err)
+	}
+	return parsed, nil
+}
+
+// validComponent returns an error unless component has a level override
+func validComponent(component string) error {
+	for _, candidate := range Components {
+		if component == candidate {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w %q (known components: %v)", ErrUnknownComponent, component, Components)
+}
+
+// SetLevel permanently changes the level of the global logger without rebuilding it
+func SetLevel(level string) error {
+	return SetLevelFor("", level, 0)
+}
+
+// SetLevelFor changes the level of a component, or of the global logger when
+// component is empty. With a positive ttl the previous level is restored once the
+// ttl expires.
+func SetLevelFor(component, level string, ttl time.Duration) error {
+	if component != "" {
+		if err := validComponent(component); err != nil {
+			return err
+		}
+	}
+	parsed, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+	levels.set(component, &parsed, ttl)
+	return nil
+}
+
+// ResetComponentLevel removes the override of a component, which then follows the
+// global level again
+func ResetComponentLevel(component string) error {
+	if err := validComponent(component); err != nil {
+		return err
+	}
+	levels.set(component, nil, 0)
+	return nil
+}
+
+// Level returns the current level of the global logger
+func Level() string {
+	return levels.global.Level().String()
+}
+
+// Levels returns the global level and the component overrides
+func Levels() LevelState {
+	return levels.state()
+}
+
+// levelCore applies the global level, or the override of the logger's component,
+// in front of the sinks. Loggers become part of a component through a "component"
+// field added with With.
+type levelCore struct {
+	zapcore.Core
+	component string
+}
+
+// newLevelCore wraps core with the runtime levels
+func newLevelCore(core zapcore.Core) zapcore.Core {
+	return &levelCore{Core: core}
+}
+
+// Enabled implements zapcore.LevelEnabler
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	return level >= levels.level(c.component) && c.Core.Enabled(level)
+}
+
+// Level reports the effective minimum level, e.g. for zap.Logger.Level
+func (c *levelCore) Level() zapcore.Level {
+	return levels.level(c.component)
+}
+
+// With adds fields, picking up the component from a "component" field
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	component := c.component
+	for _, field := range fields {
+		if field.Key == "component" && field.Type == zapcore.StringType {
+			component = field.String
+		}
+	}
+	return &levelCore{Core: c.Core.With(fields), component: component}
+}
+
+// Check skips entries below the effective level
+func (c *levelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if entry.Level < levels.level(c.component) {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
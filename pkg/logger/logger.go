@@ -3,11 +3,14 @@ package logger
 import (
 	"context"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 // ContextKey represents keys used in context for logging
@@ -20,71 +23,119 @@ const (
 	RequestIDKey ContextKey = "request_id"
 	// UserIDKey is the key for user/API key ID in context
 	UserIDKey ContextKey = "user_id"
+	// TenantIDKey is the key for the tenant (organization) ID in context
+	TenantIDKey ContextKey = "tenant_id"
 )
 
+// Headers carrying request identifiers between services
+const (
+	CorrelationIDHeader = "X-Correlation-ID"
+	RequestIDHeader     = "X-Request-ID"
+)
+
+// MaxCorrelationIDLength bounds incoming correlation and request IDs
+const MaxCorrelationIDLength = 128
+
 // Logger wraps zap logger with additional functionality
 type Logger struct {
 	*zap.Logger
 	sugar *zap.SugaredLogger
+	sinks []*sink // Outputs owned by the global logger; nil for derived loggers
 }
 
 // Config represents logger configuration
 type Config struct {
-	Level       string   `json:"level" default:"info"`
-	Environment string   `json:"environment" default:"development"`
-	OutputPaths []string `json:"output_paths"`
+	Level       string       `json:"level" default:"info"`
+	Environment string       `json:"environment" default:"development"`
+	OutputPaths []string     `json:"output_paths"`
+	Sinks       []SinkConfig // Take precedence over OutputPaths when set
+	Redaction   RedactionConfig
+	Sampling    SamplingConfig
 }
 
-var (
-	// Global logger instance
-	globalLogger *Logger
-)
+// Global logger instance
+var globalLogger *Logger
 
-// Initialize sets up the global logger
+// Initialize sets up the global logger. A previously initialized global logger is
+// flushed and its outputs closed.
 func Initialize(config *Config) error {
-	var zapConfig zap.Config
-
-	// Configure based on environment
-	if config.Environment == "production" {
-		zapConfig = zap.NewProductionConfig()
-		zapConfig.DisableStacktrace = true
-	} else {
-		zapConfig = zap.NewDevelopmentConfig()
-		zapConfig.DisableStacktrace = false
-	}
-
 	// Set log level
-	level, err := zap.ParseAtomicLevel(config.Level)
+	level, err := parseLevel(config.Level)
 	if err != nil {
-		return fmt.Errorf("invalid log level: %w", err)
+		return err
 	}
-	zapConfig.Level = level
 
-	// Set output paths
-	if len(config.OutputPaths) > 0 {
-		zapConfig.OutputPaths = config.OutputPaths
+	redactor, err := NewRedactor(config.Redaction)
+	if err != nil {
+		return err
 	}
 
-	// Add custom fields
-	zapConfig.InitialFields = map[string]interface{}{
-		"service": "solana-balance-api",
-		"version": "1.0.0",
+	// Open every sink; without explicit sinks the output paths are used
+	sinkConfigs := config.Sinks
+	if len(sinkConfigs) == 0 {
+		sinkConfigs = sinksFromOutputPaths(config.OutputPaths)
+	}
+	sinks := make([]*sink, 0, len(sinkConfigs))
+	cores := make([]zapcore.Core, 0, len(sinkConfigs))
+	for _, sinkConfig := range sinkConfigs {
+		opened, err := openSink(sinkConfig, config.Environment)
+		if err != nil {
+			closeSinks(sinks)
+			return err
+		}
+		sinks = append(sinks, opened)
+		// Redaction wraps each sink so every sink still applies its own level
+		cores = append(cores, newRedactCore(opened.core, redactor))
 	}
 
-	// Build logger
-	zapLogger, err := zapConfig.Build()
-	if err != nil {
-		return fmt.Errorf("failed to build logger: %w", err)
+	// Runtime levels apply before sampling, so only logged entries are sampled
+	core := newLevelCore(newSamplingCore(zapcore.NewTee(cores...), config.Sampling))
+
+	options := []zap.Option{
+		zap.AddCaller(),
+		zap.ErrorOutput(zapcore.Lock(os.Stderr)),
+		// Add custom fields
+		zap.Fields(
+			zap.String("service", "solana-balance-api"),
+			zap.String("version", "1.0.0"),
+		),
+	}
+	if config.Environment != "production" {
+		options = append(options, zap.Development(), zap.AddStacktrace(zapcore.WarnLevel))
 	}
+	zapLogger := zap.New(core, options...)
 
+	previous := globalLogger
+	levels.reset(level)
 	globalLogger = &Logger{
 		Logger: zapLogger,
 		sugar:  zapLogger.Sugar(),
+		sinks:  sinks,
+	}
+
+	// Loggers derived from the previous one keep working: closed async sinks
+	// write through directly
+	if previous != nil {
+		_ = previous.Close()
 	}
 
 	return nil
 }
 
+// closeSinks closes the outputs that need closing
+func closeSinks(sinks []*sink) error {
+	var firstErr error
+	for _, opened := range sinks {
+		if opened.closer == nil {
+			continue
+		}
+		if err := opened.closer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
 // GetLogger returns the global logger instance
 func GetLogger() *Logger {
 	if globalLogger == nil {
@@ -119,6 +170,19 @@ func (l *Logger) WithContext(ctx context.Context) *Logger {
 		fields = append(fields, zap.String("user_id", userID.(string)))
 	}
 
+	// Add tenant ID if present
+	if tenantID := ctx.Value(TenantIDKey); tenantID != nil {
+		fields = append(fields, zap.String("tenant_id", tenantID.(string)))
+	}
+
+	// Add trace and span IDs if the request is traced, linking logs to traces
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		fields = append(fields,
+			zap.String("trace_id", spanContext.TraceID().String()),
+			zap.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+
 	return &Logger{
 		Logger: l.Logger.With(fields...),
 		sugar:  l.Logger.With(fields...).Sugar(),
@@ -201,9 +265,14 @@ func (l *Logger) Sync() error {
 	return l.Logger.Sync()
 }
 
-// Close closes the logger
+// Close flushes buffered entries and closes the logger's file and async outputs.
+// Entries logged afterwards are still written, synchronously.
 func (l *Logger) Close() error {
-	return l.Logger.Sync()
+	syncErr := l.Logger.Sync()
+	if err := closeSinks(l.sinks); err != nil {
+		return err
+	}
+	return syncErr
 }
 
 // GenerateCorrelationID generates a new correlation ID
@@ -216,6 +285,55 @@ func GenerateRequestID() string {
 	return uuid.New().String()
 }
 
+// ValidCorrelationID reports whether an incoming correlation or request ID may be
+// reused: 1 to MaxCorrelationIDLength characters from [A-Za-z0-9._:-]. The restricted
+// alphabet keeps caller-supplied IDs from injecting content into logs or headers.
+func ValidCorrelationID(id string) bool {
+	if id == "" || len(id) > MaxCorrelationIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		switch ch := id[i]; {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9':
+		case ch == '-', ch == '_', ch == '.', ch == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// incomingIDs returns the correlation and request IDs for a request, reusing valid
+// IDs sent by the caller. A caller that only sends X-Request-ID has it used as the
+// correlation ID too, so the ID survives the hop either way.
+func incomingIDs(c *gin.Context) (correlationID, requestID string, rejected []string) {
+	incomingCorrelation := c.GetHeader(CorrelationIDHeader)
+	incomingRequest := c.GetHeader(RequestIDHeader)
+
+	if ValidCorrelationID(incomingRequest) {
+		requestID = incomingRequest
+	} else {
+		if incomingRequest != "" {
+			rejected = append(rejected, RequestIDHeader)
+		}
+		requestID = GenerateRequestID()
+	}
+
+	switch {
+	case ValidCorrelationID(incomingCorrelation):
+		correlationID = incomingCorrelation
+	case incomingCorrelation == "" && ValidCorrelationID(incomingRequest):
+		correlationID = incomingRequest
+	default:
+		if incomingCorrelation != "" {
+			rejected = append(rejected, CorrelationIDHeader)
+		}
+		correlationID = GenerateCorrelationID()
+	}
+
+	return correlationID, requestID, rejected
+}
+
 // ContextWithCorrelationID adds correlation ID to context
 func ContextWithCorrelationID(ctx context.Context, correlationID string) context.Context {
 	return context.WithValue(ctx, CorrelationIDKey, correlationID)
@@ -231,6 +349,11 @@ func ContextWithUserID(ctx context.Context, userID string) context.Context {
 	return context.WithValue(ctx, UserIDKey, userID)
 }
 
+// ContextWithTenantID adds tenant ID to context
+func ContextWithTenantID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, TenantIDKey, tenantID)
+}
+
 // GetCorrelationIDFromContext extracts correlation ID from context
 func GetCorrelationIDFromContext(ctx context.Context) string {
 	if correlationID := ctx.Value(CorrelationIDKey); correlationID != nil {
@@ -255,14 +378,21 @@ func GetUserIDFromContext(ctx context.Context) string {
 	return ""
 }
 
+// GetTenantIDFromContext extracts tenant ID from context
+func GetTenantIDFromContext(ctx context.Context) string {
+	if tenantID := ctx.Value(TenantIDKey); tenantID != nil {
+		return tenantID.(string)
+	}
+	return ""
+}
+
 // LoggingMiddleware creates a Gin middleware for structured logging with correlation IDs
 func LoggingMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 
-		// Generate correlation and request IDs
-		correlationID := GenerateCorrelationID()
-		requestID := GenerateRequestID()
+		// Reuse the caller's correlation and request IDs when valid, otherwise generate them
+		correlationID, requestID, rejected := incomingIDs(c)
 
 		// Add IDs to Gin context
 		c.Set(string(CorrelationIDKey), correlationID)
@@ -275,12 +405,20 @@ func LoggingMiddleware() gin.HandlerFunc {
 		c.Request = c.Request.WithContext(ctx)
 
 		// Add correlation ID to response headers
-		c.Header("X-Correlation-ID", correlationID)
-		c.Header("X-Request-ID", requestID)
+		c.Header(CorrelationIDHeader, correlationID)
+		c.Header(RequestIDHeader, requestID)
 
 		// Create logger with context
 		logger := GetLogger().WithContext(ctx)
 
+		// Invalid IDs are replaced; only their length is logged, never their content
+		for _, header := range rejected {
+			logger.Warn("Ignoring invalid incoming request identifier",
+				zap.String("header", header),
+				zap.Int("length", len(c.GetHeader(header))),
+			)
+		}
+
 		// Log request start
 		logger.Info("Request started",
 			zap.String("method", c.Request.Method),
@@ -358,9 +496,10 @@ func RecoveryMiddleware() gin.HandlerFunc {
 		// Return 500 error
 		c.JSON(500, gin.H{
 			"error": gin.H{
-				"code":    "INTERNAL_ERROR",
-				"message": "Internal server error",
-				"details": "An unexpected error occurred",
+				"code":      "INTERNAL_ERROR",
+				"message":   "Internal server error",
+				"details":   "An unexpected error occurred",
+				"retryable": false,
 			},
 			"timestamp":      time.Now().UTC().Format(time.RFC3339),
 			"correlation_id": GetCorrelationIDFromContext(ctx),
@@ -0,0 +1,221 @@
+package logger
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestValidCorrelationID(t *testing.T) {
+	assert.True(t, ValidCorrelationID("3f1c2d4e-5b6a-4c8d-9e0f-a1b2c3d4e5f6"))
+	assert.True(t, ValidCorrelationID("svc-a:req_42.1"))
+	assert.True(t, ValidCorrelationID(strings.Repeat("a", MaxCorrelationIDLength)))
+
+	assert.False(t, ValidCorrelationID(""))
+	assert.False(t, ValidCorrelationID(strings.Repeat("a", MaxCorrelationIDLength+1)))
+	assert.False(t, ValidCorrelationID("id with spaces"))
+	assert.False(t, ValidCorrelationID("id\nforged log line"))
+	assert.False(t, ValidCorrelationID("<script>"))
+}
+
+func TestLoggingMiddlewareIncomingIDs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var contextID string
+	engine := gin.New()
+	engine.Use(LoggingMiddleware())
+	engine.GET("/", func(c *gin.Context) {
+		contextID = GetCorrelationIDFromContext(c.Request.Context())
+	})
+
+	serve := func(headers map[string]string) http.Header {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w.Header()
+	}
+
+	t.Run("CorrelationIDReused", func(t *testing.T) {
+		headers := serve(map[string]string{CorrelationIDHeader: "upstream-123", RequestIDHeader: "hop-7"})
+		assert.Equal(t, "upstream-123", headers.Get(CorrelationIDHeader))
+		assert.Equal(t, "hop-7", headers.Get(RequestIDHeader))
+		assert.Equal(t, "upstream-123", contextID)
+	})
+
+	t.Run("RequestIDUsedAsCorrelationID", func(t *testing.T) {
+		headers := serve(map[string]string{RequestIDHeader: "hop-7"})
+		assert.Equal(t, "hop-7", headers.Get(CorrelationIDHeader))
+		assert.Equal(t, "hop-7", contextID)
+	})
+
+	t.Run("InvalidIDsReplaced", func(t *testing.T) {
+		headers := serve(map[string]string{CorrelationIDHeader: "bad id", RequestIDHeader: strings.Repeat("x", 200)})
+		assert.NotEqual(t, "bad id", headers.Get(CorrelationIDHeader))
+		assert.True(t, ValidCorrelationID(headers.Get(CorrelationIDHeader)))
+		assert.Len(t, headers.Get(RequestIDHeader), 36)
+	})
+
+	t.Run("GeneratedWhenAbsent", func(t *testing.T) {
+		headers := serve(nil)
+		assert.Len(t, headers.Get(CorrelationIDHeader), 36)
+		assert.Equal(t, headers.Get(CorrelationIDHeader), contextID)
+	})
+}
+
+func TestRedaction(t *testing.T) {
+	wallet := "9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM"
+
+	newObserved := func(t *testing.T, cfg RedactionConfig) (*zap.Logger, *observer.ObservedLogs) {
+		redactor, err := NewRedactor(cfg)
+		require.NoError(t, err)
+		core, logs := observer.New(zapcore.DebugLevel)
+		return zap.New(newRedactCore(core, redactor)), logs
+	}
+
+	t.Run("DefaultRules", func(t *testing.T) {
+		log, logs := newObserved(t, RedactionConfig{})
+		log.With(zap.String("wallet_address", wallet)).Info("request",
+			zap.Strings("wallet_addresses", []string{wallet, wallet}),
+			zap.String("auth_header_format", "Token sk_live_abcdef123456"),
+			zap.String("mongodb_uri", "mongodb://api:hunter2@db:27017"),
+			zap.String("header", "Bearer eyJhbGciOi"),
+			zap.String("endpoint", "/api/get-balance"),
+			zap.Any("error_context", map[string]interface{}{"wallet_addresses": []string{wallet}, "path": "/api"}),
+		)
+
+		fields := logs.All()[0].ContextMap()
+		assert.Equal(t, HashValue(wallet), fields["wallet_address"])
+		assert.Equal(t, []interface{}{HashValue(wallet), HashValue(wallet)}, fields["wallet_addresses"])
+		assert.Equal(t, "Token REDACTED", fields["auth_header_format"])
+		assert.Equal(t, "mongodb://api:REDACTED@db:27017", fields["mongodb_uri"])
+		assert.Equal(t, "Bearer REDACTED", fields["header"], "bearer tokens are masked in any field")
+		assert.Equal(t, "/api/get-balance", fields["endpoint"])
+		assert.Equal(t, map[string]interface{}{"wallet_addresses": []interface{}{HashValue(wallet)}, "path": "/api"}, fields["error_context"])
+	})
+
+	t.Run("ListenAddressNotRedacted", func(t *testing.T) {
+		log, logs := newObserved(t, RedactionConfig{})
+		log.Info("Starting HTTP server", zap.String("address", ":8080"))
+
+		assert.Equal(t, ":8080", logs.All()[0].ContextMap()["address"])
+	})
+
+	t.Run("ConfiguredRules", func(t *testing.T) {
+		log, logs := newObserved(t, RedactionConfig{
+			Wallets: RedactTruncate,
+			Fields:  map[string]string{"client_ip": RedactDrop, "auth_header_format": RedactNone},
+		})
+		log.Info("request",
+			zap.String("wallet_address", wallet),
+			zap.String("client_ip", "203.0.113.7"),
+			zap.String("auth_header_format", "Token abc"),
+		)
+
+		fields := logs.All()[0].ContextMap()
+		assert.Equal(t, "9WzD...AWWM", fields["wallet_address"])
+		assert.NotContains(t, fields, "client_ip")
+		assert.Equal(t, "Token abc", fields["auth_header_format"])
+	})
+
+	t.Run("InvalidAction", func(t *testing.T) {
+		_, err := NewRedactor(RedactionConfig{Fields: map[string]string{"user_id": "encrypt"}})
+		assert.Error(t, err)
+	})
+}
+
+func TestSampling(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := zap.New(newSamplingCore(core, SamplingConfig{
+		Enabled:    true,
+		Tick:       time.Minute,
+		Initial:    2,
+		Thereafter: 5,
+		Messages:   []string{"noisy"},
+	}))
+
+	dropped := SampledDropped()
+	for i := 0; i < 12; i++ {
+		log.Info("noisy")
+		log.Info("quiet")
+	}
+
+	assert.Equal(t, 4, logs.FilterMessage("noisy").Len(), "first 2, then every 5th")
+	assert.Equal(t, 12, logs.FilterMessage("quiet").Len(), "unlisted messages are not sampled")
+	assert.Equal(t, int64(8), SampledDropped()-dropped)
+}
+
+func TestRuntimeLevels(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	base := zap.New(newLevelCore(core))
+	levels.reset(zapcore.InfoLevel)
+	defer levels.reset(zapcore.InfoLevel)
+
+	t.Run("ComponentOverride", func(t *testing.T) {
+		logs.TakeAll()
+		require.NoError(t, SetLevelFor(ComponentRPC, "debug", 0))
+		defer ResetComponentLevel(ComponentRPC)
+
+		base.With(zap.String("component", ComponentRPC)).Debug("rpc debug")
+		base.With(zap.String("component", ComponentAuth)).Debug("auth debug")
+		base.Debug("global debug")
+
+		entries := logs.TakeAll()
+		require.Len(t, entries, 1)
+		assert.Equal(t, "rpc debug", entries[0].Message)
+		assert.Equal(t, "debug", Levels().Components[ComponentRPC].Level)
+
+		// A component can also be quieter than the global level
+		require.NoError(t, SetLevelFor(ComponentAuth, "error", 0))
+		defer ResetComponentLevel(ComponentAuth)
+		base.With(zap.String("component", ComponentAuth)).Warn("auth warning")
+		assert.Zero(t, logs.Len())
+	})
+
+	t.Run("UnknownComponent", func(t *testing.T) {
+		err := SetLevelFor("database", "debug", 0)
+		assert.True(t, errors.Is(err, ErrUnknownComponent))
+		assert.Error(t, SetLevelFor("", "verbose", 0))
+	})
+
+	t.Run("AutoRevert", func(t *testing.T) {
+		require.NoError(t, SetLevelFor("", "debug", 50*time.Millisecond))
+		require.NoError(t, SetLevelFor(ComponentBalanceService, "warn", 50*time.Millisecond))
+
+		state := Levels()
+		assert.Equal(t, "debug", state.Level)
+		assert.Equal(t, "info", state.RevertsTo)
+		require.NotNil(t, state.RevertsAt)
+		assert.Equal(t, "", state.Components[ComponentBalanceService].RevertsTo, "the override is removed on revert")
+
+		require.Eventually(t, func() bool {
+			state := Levels()
+			return state.Level == "info" && len(state.Components) == 0
+		}, time.Second, 5*time.Millisecond)
+		assert.Nil(t, Levels().RevertsAt)
+	})
+
+	t.Run("ReplacingTemporaryChange", func(t *testing.T) {
+		require.NoError(t, SetLevelFor("", "debug", time.Hour))
+		require.NoError(t, SetLevelFor("", "warn", time.Hour))
+		assert.Equal(t, "info", Levels().RevertsTo, "reverts to the level before the first change")
+
+		// A permanent change cancels the pending revert
+		require.NoError(t, SetLevel("error"))
+		state := Levels()
+		assert.Equal(t, "error", state.Level)
+		assert.Nil(t, state.RevertsAt)
+	})
+}
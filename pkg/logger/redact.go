@@ -0,0 +1,288 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"solana-balance-api/pkg/secrets"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Redaction actions applied to log fields
+const (
+	RedactHash     = "hash"     // Replace with a short SHA-256 digest; equal values stay correlatable
+	RedactTruncate = "truncate" // Keep the first and last four characters
+	RedactMask     = "mask"     // Keep an authorization scheme such as "Bearer", mask the credential
+	RedactURI      = "uri"      // Mask passwords, credential query parameters and token path segments
+	RedactDrop     = "drop"     // Remove the field
+	RedactNone     = "none"     // Log the value as is
+)
+
+// RedactionActions lists every valid redaction action
+var RedactionActions = []string{RedactHash, RedactTruncate, RedactMask, RedactURI, RedactDrop, RedactNone}
+
+// walletFields carry wallet addresses and follow RedactionConfig.Wallets. Generic
+// names such as "address" are left out because they also carry listen and peer
+// addresses; add them through RedactionConfig.Fields if needed.
+var walletFields = []string{"wallet", "wallets", "wallet_address", "wallet_addresses"}
+
+// defaultFieldRules apply unless overridden by RedactionConfig.Fields
+var defaultFieldRules = map[string]string{
+	"authorization":      RedactMask,
+	"auth_header":        RedactMask,
+	"auth_header_format": RedactMask,
+	"api_key":            RedactMask,
+	"token":              RedactMask,
+	"access_token":       RedactMask,
+	"refresh_token":      RedactMask,
+	"client_secret":      RedactMask,
+	"password":           RedactMask,
+	"mongodb_uri":        RedactURI,
+	"rpc_endpoint":       RedactURI,
+	"jwks_url":           RedactURI,
+	"uri":                RedactURI,
+}
+
+// authSchemes are credential prefixes masked in any string field
+var authSchemes = []string{"Bearer ", "Basic ", "ApiKey "}
+
+// RedactionConfig configures field-level log redaction
+type RedactionConfig struct {
+	Wallets string            // Action for wallet address fields; empty means hash
+	Fields  map[string]string // Additional or overriding rules: field name -> action
+}
+
+// Redactor rewrites log fields according to per-field rules
+type Redactor struct {
+	rules map[string]string
+}
+
+// NewRedactor builds the field rules from the defaults and cfg
+func NewRedactor(cfg RedactionConfig) (*Redactor, error) {
+	wallets := cfg.Wallets
+	if wallets == "" {
+		wallets = RedactHash
+	}
+
+	rules := make(map[string]string, len(defaultFieldRules)+len(walletFields)+len(cfg.Fields))
+	for field, action := range defaultFieldRules {
+		rules[field] = action
+	}
+	for _, field := range walletFields {
+		rules[field] = wallets
+	}
+	for field, action := range cfg.Fields {
+		rules[field] = action
+	}
+
+	for field, action := range rules {
+		if !validAction(action) {
+			return nil, fmt.Errorf("invalid redaction action %q for field %s", action, field)
+		}
+	}
+	return &Redactor{rules: rules}, nil
+}
+
+// validAction reports whether action is a known redaction action
+func validAction(action string) bool {
+	for _, candidate := range RedactionActions {
+		if action == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+// Fields returns fields with the redaction rules applied
+func (r *Redactor) Fields(fields []zapcore.Field) []zapcore.Field {
+	var redacted []zapcore.Field
+	for i, field := range fields {
+		updated, changed := r.field(field)
+		if !changed {
+			if redacted != nil {
+				redacted = append(redacted, field)
+			}
+			continue
+		}
+		if redacted == nil {
+			redacted = make([]zapcore.Field, i, len(fields))
+			copy(redacted, fields[:i])
+		}
+		if updated.Type != zapcore.SkipType {
+			redacted = append(redacted, updated)
+		}
+	}
+	if redacted == nil {
+		return fields
+	}
+	return redacted
+}
+
+// field applies the rule for a single field, reporting whether it changed
+func (r *Redactor) field(field zapcore.Field) (zapcore.Field, bool) {
+	action, hasRule := r.rules[field.Key]
+	if !hasRule {
+		// Credentials are masked wherever they appear
+		if field.Type == zapcore.StringType && hasAuthScheme(field.String) {
+			return zap.String(field.Key, maskCredential(field.String)), true
+		}
+		// Rules also apply to the keys of context maps, such as AppError contexts
+		if values, ok := field.Interface.(map[string]interface{}); ok && field.Type == zapcore.ReflectType {
+			if redacted, changed := r.redactMap(values); changed {
+				return zap.Any(field.Key, redacted), true
+			}
+		}
+		return field, false
+	}
+
+	switch action {
+	case RedactNone:
+		return field, false
+	case RedactDrop:
+		return zap.Skip(), true
+	}
+
+	if field.Type == zapcore.StringType {
+		return zap.String(field.Key, Redact(action, field.String)), true
+	}
+
+	// Arrays and other encoded values are captured and redacted element by element
+	encoder := zapcore.NewMapObjectEncoder()
+	field.AddTo(encoder)
+	switch value := encoder.Fields[field.Key].(type) {
+	case string:
+		return zap.String(field.Key, Redact(action, value)), true
+	case []interface{}:
+		values := make([]string, len(value))
+		for i, item := range value {
+			values[i] = Redact(action, fmt.Sprint(item))
+		}
+		return zap.Strings(field.Key, values), true
+	case nil:
+		return field, false
+	default:
+		return zap.String(field.Key, secrets.Redacted), true
+	}
+}
+
+// redactMap applies the field rules to the entries of a map, returning a copy when
+// any entry changed
+func (r *Redactor) redactMap(values map[string]interface{}) (map[string]interface{}, bool) {
+	var redacted map[string]interface{}
+	for key, value := range values {
+		updated, changed := r.field(zap.Any(key, value))
+		if !changed {
+			continue
+		}
+		if redacted == nil {
+			redacted = make(map[string]interface{}, len(values))
+			for k, v := range values {
+				redacted[k] = v
+			}
+		}
+		if updated.Type == zapcore.SkipType {
+			delete(redacted, key)
+			continue
+		}
+		encoder := zapcore.NewMapObjectEncoder()
+		updated.AddTo(encoder)
+		redacted[key] = encoder.Fields[key]
+	}
+	if redacted == nil {
+		return values, false
+	}
+	return redacted, true
+}
+
+// Redact applies a redaction action to a single value
+func Redact(action, value string) string {
+	if value == "" {
+		return value
+	}
+
+	switch action {
+	case RedactHash:
+		return HashValue(value)
+	case RedactTruncate:
+		return TruncateValue(value)
+	case RedactMask:
+		return maskCredential(value)
+	case RedactURI:
+		// Values without a scheme, such as route paths, carry no credentials
+		if !strings.Contains(value, "://") {
+			return value
+		}
+		return secrets.RedactURL(value)
+	case RedactNone:
+		return value
+	default:
+		return secrets.Redacted
+	}
+}
+
+// HashValue returns a short, stable digest of value, e.g. for wallet addresses
+func HashValue(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return "sha256:" + hex.EncodeToString(sum[:6])
+}
+
+// TruncateValue keeps the first and last four characters of value
+func TruncateValue(value string) string {
+	if len(value) <= 10 {
+		return value[:1] + "..."
+	}
+	return value[:4] + "..." + value[len(value)-4:]
+}
+
+// hasAuthScheme reports whether value starts with an authorization scheme
+func hasAuthScheme(value string) bool {
+	for _, scheme := range authSchemes {
+		if len(value) > len(scheme) && strings.EqualFold(value[:len(scheme)], scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskCredential masks a credential, keeping a leading scheme word such as
+// "Bearer" so the header format stays visible
+func maskCredential(value string) string {
+	if scheme, credential, found := strings.Cut(value, " "); found && credential != "" && len(scheme) <= 16 {
+		return scheme + " " + secrets.Redacted
+	}
+	return secrets.Redacted
+}
+
+// redactCore applies a Redactor to every field written through the wrapped core,
+// including fields added with With
+type redactCore struct {
+	zapcore.Core
+	redactor *Redactor
+}
+
+// newRedactCore wraps core with redaction
+func newRedactCore(core zapcore.Core, redactor *Redactor) zapcore.Core {
+	return &redactCore{Core: core, redactor: redactor}
+}
+
+// With redacts the context fields before adding them
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.redactor.Fields(fields)), redactor: c.redactor}
+}
+
+// Check adds this core, not the wrapped one, so Write applies redaction
+func (c *redactCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+// Write redacts the entry's fields and writes them to the wrapped core
+func (c *redactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(entry, c.redactor.Fields(fields))
+}
@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"sync"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Rotation defaults for file outputs given through Config.OutputPaths
+const (
+	DefaultMaxSizeMB  = 100
+	DefaultMaxBackups = 10
+)
+
+// RotationConfig configures log file rotation and retention
+type RotationConfig struct {
+	MaxSizeMB      int           // Rotate when the file reaches this size; 0 uses 100 MB
+	RotateInterval time.Duration // Also rotate once the file is this old; 0 rotates by size only
+	MaxAge         time.Duration // Delete rotated files older than this; 0 keeps them regardless of age
+	MaxBackups     int           // Number of rotated files to keep; 0 keeps all
+	Compress       bool          // Gzip rotated files
+	LocalTime      bool          // Name rotated files using local time instead of UTC
+}
+
+// rotatingWriter writes to a file rotated by size and, optionally, by age
+type rotatingWriter struct {
+	file     *lumberjack.Logger
+	interval time.Duration
+	opened   time.Time
+	mutex    sync.Mutex
+}
+
+// newRotatingWriter creates a rotating writer for the file at path
+func newRotatingWriter(path string, cfg RotationConfig) *rotatingWriter {
+	maxAgeDays := 0
+	if cfg.MaxAge > 0 {
+		// lumberjack retains by whole days; round partial days up
+		maxAgeDays = int((cfg.MaxAge + 24*time.Hour - 1) / (24 * time.Hour))
+	}
+
+	return &rotatingWriter{
+		file: &lumberjack.Logger{
+			Filename:   path,
+			MaxSize:    cfg.MaxSizeMB,
+			MaxAge:     maxAgeDays,
+			MaxBackups: cfg.MaxBackups,
+			Compress:   cfg.Compress,
+			LocalTime:  cfg.LocalTime,
+		},
+		interval: cfg.RotateInterval,
+		opened:   time.Now(),
+	}
+}
+
+// Write appends p to the file, first rotating it when it has reached its age limit
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.interval > 0 && time.Since(w.opened) >= w.interval {
+		if err := w.file.Rotate(); err != nil {
+			return 0, err
+		}
+		w.opened = time.Now()
+	}
+	return w.file.Write(p)
+}
+
+// Sync is a no-op; writes go straight to the file
+func (w *rotatingWriter) Sync() error {
+	return nil
+}
+
+// Rotate closes the current file and starts a new one
+func (w *rotatingWriter) Rotate() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.opened = time.Now()
+	return w.file.Rotate()
+}
+
+// Close closes the current file
+func (w *rotatingWriter) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.file.Close()
+}
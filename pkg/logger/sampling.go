@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// SamplingConfig configures per-message log sampling. Within each tick the first
+// Initial entries with the same level and message are logged, then every
+// Thereafter-th; the rest are dropped.
+type SamplingConfig struct {
+	Enabled    bool
+	Tick       time.Duration
+	Initial    int
+	Thereafter int
+	Messages   []string // Messages subject to sampling; empty samples every message
+}
+
+// sampledDropped counts entries dropped by sampling
+var sampledDropped int64
+
+// SampledDropped returns the number of log entries dropped by sampling
+func SampledDropped() int64 {
+	return atomic.LoadInt64(&sampledDropped)
+}
+
+// countDropped is the sampler hook counting dropped entries
+func countDropped(_ zapcore.Entry, decision zapcore.SamplingDecision) {
+	if decision&zapcore.LogDropped != 0 {
+		atomic.AddInt64(&sampledDropped, 1)
+	}
+}
+
+// messageSampler routes the configured messages through a sampler and logs all
+// other entries unsampled
+type messageSampler struct {
+	zapcore.Core
+	sampled  zapcore.Core
+	messages map[string]bool
+}
+
+// newSamplingCore wraps core with sampling as described by cfg
+func newSamplingCore(core zapcore.Core, cfg SamplingConfig) zapcore.Core {
+	if !cfg.Enabled {
+		return core
+	}
+
+	tick := cfg.Tick
+	if tick <= 0 {
+		tick = time.Second
+	}
+	sampled := zapcore.NewSamplerWithOptions(core, tick, cfg.Initial, cfg.Thereafter, zapcore.SamplerHook(countDropped))
+	if len(cfg.Messages) == 0 {
+		return sampled
+	}
+
+	messages := make(map[string]bool, len(cfg.Messages))
+	for _, message := range cfg.Messages {
+		messages[message] = true
+	}
+	return &messageSampler{Core: core, sampled: sampled, messages: messages}
+}
+
+// With adds fields to both the sampled and unsampled paths
+func (c *messageSampler) With(fields []zapcore.Field) zapcore.Core {
+	return &messageSampler{
+		Core:     c.Core.With(fields),
+		sampled:  c.sampled.With(fields),
+		messages: c.messages,
+	}
+}
+
+// Check samples entries whose message is configured for sampling
+func (c *messageSampler) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.messages[entry.Message] {
+		return c.sampled.Check(entry, checked)
+	}
+	return c.Core.Check(entry, checked)
+}
@@ -0,0 +1,134 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Sink encodings
+const (
+	EncodingJSON    = "json"
+	EncodingConsole = "console"
+)
+
+// SinkConfig describes one log output with its own minimum level and encoding
+type SinkConfig struct {
+	Path     string // "stdout", "stderr" or a file path
+	Level    string // Minimum level for this sink; empty follows the logger level only
+	Encoding string // "json" or "console"; empty follows the environment
+	Rotation RotationConfig
+	Async    AsyncConfig
+}
+
+// sink is an opened output
+type sink struct {
+	core   zapcore.Core
+	writer zapcore.WriteSyncer
+	closer io.Closer // Nil for stdout and stderr
+}
+
+// openSink opens the output described by cfg. The sink filters by its own minimum
+// level; the runtime levels are applied in front of every sink.
+func openSink(cfg SinkConfig, environment string) (*sink, error) {
+	minimum := zapcore.DebugLevel
+	if cfg.Level != "" {
+		if err := minimum.Set(cfg.Level); err != nil {
+			return nil, fmt.Errorf("invalid level for log sink %s: %w", cfg.Path, err)
+		}
+	}
+
+	encoder, err := newEncoder(cfg.Encoding, environment)
+	if err != nil {
+		return nil, fmt.Errorf("log sink %s: %w", cfg.Path, err)
+	}
+
+	var writer zapcore.WriteSyncer
+	var closer io.Closer
+	switch cfg.Path {
+	case "stdout":
+		writer = consoleSyncer{zapcore.Lock(os.Stdout)}
+	case "stderr":
+		writer = consoleSyncer{zapcore.Lock(os.Stderr)}
+	case "":
+		return nil, errors.New("log sink path must not be empty")
+	default:
+		rotating := newRotatingWriter(cfg.Path, cfg.Rotation)
+		writer, closer = rotating, rotating
+	}
+
+	if cfg.Async.Enabled {
+		async := NewAsyncWriter(writer, cfg.Async)
+		writer, closer = async, async
+	}
+
+	return &sink{
+		core:   zapcore.NewCore(encoder, writer, minimum),
+		writer: writer,
+		closer: closer,
+	}, nil
+}
+
+// newEncoder creates the encoder for a sink
+func newEncoder(encoding, environment string) (zapcore.Encoder, error) {
+	encoderConfig := zap.NewDevelopmentEncoderConfig()
+	if environment == "production" {
+		encoderConfig = zap.NewProductionEncoderConfig()
+	}
+
+	if encoding == "" {
+		encoding = EncodingConsole
+		if environment == "production" {
+			encoding = EncodingJSON
+		}
+	}
+
+	switch encoding {
+	case EncodingJSON:
+		return zapcore.NewJSONEncoder(encoderConfig), nil
+	case EncodingConsole:
+		return zapcore.NewConsoleEncoder(encoderConfig), nil
+	default:
+		return nil, fmt.Errorf("unknown encoding %q (use json or console)", encoding)
+	}
+}
+
+// consoleSyncer ignores the errors returned when syncing terminals and pipes,
+// which do not support fsync
+type consoleSyncer struct {
+	zapcore.WriteSyncer
+}
+
+// Sync syncs the console, ignoring unsupported-operation errors
+func (s consoleSyncer) Sync() error {
+	err := s.WriteSyncer.Sync()
+	if errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTTY) || errors.Is(err, syscall.ENOTSUP) {
+		return nil
+	}
+	return err
+}
+
+// sinksFromOutputPaths converts legacy output paths to sinks; files are rotated
+// with the default limits
+func sinksFromOutputPaths(paths []string) []SinkConfig {
+	if len(paths) == 0 {
+		paths = []string{"stdout"}
+	}
+
+	sinks := make([]SinkConfig, len(paths))
+	for i, path := range paths {
+		sinks[i] = SinkConfig{
+			Path: path,
+			Rotation: RotationConfig{
+				MaxSizeMB:  DefaultMaxSizeMB,
+				MaxBackups: DefaultMaxBackups,
+			},
+		}
+	}
+	return sinks
+}
@@ -0,0 +1,154 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingWriter records writes, optionally blocking until released
+type blockingWriter struct {
+	mutex   sync.Mutex
+	buf     bytes.Buffer
+	release chan struct{}
+	closed  bool
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	if w.release != nil {
+		<-w.release
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *blockingWriter) Sync() error { return nil }
+
+func (w *blockingWriter) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.closed = true
+	return nil
+}
+
+func (w *blockingWriter) String() string {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncWriter(t *testing.T) {
+	t.Run("SyncFlushes", func(t *testing.T) {
+		out := &blockingWriter{}
+		w := NewAsyncWriter(out, AsyncConfig{BufferSize: 10})
+		defer w.Close()
+
+		for _, line := range []string{"a\n", "b\n", "c\n"} {
+			_, err := w.Write([]byte(line))
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Sync())
+		assert.Equal(t, "a\nb\nc\n", out.String())
+	})
+
+	t.Run("DropPolicies", func(t *testing.T) {
+		for policy, expected := range map[string]string{DropNewest: "1\n2\n", DropOldest: "1\n4\n"} {
+			out := &blockingWriter{release: make(chan struct{})}
+			w := NewAsyncWriter(out, AsyncConfig{BufferSize: 1, DropPolicy: policy})
+
+			// The first entry is taken by the background goroutine, which then blocks
+			w.Write([]byte("1\n"))
+			require.Eventually(t, func() bool { return len(w.queue) == 0 }, time.Second, time.Millisecond)
+			for _, line := range []string{"2\n", "3\n", "4\n"} {
+				w.Write([]byte(line))
+			}
+			assert.Equal(t, int64(2), w.Dropped(), policy)
+
+			close(out.release)
+			require.NoError(t, w.Close())
+			assert.Equal(t, expected, out.String(), policy)
+		}
+	})
+
+	t.Run("WritesAfterClose", func(t *testing.T) {
+		out := &blockingWriter{}
+		w := NewAsyncWriter(out, AsyncConfig{})
+		w.Write([]byte("before\n"))
+		require.NoError(t, w.Close())
+		assert.True(t, out.closed)
+
+		w.Write([]byte("after\n"))
+		assert.Equal(t, "before\nafter\n", out.String())
+		assert.NoError(t, w.Close(), "Close is idempotent")
+	})
+}
+
+func TestRotatingWriter(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "api.log")
+
+	w := newRotatingWriter(path, RotationConfig{RotateInterval: 20 * time.Millisecond, MaxBackups: 1})
+	defer w.Close()
+
+	for i := 0; i < 3; i++ {
+		_, err := w.Write([]byte("entry\n"))
+		require.NoError(t, err)
+		time.Sleep(30 * time.Millisecond)
+	}
+
+	// Rotated files are pruned to MaxBackups in the background
+	require.Eventually(t, func() bool {
+		entries, _ := os.ReadDir(dir)
+		return len(entries) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "entry\n", string(data), "each write after the interval starts a new file")
+}
+
+func TestSinks(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "errors.json")
+	consolePath := filepath.Join(dir, "all.log")
+
+	require.NoError(t, Initialize(&Config{
+		Level:       "debug",
+		Environment: "production",
+		Sinks: []SinkConfig{
+			{Path: jsonPath, Level: "warn", Encoding: EncodingJSON},
+			{Path: consolePath, Encoding: EncodingConsole, Async: AsyncConfig{Enabled: true}},
+		},
+	}))
+	log := GetLogger()
+	log.Debug("debug entry")
+	log.Warn("warn entry")
+	require.NoError(t, log.Close())
+
+	jsonLog, err := os.ReadFile(jsonPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(jsonLog), "debug entry")
+	assert.Contains(t, string(jsonLog), `"msg":"warn entry"`)
+
+	consoleLog, err := os.ReadFile(consolePath)
+	require.NoError(t, err)
+	assert.Contains(t, string(consoleLog), "debug entry")
+	assert.Contains(t, string(consoleLog), "warn entry")
+	assert.False(t, strings.HasPrefix(string(consoleLog), "{"))
+
+	// Runtime level changes apply to every sink
+	require.NoError(t, SetLevel("error"))
+	defer SetLevel("info")
+	log.Warn("suppressed")
+	require.NoError(t, log.Sync())
+	consoleLog, _ = os.ReadFile(consolePath)
+	assert.NotContains(t, string(consoleLog), "suppressed")
+}
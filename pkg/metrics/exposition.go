@@ -0,0 +1,289 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Format is a metrics exposition format
+type Format int
+
+const (
+	FormatJSON        Format = iota // Legacy JSON document
+	FormatPrometheus                // Prometheus text format 0.0.4
+	FormatOpenMetrics               // OpenMetrics text format 1.0.0
+)
+
+// Content types of the text exposition formats
+const (
+	ContentTypePrometheus  = "text/plain; version=0.0.4; charset=utf-8"
+	ContentTypeOpenMetrics = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// Namespace prefixes every exposed metric name
+const Namespace = "solana_api"
+
+// ContentType returns the HTTP content type for the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatPrometheus:
+		return ContentTypePrometheus
+	case FormatOpenMetrics:
+		return ContentTypeOpenMetrics
+	default:
+		return "application/json; charset=utf-8"
+	}
+}
+
+// ParseFormat maps a format name ("json", "prometheus" or "openmetrics") to a Format
+func ParseFormat(name string) (Format, bool) {
+	switch strings.ToLower(name) {
+	case "json":
+		return FormatJSON, true
+	case "prometheus", "text":
+		return FormatPrometheus, true
+	case "openmetrics":
+		return FormatOpenMetrics, true
+	}
+	return FormatJSON, false
+}
+
+// NegotiateFormat picks the exposition format with the highest quality in an Accept
+// header. Wildcards and a missing header select JSON, which keeps existing clients
+// working; Prometheus scrapers ask for OpenMetrics or text/plain explicitly.
+func NegotiateFormat(accept string) Format {
+	best, bestQ := FormatJSON, -1.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if value, exists := params["q"]; exists {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+
+		var format Format
+		switch mediaType {
+		case "application/openmetrics-text":
+			format = FormatOpenMetrics
+		case "text/plain":
+			format = FormatPrometheus
+		case "application/json", "*/*", "application/*":
+			format = FormatJSON
+		default:
+			continue
+		}
+
+		if q > bestQ {
+			best, bestQ = format, q
+		}
+	}
+	return best
+}
+
+// label is a single metric label
+type label struct {
+	name  string
+	value string
+}
+
+// expositionWriter writes metric families in the Prometheus or OpenMetrics text format
+type expositionWriter struct {
+	w           *bufio.Writer
+	openMetrics bool
+}
+
+// family writes the HELP and TYPE lines. OpenMetrics names counter families without
+// the _total suffix; the 0.0.4 format includes it.
+func (ew *expositionWriter) family(name, metricType, help string) {
+	if metricType == "counter" && !ew.openMetrics {
+		name += "_total"
+	}
+	ew.w.WriteString("# HELP " + name + " " + escapeHelp(help) + "\n")
+	ew.w.WriteString("# TYPE " + name + " " + metricType + "\n")
+}
+
+// sample writes a single sample line
+func (ew *expositionWriter) sample(name string, labels []label, value float64) {
+	ew.w.WriteString(name)
+	if len(labels) > 0 {
+		ew.w.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				ew.w.WriteByte(',')
+			}
+			ew.w.WriteString(l.name + `="` + escapeLabelValue(l.value) + `"`)
+		}
+		ew.w.WriteByte('}')
+	}
+	ew.w.WriteByte(' ')
+	ew.w.WriteString(formatFloat(value))
+	ew.w.WriteByte('\n')
+}
+
+// counter writes a counter family with one unlabelled sample
+func (ew *expositionWriter) counter(name, help string, value int64) {
+	ew.family(name, "counter", help)
+	ew.sample(name+"_total", nil, float64(value))
+}
+
+// gauge writes a gauge family with one unlabelled sample
+func (ew *expositionWriter) gauge(name, help string, value float64) {
+	ew.family(name, "gauge", help)
+	ew.sample(name, nil, value)
+}
+
+// histogram writes the bucket, sum and count samples of one histogram series
+func (ew *expositionWriter) histogram(name string, labels []label, h HistogramSnapshot) {
+	for i, bound := range h.Bounds {
+		bucketLabels := append(append([]label(nil), labels...), label{name: "le", value: formatFloat(bound)})
+		ew.sample(name+"_bucket", bucketLabels, float64(h.Cumulative[i]))
+	}
+	ew.sample(name+"_sum", labels, h.Sum)
+	ew.sample(name+"_count", labels, float64(h.Count))
+}
+
+// WriteExposition writes every metric in the Prometheus text or OpenMetrics format
+func (mc *MetricsCollector) WriteExposition(out io.Writer, format Format) error {
+	ew := &expositionWriter{w: bufio.NewWriter(out), openMetrics: format == FormatOpenMetrics}
+	m := mc.GetMetrics()
+	ns := Namespace + "_"
+
+	// Request counters
+	ew.counter(ns+"requests", "Requests started.", m.TotalRequests)
+	ew.counter(ns+"requests_successful", "Requests completed successfully.", m.SuccessfulRequests)
+	ew.counter(ns+"requests_failed", "Requests completed with a failure.", m.FailedRequests)
+	ew.gauge(ns+"active_requests", "Requests currently in flight.", float64(m.ActiveRequests))
+
+	// Cache and concurrency counters
+	ew.counter(ns+"cache_hits", "Balance cache hits.", m.CacheHits)
+	ew.counter(ns+"cache_misses", "Balance cache misses.", m.CacheMisses)
+	ew.counter(ns+"mutex_waits", "Per-wallet mutex acquisitions that waited longer than 1ms.", m.MutexWaits)
+
+	// Per-route HTTP series
+	httpSeries := mc.GetHTTPSeries()
+	ew.family(ns+"http_requests", "counter", "HTTP requests by method, route and status.")
+	for _, series := range httpSeries {
+		statuses := make([]int, 0, len(series.Statuses))
+		for status := range series.Statuses {
+			statuses = append(statuses, status)
+		}
+		sort.Ints(statuses)
+		for _, status := range statuses {
+			ew.sample(ns+"http_requests_total", []label{
+				{name: "method", value: series.Method},
+				{name: "route", value: series.Route},
+				{name: "status", value: strconv.Itoa(status)},
+			}, float64(series.Statuses[status]))
+		}
+	}
+	ew.family(ns+"http_request_duration_seconds", "histogram", "HTTP request latency by method and route.")
+	for _, series := range httpSeries {
+		ew.histogram(ns+"http_request_duration_seconds", []label{
+			{name: "method", value: series.Method},
+			{name: "route", value: series.Route},
+		}, series.Duration)
+	}
+
+	// Per-method RPC series
+	rpcSeries := mc.GetRPCSeries()
+	ew.family(ns+"rpc_calls", "counter", "Solana RPC calls by method.")
+	for _, series := range rpcSeries {
+		ew.sample(ns+"rpc_calls_total", []label{{name: "method", value: series.Method}}, float64(series.Calls))
+	}
+	ew.family(ns+"rpc_failures", "counter", "Failed Solana RPC calls by method.")
+	for _, series := range rpcSeries {
+		ew.sample(ns+"rpc_failures_total", []label{{name: "method", value: series.Method}}, float64(series.Failures))
+	}
+	ew.family(ns+"rpc_duration_seconds", "histogram", "Solana RPC latency by method.")
+	for _, series := range rpcSeries {
+		ew.histogram(ns+"rpc_duration_seconds", []label{{name: "method", value: series.Method}}, series.Duration)
+	}
+
+	// Per-tenant counters
+	tenantIDs := make([]string, 0, len(m.Tenants))
+	for tenantID := range m.Tenants {
+		tenantIDs = append(tenantIDs, tenantID)
+	}
+	sort.Strings(tenantIDs)
+
+	tenantCounters := []struct {
+		name  string
+		help  string
+		value func(TenantMetrics) int64
+	}{
+		{"tenant_requests", "Requests by tenant.", func(t TenantMetrics) int64 { return t.Requests }},
+		{"tenant_failed_requests", "Failed requests by tenant.", func(t TenantMetrics) int64 { return t.FailedRequests }},
+		{"tenant_wallets", "Wallets queried by tenant.", func(t TenantMetrics) int64 { return t.Wallets }},
+		{"tenant_rpc_calls", "RPC calls made on behalf of a tenant.", func(t TenantMetrics) int64 { return t.RPCCalls }},
+	}
+	for _, counter := range tenantCounters {
+		ew.family(ns+counter.name, "counter", counter.help)
+		for _, tenantID := range tenantIDs {
+			ew.sample(ns+counter.name+"_total", []label{{name: "tenant", value: tenantID}}, float64(counter.value(m.Tenants[tenantID])))
+		}
+	}
+
+	// Sliding-window latency quantiles
+	trackers := make([]string, 0, len(m.Latency))
+	for name := range m.Latency {
+		trackers = append(trackers, name)
+	}
+	sort.Strings(trackers)
+
+	ew.family(ns+"latency_quantile_seconds", "gauge", "Latency quantile estimates over sliding windows.")
+	for _, name := range trackers {
+		for _, window := range LatencyWindows {
+			summary := m.Latency[name][window.Name]
+			for _, q := range []struct {
+				label string
+				ms    float64
+			}{{"0.5", summary.P50Ms}, {"0.95", summary.P95Ms}, {"0.99", summary.P99Ms}} {
+				ew.sample(ns+"latency_quantile_seconds", []label{
+					{name: "operation", value: name},
+					{name: "window", value: window.Name},
+					{name: "quantile", value: q.label},
+				}, q.ms/1000)
+			}
+		}
+	}
+
+	ew.gauge(ns+"uptime_seconds", "Seconds since metrics collection started.", mc.GetUptime().Seconds())
+
+	if ew.openMetrics {
+		ew.w.WriteString("# EOF\n")
+	}
+	return ew.w.Flush()
+}
+
+// formatFloat formats a sample value, using the exposition spelling of infinities
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// escapeLabelValue escapes backslashes, double quotes and newlines
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// escapeHelp escapes backslashes and newlines in HELP text
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
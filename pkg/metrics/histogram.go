@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Default latency buckets in seconds, from 1ms to 10s
+var DefaultLatencyBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Histogram counts observations into fixed buckets, Prometheus style
+type Histogram struct {
+	bounds []float64 // Upper bounds, ascending, without +Inf
+	counts []uint64  // Per-bucket counts; the last entry is the +Inf bucket
+	sum    float64
+	count  uint64
+	mutex  sync.Mutex
+}
+
+// HistogramSnapshot is a point-in-time copy of a histogram with cumulative counts
+type HistogramSnapshot struct {
+	Bounds     []float64 `json:"bounds"`
+	Cumulative []uint64  `json:"cumulative"` // Count of observations <= Bounds[i]; the last entry is +Inf
+	Sum        float64   `json:"sum"`
+	Count      uint64    `json:"count"`
+}
+
+// NewHistogram creates a histogram with the given upper bounds
+func NewHistogram(bounds []float64) *Histogram {
+	sorted := append([]float64(nil), bounds...)
+	sort.Float64s(sorted)
+	return &Histogram{
+		bounds: sorted,
+		counts: make([]uint64, len(sorted)+1),
+	}
+}
+
+// Observe records a value
+func (h *Histogram) Observe(value float64) {
+	i := sort.SearchFloat64s(h.bounds, value)
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.counts[i]++
+	h.sum += value
+	h.count++
+}
+
+// ObserveDuration records a duration in seconds
+func (h *Histogram) ObserveDuration(d time.Duration) {
+	h.Observe(d.Seconds())
+}
+
+// Snapshot returns a copy of the histogram with cumulative bucket counts
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	snapshot := HistogramSnapshot{
+		Bounds:     append(append([]float64(nil), h.bounds...), math.Inf(1)),
+		Cumulative: make([]uint64, len(h.counts)),
+		Sum:        h.sum,
+		Count:      h.count,
+	}
+	var running uint64
+	for i, count := range h.counts {
+		running += count
+		snapshot.Cumulative[i] = running
+	}
+	return snapshot
+}
@@ -1,11 +1,42 @@
 package metrics
 
 import (
+	"net/http"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
+// Latency tracker names used as keys in Metrics.Latency
+const (
+	LatencyHTTP        = "http"
+	LatencyRPC         = "rpc"
+	LatencyMutexWait   = "mutex_wait"
+	LatencyCacheLookup = "cache_lookup"
+)
+
+// Label value used for requests that did not match a route and RPC calls recorded
+// without a method
+const unknownLabel = "unknown"
+
+// Label value used for HTTP methods outside the standard set, so arbitrary client
+// supplied methods cannot grow the series map
+const otherLabel = "other"
+
+// standardMethods lists the HTTP methods recorded under their own label
+var standardMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
 // Metrics holds performance metrics for the application
 type Metrics struct {
 	// Request metrics
@@ -31,16 +62,76 @@ type Metrics struct {
 	ActiveRequests int64 `json:"active_requests"`
 	MutexWaits     int64 `json:"mutex_waits"`
 
+	// Per-tenant metrics, keyed by tenant ID
+	Tenants map[string]TenantMetrics `json:"tenants,omitempty"`
+
+	// Latency quantiles by tracker (http, rpc, mutex_wait, cache_lookup) and window (1m, 5m, 15m)
+	Latency map[string]map[string]LatencySummary `json:"latency,omitempty"`
+
 	// Internal fields for calculations
 	totalResponseTime time.Duration
 	totalRPCTime      time.Duration
 	mutex             sync.RWMutex
 }
 
+// TenantMetrics holds request counters for a single tenant
+type TenantMetrics struct {
+	Requests       int64 `json:"requests"`
+	FailedRequests int64 `json:"failed_requests"`
+	Wallets        int64 `json:"wallets"`
+	RPCCalls       int64 `json:"rpc_calls"`
+}
+
+// HTTPSeries holds request counts by status and the latency histogram for one route
+type HTTPSeries struct {
+	Method   string            `json:"method"`
+	Route    string            `json:"route"`
+	Statuses map[int]int64     `json:"statuses"`
+	Duration HistogramSnapshot `json:"duration_seconds"`
+}
+
+// RPCSeries holds call counts and the latency histogram for one RPC method
+type RPCSeries struct {
+	Method   string            `json:"method"`
+	Calls    int64             `json:"calls"`
+	Failures int64             `json:"failures"`
+	Duration HistogramSnapshot `json:"duration_seconds"`
+}
+
+// httpRoute identifies an HTTP series
+type httpRoute struct {
+	method string
+	route  string
+}
+
+// httpRouteMetrics holds the live counters for an HTTP series
+type httpRouteMetrics struct {
+	statuses map[int]int64
+	duration *Histogram
+}
+
+// rpcMethodMetrics holds the live counters for an RPC series
+type rpcMethodMetrics struct {
+	calls    int64
+	failures int64
+	duration *Histogram
+}
+
 // MetricsCollector provides thread-safe metrics collection
 type MetricsCollector struct {
 	metrics   *Metrics
 	startTime time.Time
+
+	tenants     map[string]*TenantMetrics
+	tenantMutex sync.Mutex
+
+	// Labelled series for the Prometheus exposition
+	httpRoutes  map[httpRoute]*httpRouteMetrics
+	rpcMethods  map[string]*rpcMethodMetrics
+	seriesMutex sync.Mutex
+
+	// Sliding-window latency quantiles, keyed by tracker name
+	latency map[string]*LatencyTracker
 }
 
 // NewMetricsCollector creates a new metrics collector
@@ -49,7 +140,16 @@ func NewMetricsCollector() *MetricsCollector {
 		metrics: &Metrics{
 			MinResponseTime: time.Duration(^uint64(0) >> 1), // Max duration
 		},
-		startTime: time.Now(),
+		startTime:  time.Now(),
+		tenants:    make(map[string]*TenantMetrics),
+		httpRoutes: make(map[httpRoute]*httpRouteMetrics),
+		rpcMethods: make(map[string]*rpcMethodMetrics),
+		latency: map[string]*LatencyTracker{
+			LatencyHTTP:        NewLatencyTracker(),
+			LatencyRPC:         NewLatencyTracker(),
+			LatencyMutexWait:   NewLatencyTracker(),
+			LatencyCacheLookup: NewLatencyTracker(),
+		},
 	}
 }
 
@@ -69,6 +169,8 @@ func (mc *MetricsCollector) RecordRequestComplete(duration time.Duration, succes
 		atomic.AddInt64(&mc.metrics.FailedRequests, 1)
 	}
 
+	mc.latency[LatencyHTTP].Observe(duration)
+
 	// Update response time metrics
 	mc.metrics.mutex.Lock()
 	defer mc.metrics.mutex.Unlock()
@@ -83,10 +185,10 @@ func (mc *MetricsCollector) RecordRequestComplete(duration time.Duration, succes
 		mc.metrics.MaxResponseTime = duration
 	}
 
-	// Calculate average
-	totalRequests := atomic.LoadInt64(&mc.metrics.TotalRequests)
-	if totalRequests > 0 {
-		mc.metrics.AverageResponseTime = mc.metrics.totalResponseTime / time.Duration(totalRequests)
+	// Average over completed requests; in-flight requests have no duration yet
+	completed := mc.completedRequests()
+	if completed > 0 {
+		mc.metrics.AverageResponseTime = mc.metrics.totalResponseTime / time.Duration(completed)
 	}
 }
 
@@ -100,8 +202,55 @@ func (mc *MetricsCollector) RecordCacheMiss() {
 	atomic.AddInt64(&mc.metrics.CacheMisses, 1)
 }
 
-// RecordRPCCall records an RPC call
+// RecordHTTPRequest records a completed HTTP request by method, route template and
+// status. An empty route (no route matched) is recorded as "unknown" and a
+// non-standard method as "other".
+func (mc *MetricsCollector) RecordHTTPRequest(method, route string, status int, duration time.Duration) {
+	if !standardMethods[method] {
+		method = otherLabel
+	}
+	if route == "" {
+		route = unknownLabel
+	}
+	key := httpRoute{method: method, route: route}
+
+	mc.seriesMutex.Lock()
+	series, exists := mc.httpRoutes[key]
+	if !exists {
+		series = &httpRouteMetrics{
+			statuses: make(map[int]int64),
+			duration: NewHistogram(DefaultLatencyBuckets),
+		}
+		mc.httpRoutes[key] = series
+	}
+	series.statuses[status]++
+	mc.seriesMutex.Unlock()
+
+	series.duration.ObserveDuration(duration)
+}
+
+// RecordRPCCall records an RPC call without a method label
 func (mc *MetricsCollector) RecordRPCCall(duration time.Duration, success bool) {
+	mc.RecordRPCMethodCall(unknownLabel, duration, success)
+}
+
+// RecordRPCMethodCall records an RPC call for the given RPC method
+func (mc *MetricsCollector) RecordRPCMethodCall(method string, duration time.Duration, success bool) {
+	mc.seriesMutex.Lock()
+	series, exists := mc.rpcMethods[method]
+	if !exists {
+		series = &rpcMethodMetrics{duration: NewHistogram(DefaultLatencyBuckets)}
+		mc.rpcMethods[method] = series
+	}
+	series.calls++
+	if !success {
+		series.failures++
+	}
+	mc.seriesMutex.Unlock()
+
+	series.duration.ObserveDuration(duration)
+	mc.latency[LatencyRPC].Observe(duration)
+
 	atomic.AddInt64(&mc.metrics.RPCCalls, 1)
 
 	if !success {
@@ -121,11 +270,140 @@ func (mc *MetricsCollector) RecordRPCCall(duration time.Duration, success bool)
 	}
 }
 
+// RecordTenantRequest records a completed request for a tenant
+func (mc *MetricsCollector) RecordTenantRequest(tenantID string, success bool) {
+	if tenantID == "" {
+		return
+	}
+
+	mc.tenantMutex.Lock()
+	defer mc.tenantMutex.Unlock()
+
+	tenant := mc.tenantLocked(tenantID)
+	tenant.Requests++
+	if !success {
+		tenant.FailedRequests++
+	}
+}
+
+// RecordTenantUsage records wallets queried and RPC calls made on behalf of a tenant
+func (mc *MetricsCollector) RecordTenantUsage(tenantID string, wallets, rpcCalls int) {
+	if tenantID == "" {
+		return
+	}
+
+	mc.tenantMutex.Lock()
+	defer mc.tenantMutex.Unlock()
+
+	tenant := mc.tenantLocked(tenantID)
+	tenant.Wallets += int64(wallets)
+	tenant.RPCCalls += int64(rpcCalls)
+}
+
+// tenantLocked returns the counters for a tenant. Caller must hold tenantMutex.
+func (mc *MetricsCollector) tenantLocked(tenantID string) *TenantMetrics {
+	tenant, exists := mc.tenants[tenantID]
+	if !exists {
+		tenant = &TenantMetrics{}
+		mc.tenants[tenantID] = tenant
+	}
+	return tenant
+}
+
+// GetTenantMetrics returns a copy of the per-tenant counters
+func (mc *MetricsCollector) GetTenantMetrics() map[string]TenantMetrics {
+	mc.tenantMutex.Lock()
+	defer mc.tenantMutex.Unlock()
+
+	tenants := make(map[string]TenantMetrics, len(mc.tenants))
+	for tenantID, tenant := range mc.tenants {
+		tenants[tenantID] = *tenant
+	}
+	return tenants
+}
+
+// GetHTTPSeries returns a snapshot of the per-route HTTP series, sorted by route and method
+func (mc *MetricsCollector) GetHTTPSeries() []HTTPSeries {
+	mc.seriesMutex.Lock()
+	defer mc.seriesMutex.Unlock()
+
+	series := make([]HTTPSeries, 0, len(mc.httpRoutes))
+	for key, route := range mc.httpRoutes {
+		statuses := make(map[int]int64, len(route.statuses))
+		for status, count := range route.statuses {
+			statuses[status] = count
+		}
+		series = append(series, HTTPSeries{
+			Method:   key.method,
+			Route:    key.route,
+			Statuses: statuses,
+			Duration: route.duration.Snapshot(),
+		})
+	}
+
+	sort.Slice(series, func(i, j int) bool {
+		if series[i].Route != series[j].Route {
+			return series[i].Route < series[j].Route
+		}
+		return series[i].Method < series[j].Method
+	})
+	return series
+}
+
+// GetRPCSeries returns a snapshot of the per-method RPC series, sorted by method
+func (mc *MetricsCollector) GetRPCSeries() []RPCSeries {
+	mc.seriesMutex.Lock()
+	defer mc.seriesMutex.Unlock()
+
+	series := make([]RPCSeries, 0, len(mc.rpcMethods))
+	for method, rpc := range mc.rpcMethods {
+		series = append(series, RPCSeries{
+			Method:   method,
+			Calls:    rpc.calls,
+			Failures: rpc.failures,
+			Duration: rpc.duration.Snapshot(),
+		})
+	}
+
+	sort.Slice(series, func(i, j int) bool {
+		return series[i].Method < series[j].Method
+	})
+	return series
+}
+
 // RecordMutexWait records a mutex wait
 func (mc *MetricsCollector) RecordMutexWait() {
 	atomic.AddInt64(&mc.metrics.MutexWaits, 1)
 }
 
+// RecordMutexWaitTime records the time spent acquiring a per-wallet mutex. Waits
+// longer than 1ms are also counted in MutexWaits.
+func (mc *MetricsCollector) RecordMutexWaitTime(wait time.Duration) {
+	mc.latency[LatencyMutexWait].Observe(wait)
+	if wait > time.Millisecond {
+		mc.RecordMutexWait()
+	}
+}
+
+// RecordCacheLookup records the time spent on a cache lookup
+func (mc *MetricsCollector) RecordCacheLookup(duration time.Duration) {
+	mc.latency[LatencyCacheLookup].Observe(duration)
+}
+
+// GetLatencySummaries returns latency quantiles for every tracker and window
+func (mc *MetricsCollector) GetLatencySummaries() map[string]map[string]LatencySummary {
+	summaries := make(map[string]map[string]LatencySummary, len(mc.latency))
+	for name, tracker := range mc.latency {
+		summaries[name] = tracker.Summaries()
+	}
+	return summaries
+}
+
+// completedRequests returns the number of requests that have finished
+func (mc *MetricsCollector) completedRequests() int64 {
+	return atomic.LoadInt64(&mc.metrics.SuccessfulRequests) + atomic.LoadInt64(&mc.metrics.FailedRequests)
+}
+
 // GetMetrics returns a copy of current metrics
 func (mc *MetricsCollector) GetMetrics() *Metrics {
 	mc.metrics.mutex.RLock()
@@ -146,6 +424,8 @@ func (mc *MetricsCollector) GetMetrics() *Metrics {
 		AverageRPCTime:      mc.metrics.AverageRPCTime,
 		ActiveRequests:      atomic.LoadInt64(&mc.metrics.ActiveRequests),
 		MutexWaits:          atomic.LoadInt64(&mc.metrics.MutexWaits),
+		Tenants:             mc.GetTenantMetrics(),
+		Latency:             mc.GetLatencySummaries(),
 	}
 }
 
@@ -176,6 +456,19 @@ func (mc *MetricsCollector) Reset() {
 	mc.metrics.totalResponseTime = 0
 	mc.metrics.totalRPCTime = 0
 
+	mc.tenantMutex.Lock()
+	mc.tenants = make(map[string]*TenantMetrics)
+	mc.tenantMutex.Unlock()
+
+	mc.seriesMutex.Lock()
+	mc.httpRoutes = make(map[httpRoute]*httpRouteMetrics)
+	mc.rpcMethods = make(map[string]*rpcMethodMetrics)
+	mc.seriesMutex.Unlock()
+
+	for _, tracker := range mc.latency {
+		tracker.Reset()
+	}
+
 	mc.startTime = time.Now()
 }
 
@@ -192,10 +485,10 @@ func (mc *MetricsCollector) GetCacheHitRatio() float64 {
 	return float64(hits) / float64(total) * 100.0
 }
 
-// GetSuccessRate returns the success rate as a percentage
+// GetSuccessRate returns the success rate of completed requests as a percentage
 func (mc *MetricsCollector) GetSuccessRate() float64 {
 	successful := atomic.LoadInt64(&mc.metrics.SuccessfulRequests)
-	total := atomic.LoadInt64(&mc.metrics.TotalRequests)
+	total := mc.completedRequests()
 
 	if total == 0 {
 		return 0.0
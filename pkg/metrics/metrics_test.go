@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -89,3 +90,51 @@ func TestMetricsCollector(t *testing.T) {
 		assert.Equal(t, int64(0), metrics.RPCCalls)
 	})
 }
+
+func TestNegotiateFormat(t *testing.T) {
+	assert.Equal(t, FormatJSON, NegotiateFormat(""))
+	assert.Equal(t, FormatJSON, NegotiateFormat("*/*"))
+	assert.Equal(t, FormatJSON, NegotiateFormat("application/json"))
+	assert.Equal(t, FormatPrometheus, NegotiateFormat("text/plain; version=0.0.4"))
+	assert.Equal(t, FormatOpenMetrics, NegotiateFormat("application/openmetrics-text;version=1.0.0,application/openmetrics-text;version=0.0.1;q=0.75,text/plain;version=0.0.4;q=0.5,*/*;q=0.1"))
+	assert.Equal(t, FormatPrometheus, NegotiateFormat("application/openmetrics-text;q=0.2,text/plain;q=0.9"))
+}
+
+func TestWriteExposition(t *testing.T) {
+	collector := NewMetricsCollector()
+	collector.RecordRequest()
+	collector.RecordRequestComplete(30*time.Millisecond, true)
+	collector.RecordHTTPRequest("POST", "/api/get-balance", 200, 30*time.Millisecond)
+	collector.RecordHTTPRequest("POST", "/api/get-balance", 429, time.Millisecond)
+	collector.RecordHTTPRequest("X-RANDOM-1", "", 404, time.Millisecond)
+	collector.RecordHTTPRequest("X-RANDOM-2", "", 404, time.Millisecond)
+	collector.RecordRPCMethodCall("getAccountInfo", 200*time.Millisecond, false)
+	collector.RecordTenantRequest(`team"a`, true)
+
+	t.Run("Prometheus", func(t *testing.T) {
+		var out strings.Builder
+		assert.NoError(t, collector.WriteExposition(&out, FormatPrometheus))
+		text := out.String()
+
+		assert.Contains(t, text, "# TYPE solana_api_requests_total counter\nsolana_api_requests_total 1\n")
+		assert.Contains(t, text, `solana_api_http_requests_total{method="POST",route="/api/get-balance",status="429"} 1`)
+		assert.Contains(t, text, `solana_api_http_request_duration_seconds_bucket{method="POST",route="/api/get-balance",le="0.05"} 2`)
+		assert.Contains(t, text, `solana_api_http_request_duration_seconds_bucket{method="POST",route="/api/get-balance",le="0.025"} 1`)
+		assert.Contains(t, text, `solana_api_http_request_duration_seconds_count{method="POST",route="/api/get-balance"} 2`)
+		assert.Contains(t, text, `solana_api_http_requests_total{method="other",route="unknown",status="404"} 2`)
+		assert.NotContains(t, text, "X-RANDOM")
+		assert.Contains(t, text, `solana_api_rpc_failures_total{method="getAccountInfo"} 1`)
+		assert.Contains(t, text, `solana_api_rpc_duration_seconds_bucket{method="getAccountInfo",le="+Inf"} 1`)
+		assert.Contains(t, text, `solana_api_tenant_requests_total{tenant="team\"a"} 1`)
+		assert.NotContains(t, text, "# EOF")
+	})
+
+	t.Run("OpenMetrics", func(t *testing.T) {
+		var out strings.Builder
+		assert.NoError(t, collector.WriteExposition(&out, FormatOpenMetrics))
+		text := out.String()
+
+		assert.Contains(t, text, "# TYPE solana_api_requests counter\nsolana_api_requests_total 1\n")
+		assert.True(t, strings.HasSuffix(text, "# EOF\n"))
+	})
+}
@@ -0,0 +1,224 @@
+package metrics
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Latency tracker layout: log-linear buckets with 5% relative width from 1µs, kept in
+// 10-second slots spanning the longest window. Quantile estimates are accurate to
+// about ±2.5%.
+const (
+	latencyGrowth     = 1.05
+	latencyBuckets    = 460 // 1µs * 1.05^460 is about 93 minutes; longer latencies share the last bucket
+	latencySlot       = 10 * time.Second
+	latencyMaxWindow  = 15 * time.Minute
+	latencySlotsCount = int(latencyMaxWindow / latencySlot)
+)
+
+// Sliding windows reported for every latency tracker
+var LatencyWindows = []struct {
+	Name     string
+	Duration time.Duration
+}{
+	{"1m", time.Minute},
+	{"5m", 5 * time.Minute},
+	{"15m", 15 * time.Minute},
+}
+
+// logGrowth caches the bucket growth factor's logarithm
+var logGrowth = math.Log(latencyGrowth)
+
+// LatencySummary holds quantile estimates over one window, in milliseconds
+type LatencySummary struct {
+	Count  uint64  `json:"count"`
+	MeanMs float64 `json:"mean_ms"`
+	P50Ms  float64 `json:"p50_ms"`
+	P95Ms  float64 `json:"p95_ms"`
+	P99Ms  float64 `json:"p99_ms"`
+	MaxMs  float64 `json:"max_ms"`
+}
+
+// latencySlotData holds the observations of one time slot
+type latencySlotData struct {
+	index  int64 // Slot number since the epoch; identifies stale ring entries
+	counts [latencyBuckets + 1]uint64
+	count  uint64
+	sum    time.Duration
+	max    time.Duration
+}
+
+// LatencyTracker estimates latency quantiles over sliding time windows using
+// HDR-style log-linear buckets. Memory is fixed regardless of traffic.
+type LatencyTracker struct {
+	slots []latencySlotData
+	mutex sync.Mutex
+	now   func() time.Time
+}
+
+// NewLatencyTracker creates a latency tracker covering the last 15 minutes
+func NewLatencyTracker() *LatencyTracker {
+	return &LatencyTracker{
+		slots: make([]latencySlotData, latencySlotsCount),
+		now:   time.Now,
+	}
+}
+
+// Observe records a latency
+func (lt *LatencyTracker) Observe(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	bucket := latencyBucket(d)
+
+	lt.mutex.Lock()
+	defer lt.mutex.Unlock()
+
+	slot := lt.slotLocked(lt.now())
+	slot.counts[bucket]++
+	slot.count++
+	slot.sum += d
+	if d > slot.max {
+		slot.max = d
+	}
+}
+
+// slotLocked returns the ring entry for the given time, clearing it if it holds an
+// older slot. Caller must hold mutex.
+func (lt *LatencyTracker) slotLocked(now time.Time) *latencySlotData {
+	index := now.UnixNano() / int64(latencySlot)
+	slot := &lt.slots[index%int64(len(lt.slots))]
+	if slot.index != index {
+		*slot = latencySlotData{index: index}
+	}
+	return slot
+}
+
+// Summary returns quantile estimates for observations within the window
+func (lt *LatencyTracker) Summary(window time.Duration) LatencySummary {
+	slots, current := lt.snapshot(window)
+	return summarize(slots, current, window)
+}
+
+// Summaries returns the summary for every window in LatencyWindows, keyed by name.
+// All windows are computed from a single snapshot.
+func (lt *LatencyTracker) Summaries() map[string]LatencySummary {
+	slots, current := lt.snapshot(latencyMaxWindow)
+	summaries := make(map[string]LatencySummary, len(LatencyWindows))
+	for _, window := range LatencyWindows {
+		summaries[window.Name] = summarize(slots, current, window.Duration)
+	}
+	return summaries
+}
+
+// snapshot copies the non-empty slots within the window, along with the current
+// slot number. Only the copy happens under the mutex so Observe is not held up
+// while quantiles are computed.
+func (lt *LatencyTracker) snapshot(window time.Duration) ([]latencySlotData, int64) {
+	lt.mutex.Lock()
+	defer lt.mutex.Unlock()
+
+	current := lt.now().UnixNano() / int64(latencySlot)
+	oldest := current - int64(window/latencySlot) + 1
+	slots := make([]latencySlotData, 0, len(lt.slots))
+	for i := range lt.slots {
+		slot := &lt.slots[i]
+		if slot.count == 0 || slot.index < oldest || slot.index > current {
+			continue
+		}
+		slots = append(slots, *slot)
+	}
+	return slots, current
+}
+
+// summarize computes quantile estimates over the slots that fall within the window
+// ending at the current slot
+func summarize(slots []latencySlotData, current int64, window time.Duration) LatencySummary {
+	var counts [latencyBuckets + 1]uint64
+	var summary LatencySummary
+	var sum, max time.Duration
+
+	oldest := current - int64(window/latencySlot) + 1
+	for i := range slots {
+		slot := &slots[i]
+		if slot.index < oldest || slot.index > current {
+			continue
+		}
+		for bucket, count := range slot.counts {
+			counts[bucket] += count
+		}
+		summary.Count += slot.count
+		sum += slot.sum
+		if slot.max > max {
+			max = slot.max
+		}
+	}
+
+	if summary.Count == 0 {
+		return summary
+	}
+
+	summary.MeanMs = durationMs(sum / time.Duration(summary.Count))
+	summary.MaxMs = durationMs(max)
+	summary.P50Ms = math.Min(quantileMs(counts[:], summary.Count, 0.50), summary.MaxMs)
+	summary.P95Ms = math.Min(quantileMs(counts[:], summary.Count, 0.95), summary.MaxMs)
+	summary.P99Ms = math.Min(quantileMs(counts[:], summary.Count, 0.99), summary.MaxMs)
+	return summary
+}
+
+// Reset discards all observations
+func (lt *LatencyTracker) Reset() {
+	lt.mutex.Lock()
+	defer lt.mutex.Unlock()
+
+	for i := range lt.slots {
+		lt.slots[i] = latencySlotData{}
+	}
+}
+
+// latencyBucket maps a duration to its bucket. Bucket 0 holds values below 1µs;
+// bucket i holds values in [1.05^(i-1), 1.05^i) µs.
+func latencyBucket(d time.Duration) int {
+	micros := float64(d) / float64(time.Microsecond)
+	if micros < 1 {
+		return 0
+	}
+	bucket := 1 + int(math.Log(micros)/logGrowth)
+	if bucket > latencyBuckets {
+		bucket = latencyBuckets
+	}
+	return bucket
+}
+
+// quantileMs returns the representative value, in milliseconds, of the bucket that
+// holds the q-quantile observation
+func quantileMs(counts []uint64, total uint64, q float64) float64 {
+	rank := uint64(math.Ceil(q * float64(total)))
+	if rank == 0 {
+		rank = 1
+	}
+
+	var seen uint64
+	for bucket, count := range counts {
+		seen += count
+		if seen >= rank {
+			return bucketMidpointMs(bucket)
+		}
+	}
+	return bucketMidpointMs(len(counts) - 1)
+}
+
+// bucketMidpointMs returns the geometric midpoint of a bucket in milliseconds
+func bucketMidpointMs(bucket int) float64 {
+	if bucket == 0 {
+		return 0.0005
+	}
+	lower := math.Pow(latencyGrowth, float64(bucket-1))
+	return lower * math.Sqrt(latencyGrowth) / 1000
+}
+
+// durationMs converts a duration to fractional milliseconds
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
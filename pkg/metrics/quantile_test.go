@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatencyTracker(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := NewLatencyTracker()
+	tracker.now = func() time.Time { return now }
+
+	t.Run("Empty", func(t *testing.T) {
+		assert.Equal(t, LatencySummary{}, tracker.Summary(time.Minute))
+	})
+
+	t.Run("Quantiles", func(t *testing.T) {
+		// 1..100ms, one observation each
+		for i := 1; i <= 100; i++ {
+			tracker.Observe(time.Duration(i) * time.Millisecond)
+		}
+
+		summary := tracker.Summary(time.Minute)
+		assert.Equal(t, uint64(100), summary.Count)
+		assert.InDelta(t, 50.5, summary.MeanMs, 0.01)
+		assert.InEpsilon(t, 50, summary.P50Ms, 0.05)
+		assert.InEpsilon(t, 95, summary.P95Ms, 0.05)
+		assert.InEpsilon(t, 99, summary.P99Ms, 0.05)
+		assert.Equal(t, 100.0, summary.MaxMs)
+	})
+
+	t.Run("Windows", func(t *testing.T) {
+		// Two minutes later only the 5m and 15m windows still see the observations
+		now = now.Add(2 * time.Minute)
+		tracker.Observe(time.Second)
+
+		assert.Equal(t, uint64(1), tracker.Summary(time.Minute).Count)
+		assert.Equal(t, uint64(101), tracker.Summary(5*time.Minute).Count)
+
+		summaries := tracker.Summaries()
+		assert.Equal(t, uint64(1), summaries["1m"].Count)
+		assert.InEpsilon(t, 1000, summaries["1m"].P99Ms, 0.05)
+		assert.Equal(t, uint64(101), summaries["15m"].Count)
+	})
+
+	t.Run("Expiry", func(t *testing.T) {
+		now = now.Add(20 * time.Minute)
+		assert.Equal(t, uint64(0), tracker.Summary(15*time.Minute).Count)
+	})
+}
+
+func TestLatencyTrackerLongLatencies(t *testing.T) {
+	tracker := NewLatencyTracker()
+
+	// Stuck requests up to 80 minutes keep their own buckets
+	for _, d := range []time.Duration{10 * time.Minute, 80 * time.Minute} {
+		assert.Less(t, latencyBucket(d), latencyBuckets, d.String())
+	}
+
+	tracker.Observe(30 * time.Minute)
+	summary := tracker.Summary(time.Minute)
+	assert.InEpsilon(t, 30*60*1000, summary.P99Ms, 0.05)
+	assert.Equal(t, float64(30*60*1000), summary.MaxMs)
+}
+
+func TestLatencyTrackerConcurrentSummaries(t *testing.T) {
+	tracker := NewLatencyTracker()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				tracker.Observe(time.Millisecond)
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		tracker.Summaries()
+	}
+	wg.Wait()
+
+	assert.Equal(t, uint64(4000), tracker.Summaries()["15m"].Count)
+}
+
+func TestAverageResponseTimeUsesCompletedRequests(t *testing.T) {
+	collector := NewMetricsCollector()
+
+	// One request still in flight must not dilute the average
+	collector.RecordRequest()
+	collector.RecordRequest()
+	collector.RecordRequestComplete(40*time.Millisecond, true)
+
+	metrics := collector.GetMetrics()
+	assert.Equal(t, 40*time.Millisecond, metrics.AverageResponseTime)
+	assert.Equal(t, uint64(1), metrics.Latency[LatencyHTTP]["1m"].Count)
+	assert.Equal(t, 100.0, collector.GetSuccessRate())
+}
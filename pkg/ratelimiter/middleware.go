@@ -8,49 +8,184 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// Middleware creates a Gin middleware for rate limiting
+// Gin context key used to charge additional units from handlers
+const bindingsContextKey = "rate_limit_bindings"
+
+// binding ties a rate limiter to the client key it charges for the current request
+type binding struct {
+	limiter *RateLimiter
+	client  string
+	cost    int // Units charged to this limiter for the current request
+}
+
+// ExceededFunc is called when a client is first rejected within a window, e.g. to
+// audit the client being locked out until resetTime
+type ExceededFunc func(c *gin.Context, client string, cost int, resetTime time.Time)
+
+// OnExceeded sets the function called on a client's first rejection in each window.
+// It must be set before the limiter starts serving requests.
+func (rl *RateLimiter) OnExceeded(fn ExceededFunc) {
+	rl.onExceeded = fn
+}
+
+// RejectFunc writes the response for a rejected request, e.g. in the application's
+// error format. details describes the exceeded limit.
+type RejectFunc func(c *gin.Context, details string, retryAfter time.Duration)
+
+// OnReject sets the function writing 429 responses instead of the built-in body.
+// It must be set before the limiter starts serving requests.
+func (rl *RateLimiter) OnReject(fn RejectFunc) {
+	rl.onReject = fn
+}
+
+// Middleware creates a Gin middleware for rate limiting by client IP
 func (rl *RateLimiter) Middleware() gin.HandlerFunc {
+	return rl.KeyedMiddleware(func(c *gin.Context) string {
+		return c.ClientIP()
+	})
+}
+
+// KeyedMiddleware creates a Gin middleware that rate limits by the key returned from
+// keyFunc. Requests for which keyFunc returns "" are not limited by this limiter.
+// Several keyed middlewares can be chained; handler charges apply to all of them.
+func (rl *RateLimiter) KeyedMiddleware(keyFunc func(*gin.Context) string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Get client IP
-		clientIP := c.ClientIP()
-
-		// Check if request is allowed
-		if !rl.IsAllowed(clientIP) {
-			// Get current request info for headers
-			_, resetTime := rl.GetRequestInfo(clientIP)
-
-			// Set rate limit headers
-			c.Header("X-RateLimit-Limit", strconv.Itoa(rl.limit))
-			c.Header("X-RateLimit-Remaining", "0")
-			c.Header("X-RateLimit-Reset", strconv.FormatInt(resetTime.Unix(), 10))
-			c.Header("Retry-After", strconv.Itoa(int(time.Until(resetTime).Seconds())))
-
-			// Return 429 Too Many Requests
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error": gin.H{
-					"code":    "RATE_LIMIT_EXCEEDED",
-					"message": "Too many requests. Rate limit exceeded.",
-					"details": "Maximum " + strconv.Itoa(rl.limit) + " requests per minute allowed.",
-				},
-				"timestamp": time.Now().UTC().Format(time.RFC3339),
-			})
-			c.Abort()
+		client := keyFunc(c)
+		if client == "" {
+			c.Next()
 			return
 		}
 
-		// Get current request info for headers
-		count, resetTime := rl.GetRequestInfo(clientIP)
-		remaining := rl.limit - count
-		if remaining < 0 {
-			remaining = 0
+		// Check if the admission cost is covered by the remaining budget
+		cost := rl.Costs().Request
+		if !rl.AllowN(client, cost) {
+			rl.abortRateLimited(c, client, cost)
+			return
 		}
 
+		// Expose the limiter so handlers can charge request-specific costs
+		bindings := append(bindingsFromContext(c), &binding{limiter: rl, client: client, cost: cost})
+		c.Set(bindingsContextKey, bindings)
+
 		// Set rate limit headers for successful requests
-		c.Header("X-RateLimit-Limit", strconv.Itoa(rl.limit))
-		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
-		c.Header("X-RateLimit-Reset", strconv.FormatInt(resetTime.Unix(), 10))
+		setHeaders(c, bindings)
 
 		// Continue to next handler
 		c.Next()
 	}
 }
+
+// ChargeWallets charges the wallet cost for n addresses against the caller's budgets.
+// It returns false and aborts with 429 when any remaining budget cannot cover the
+// charge; nothing is consumed in that case. It is a no-op without the middleware.
+func ChargeWallets(c *gin.Context, n int) bool {
+	bindings := bindingsFromContext(c)
+	if len(bindings) == 0 {
+		return true
+	}
+
+	costs := make([]int, len(bindings))
+	for i, b := range bindings {
+		costs[i] = b.limiter.Costs().Wallet * n
+		if !b.limiter.AllowN(b.client, costs[i]) {
+			// Refund the budgets that were already charged
+			for j, charged := range bindings[:i] {
+				charged.limiter.Refund(charged.client, costs[j])
+			}
+			b.limiter.abortRateLimited(c, b.client, costs[i])
+			return false
+		}
+	}
+
+	for i, b := range bindings {
+		b.cost += costs[i]
+	}
+	setHeaders(c, bindings)
+	return true
+}
+
+// ChargeRPCMisses charges the RPC miss cost for n cache misses. The charge is applied
+// unconditionally because the upstream RPC credits have already been spent.
+// Must be called before the response is written so headers reflect the charge.
+func ChargeRPCMisses(c *gin.Context, n int) {
+	bindings := bindingsFromContext(c)
+	if len(bindings) == 0 || n <= 0 {
+		return
+	}
+
+	for _, b := range bindings {
+		cost := b.limiter.Costs().RPCMiss * n
+		b.limiter.Consume(b.client, cost)
+		b.cost += cost
+	}
+
+	setHeaders(c, bindings)
+}
+
+// bindingsFromContext returns the limiters stored by the middlewares
+func bindingsFromContext(c *gin.Context) []*binding {
+	value, exists := c.Get(bindingsContextKey)
+	if !exists {
+		return nil
+	}
+	bindings, _ := value.([]*binding)
+	return bindings
+}
+
+// setHeaders writes the budget state of the most constrained limiter to the
+// X-RateLimit-* headers
+func setHeaders(c *gin.Context, bindings []*binding) {
+	tightest := bindings[0]
+	for _, b := range bindings[1:] {
+		if b.limiter.Remaining(b.client) < tightest.limiter.Remaining(tightest.client) {
+			tightest = b
+		}
+	}
+
+	rl, client := tightest.limiter, tightest.client
+	_, resetTime := rl.GetRequestInfo(client)
+
+	c.Header("X-RateLimit-Limit", strconv.Itoa(rl.LimitFor(client)))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(rl.Remaining(client)))
+	c.Header("X-RateLimit-Reset", strconv.FormatInt(resetTime.Unix(), 10))
+	c.Header("X-RateLimit-Cost", strconv.Itoa(tightest.cost))
+}
+
+// abortRateLimited responds with 429 for a request whose cost exceeds the remaining budget
+func (rl *RateLimiter) abortRateLimited(c *gin.Context, client string, cost int) {
+	// Get current request info for headers
+	_, resetTime := rl.GetRequestInfo(client)
+	limit := rl.LimitFor(client)
+
+	// Set rate limit headers
+	c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(rl.Remaining(client)))
+	c.Header("X-RateLimit-Reset", strconv.FormatInt(resetTime.Unix(), 10))
+	c.Header("X-RateLimit-Cost", strconv.Itoa(cost))
+	retryAfter := int(time.Until(resetTime).Seconds())
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+
+	if rl.onExceeded != nil && rl.markExceeded(client) {
+		rl.onExceeded(c, client, cost, resetTime)
+	}
+
+	details := "Request costs " + strconv.Itoa(cost) + " units; maximum " + strconv.Itoa(limit) + " units per " + rl.Window().String() + " allowed."
+	if rl.onReject != nil {
+		rl.onReject(c, details, time.Until(resetTime))
+		c.Abort()
+		return
+	}
+
+	// Return 429 Too Many Requests
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error": gin.H{
+			"code":        "RATE_LIMIT_EXCEEDED",
+			"message":     "Too many requests. Rate limit exceeded.",
+			"details":     details,
+			"retryable":   true,
+			"retry_after": retryAfter,
+		},
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	c.Abort()
+}
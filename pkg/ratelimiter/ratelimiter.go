@@ -5,66 +5,212 @@ import (
 	"time"
 )
 
-// RequestCounter tracks request count and reset time for an IP
+// RequestCounter tracks consumed units and reset time for an IP
 type RequestCounter struct {
 	Count     int
 	ResetTime time.Time
+	Exceeded  bool // Set once the client has been rejected in the current window
 }
 
-// RateLimiter implements IP-based rate limiting with in-memory tracking
+// Costs defines how many rate limit units each part of a request consumes
+type Costs struct {
+	Request int `json:"request"`  // Charged on admission for every request
+	Wallet  int `json:"wallet"`   // Charged per wallet address in a balance request
+	RPCMiss int `json:"rpc_miss"` // Charged per cache miss that reaches the RPC
+}
+
+// DefaultCosts returns the default cost model
+func DefaultCosts() Costs {
+	return Costs{
+		Request: 1,
+		Wallet:  1,
+		RPCMiss: 1,
+	}
+}
+
+// RateLimiter implements cost-weighted, IP-based rate limiting with in-memory tracking.
+// Every client gets a budget of limit units per window; requests consume units
+// according to the configured Costs.
 type RateLimiter struct {
 	requests map[string]*RequestCounter
+	limits   map[string]int // Per-client limit overrides
 	mutex    sync.RWMutex
 	limit    int
 	window   time.Duration
+	costs    Costs
+
+	onExceeded ExceededFunc
+	onReject   RejectFunc
 }
 
-// New creates a new RateLimiter with specified limit and window
+// New creates a new RateLimiter with specified unit limit and window using the default costs
 func New(limit int, window time.Duration) *RateLimiter {
+	return NewWithCosts(limit, window, DefaultCosts())
+}
+
+// NewWithCosts creates a new RateLimiter with specified unit limit, window and cost model
+func NewWithCosts(limit int, window time.Duration, costs Costs) *RateLimiter {
 	return &RateLimiter{
 		requests: make(map[string]*RequestCounter),
+		limits:   make(map[string]int),
 		limit:    limit,
 		window:   window,
+		costs:    costs,
+	}
+}
+
+// Costs returns the cost model used by the rate limiter
+func (rl *RateLimiter) Costs() Costs {
+	rl.mutex.RLock()
+	defer rl.mutex.RUnlock()
+
+	return rl.costs
+}
+
+// Limit returns the default number of units available per window
+func (rl *RateLimiter) Limit() int {
+	rl.mutex.RLock()
+	defer rl.mutex.RUnlock()
+
+	return rl.limit
+}
+
+// Window returns the length of a rate limit window
+func (rl *RateLimiter) Window() time.Duration {
+	rl.mutex.RLock()
+	defer rl.mutex.RUnlock()
+
+	return rl.window
+}
+
+// Configure replaces the default limit, window and cost model at runtime. A
+// non-positive limit keeps the current default. Per-client overrides and the units
+// already consumed are kept; a new window length applies when each client's
+// current window resets.
+func (rl *RateLimiter) Configure(limit int, window time.Duration, costs Costs) {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	if limit > 0 {
+		rl.limit = limit
 	}
+	rl.window = window
+	rl.costs = costs
 }
 
-// IsAllowed checks if the IP address is allowed to make a request
+// SetLimit overrides the number of units per window for a single client key,
+// e.g. to apply a tenant's rate tier. A non-positive limit removes the override.
+// Cleanup drops overrides of idle clients, so callers reapply them per request.
+func (rl *RateLimiter) SetLimit(client string, limit int) {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	if limit <= 0 {
+		delete(rl.limits, client)
+		return
+	}
+	rl.limits[client] = limit
+}
+
+// LimitFor returns the number of units per window for a client key
+func (rl *RateLimiter) LimitFor(client string) int {
+	rl.mutex.RLock()
+	defer rl.mutex.RUnlock()
+
+	return rl.limitLocked(client)
+}
+
+// limitLocked returns the limit for a client. Caller must hold the lock.
+func (rl *RateLimiter) limitLocked(client string) int {
+	if limit, exists := rl.limits[client]; exists {
+		return limit
+	}
+	return rl.limit
+}
+
+// IsAllowed checks if the IP address is allowed to make a request costing a single unit
 // Returns true if allowed, false if rate limit exceeded
 func (rl *RateLimiter) IsAllowed(ip string) bool {
+	return rl.AllowN(ip, 1)
+}
+
+// AllowN checks if the IP address has n units left in the current window and consumes them.
+// Nothing is consumed when the remaining budget cannot cover n.
+func (rl *RateLimiter) AllowN(ip string, n int) bool {
 	rl.mutex.Lock()
 	defer rl.mutex.Unlock()
 
-	now := time.Now()
+	counter := rl.counterLocked(ip, time.Now())
+
+	// Check if limit would be exceeded
+	if counter.Count+n > rl.limitLocked(ip) {
+		return false
+	}
+
+	// Consume units and allow request
+	counter.Count += n
+	return true
+}
+
+// Consume charges n units to the IP address unconditionally.
+// Used for costs that have already been incurred, such as RPC calls that were made.
+func (rl *RateLimiter) Consume(ip string, n int) {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	counter := rl.counterLocked(ip, time.Now())
+	counter.Count += n
+}
 
-	// Get or create request counter for this IP
+// Refund returns n units charged to the IP address, e.g. when a multi-limiter charge
+// is rejected by another limiter. The count never drops below zero, so a refund
+// arriving after the window reset cannot grant extra units.
+func (rl *RateLimiter) Refund(ip string, n int) {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	counter := rl.counterLocked(ip, time.Now())
+	counter.Count -= n
+	if counter.Count < 0 {
+		counter.Count = 0
+	}
+}
+
+// counterLocked returns the counter for the IP, starting a new window if needed.
+// Caller must hold the write lock.
+func (rl *RateLimiter) counterLocked(ip string, now time.Time) *RequestCounter {
 	counter, exists := rl.requests[ip]
 	if !exists {
-		rl.requests[ip] = &RequestCounter{
-			Count:     1,
-			ResetTime: now.Add(rl.window),
-		}
-		return true
+		counter = &RequestCounter{ResetTime: now.Add(rl.window)}
+		rl.requests[ip] = counter
+		return counter
 	}
 
-	// Check if the window has expired
+	// Reset the counter if the window has expired
 	if now.After(counter.ResetTime) {
-		// Reset the counter for new window
-		counter.Count = 1
+		counter.Count = 0
 		counter.ResetTime = now.Add(rl.window)
-		return true
+		counter.Exceeded = false
 	}
 
-	// Check if limit is exceeded
-	if counter.Count >= rl.limit {
+	return counter
+}
+
+// markExceeded flags the client as rejected in the current window and reports
+// whether this is the first rejection of the window
+func (rl *RateLimiter) markExceeded(ip string) bool {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	counter := rl.counterLocked(ip, time.Now())
+	if counter.Exceeded {
 		return false
 	}
-
-	// Increment counter and allow request
-	counter.Count++
+	counter.Exceeded = true
 	return true
 }
 
-// GetRequestInfo returns current request count and reset time for an IP
+// GetRequestInfo returns units consumed and reset time for an IP
 func (rl *RateLimiter) GetRequestInfo(ip string) (count int, resetTime time.Time) {
 	rl.mutex.RLock()
 	defer rl.mutex.RUnlock()
@@ -82,7 +228,18 @@ func (rl *RateLimiter) GetRequestInfo(ip string) (count int, resetTime time.Time
 	return counter.Count, counter.ResetTime
 }
 
-// Cleanup removes expired entries to prevent memory leaks
+// Remaining returns the number of units left for an IP in the current window
+func (rl *RateLimiter) Remaining(ip string) int {
+	count, _ := rl.GetRequestInfo(ip)
+	remaining := rl.LimitFor(ip) - count
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining
+}
+
+// Cleanup removes expired entries, and the limit overrides of clients without
+// one, to prevent memory leaks
 func (rl *RateLimiter) Cleanup() {
 	rl.mutex.Lock()
 	defer rl.mutex.Unlock()
@@ -93,4 +250,9 @@ func (rl *RateLimiter) Cleanup() {
 			delete(rl.requests, ip)
 		}
 	}
+	for client := range rl.limits {
+		if _, active := rl.requests[client]; !active {
+			delete(rl.limits, client)
+		}
+	}
 }
@@ -0,0 +1,179 @@
+package ratelimiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterCosts(t *testing.T) {
+	rl := NewWithCosts(10, time.Minute, Costs{Request: 1, Wallet: 2, RPCMiss: 3})
+
+	t.Run("AllowNConsumesUnits", func(t *testing.T) {
+		assert.True(t, rl.AllowN("1.1.1.1", 4))
+		assert.Equal(t, 6, rl.Remaining("1.1.1.1"))
+	})
+
+	t.Run("AllowNRejectsWithoutConsuming", func(t *testing.T) {
+		assert.False(t, rl.AllowN("1.1.1.1", 7))
+		assert.Equal(t, 6, rl.Remaining("1.1.1.1"))
+	})
+
+	t.Run("ConsumeIsUnconditional", func(t *testing.T) {
+		rl.Consume("1.1.1.1", 20)
+		assert.Equal(t, 0, rl.Remaining("1.1.1.1"))
+		assert.False(t, rl.IsAllowed("1.1.1.1"))
+	})
+
+	t.Run("IndependentClients", func(t *testing.T) {
+		assert.True(t, rl.IsAllowed("2.2.2.2"))
+		assert.Equal(t, 9, rl.Remaining("2.2.2.2"))
+	})
+
+	t.Run("WindowReset", func(t *testing.T) {
+		short := New(2, 10*time.Millisecond)
+		assert.True(t, short.AllowN("3.3.3.3", 2))
+		assert.False(t, short.IsAllowed("3.3.3.3"))
+
+		time.Sleep(20 * time.Millisecond)
+		assert.True(t, short.IsAllowed("3.3.3.3"))
+	})
+
+	t.Run("RefundAfterWindowReset", func(t *testing.T) {
+		short := New(5, 10*time.Millisecond)
+		assert.True(t, short.AllowN("4.4.4.4", 4))
+
+		// The window resets before the refund; it must not create extra budget
+		time.Sleep(20 * time.Millisecond)
+		short.Refund("4.4.4.4", 4)
+		assert.Equal(t, 5, short.Remaining("4.4.4.4"))
+		assert.False(t, short.AllowN("4.4.4.4", 6))
+	})
+}
+
+func TestRateLimiterPerClientLimits(t *testing.T) {
+	rl := New(10, time.Minute)
+
+	rl.SetLimit("tenant:a", 3)
+	assert.Equal(t, 3, rl.LimitFor("tenant:a"))
+	assert.Equal(t, 10, rl.LimitFor("tenant:b"))
+
+	assert.True(t, rl.AllowN("tenant:a", 3))
+	assert.False(t, rl.IsAllowed("tenant:a"))
+	assert.Equal(t, 0, rl.Remaining("tenant:a"))
+
+	rl.SetLimit("tenant:a", 0)
+	assert.Equal(t, 10, rl.LimitFor("tenant:a"))
+	assert.Equal(t, 7, rl.Remaining("tenant:a"))
+}
+
+func TestCleanupPrunesIdleLimits(t *testing.T) {
+	rl := New(10, 10*time.Millisecond)
+	rl.SetLimit("tenant:idle", 3)
+	rl.SetLimit("tenant:active", 5)
+
+	time.Sleep(20 * time.Millisecond)
+	assert.True(t, rl.IsAllowed("tenant:active"))
+	rl.Cleanup()
+
+	rl.mutex.RLock()
+	defer rl.mutex.RUnlock()
+	assert.NotContains(t, rl.limits, "tenant:idle")
+	assert.Equal(t, 5, rl.limits["tenant:active"])
+}
+
+func TestRateLimiterConfigure(t *testing.T) {
+	rl := New(10, time.Minute)
+	rl.SetLimit("tenant:a", 3)
+	assert.True(t, rl.AllowN("1.1.1.1", 4))
+
+	rl.Configure(20, time.Second, Costs{Request: 2, Wallet: 3, RPCMiss: 4})
+	assert.Equal(t, 20, rl.Limit())
+	assert.Equal(t, time.Second, rl.Window())
+	assert.Equal(t, Costs{Request: 2, Wallet: 3, RPCMiss: 4}, rl.Costs())
+	assert.Equal(t, 16, rl.Remaining("1.1.1.1"), "consumed units survive a reconfiguration")
+	assert.Equal(t, 3, rl.LimitFor("tenant:a"), "per-client overrides survive a reconfiguration")
+
+	rl.Configure(0, time.Minute, DefaultCosts())
+	assert.Equal(t, 20, rl.Limit(), "a non-positive limit keeps the default")
+}
+
+func TestChainedMiddlewaresRefundOnReject(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ipLimiter := New(100, time.Minute)
+	tenantLimiter := New(5, time.Minute)
+
+	engine := gin.New()
+	engine.Use(ipLimiter.Middleware())
+	engine.Use(tenantLimiter.KeyedMiddleware(func(*gin.Context) string { return "tenant:a" }))
+	engine.GET("/", func(c *gin.Context) {
+		if !ChargeWallets(c, 10) {
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "5", w.Header().Get("X-RateLimit-Limit"))
+	// Only the admission unit stays charged on the IP budget
+	assert.Equal(t, 99, ipLimiter.Remaining("192.0.2.1"))
+}
+
+func TestChainedMiddlewaresReportOwnCost(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	keyLimiter := NewWithCosts(100, time.Minute, Costs{Request: 1, Wallet: 1, RPCMiss: 1})
+	tenantLimiter := NewWithCosts(50, time.Minute, Costs{Request: 1, Wallet: 3, RPCMiss: 1})
+
+	engine := gin.New()
+	engine.Use(keyLimiter.KeyedMiddleware(func(*gin.Context) string { return "key:a" }))
+	engine.Use(tenantLimiter.KeyedMiddleware(func(*gin.Context) string { return "tenant:a" }))
+	engine.GET("/", func(c *gin.Context) {
+		if !ChargeWallets(c, 2) {
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	// The tenant budget is tighter, so its limit and cost are reported
+	assert.Equal(t, "50", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "7", w.Header().Get("X-RateLimit-Cost"))
+	assert.Equal(t, 97, keyLimiter.Remaining("key:a"))
+	assert.Equal(t, 43, tenantLimiter.Remaining("tenant:a"))
+}
+
+func TestOnExceededFiresOncePerWindow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	rl := New(1, time.Minute)
+	var calls []string
+	rl.OnExceeded(func(c *gin.Context, client string, cost int, resetTime time.Time) {
+		calls = append(calls, client)
+	})
+
+	engine := gin.New()
+	engine.Use(rl.Middleware())
+	engine.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	codes := []int{}
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		codes = append(codes, w.Code)
+	}
+
+	assert.Equal(t, []int{http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests}, codes)
+	assert.Equal(t, []string{"192.0.2.1"}, calls)
+}
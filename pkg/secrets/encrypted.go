@@ -0,0 +1,108 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KeySize is the length of env-encrypted keys in bytes (AES-256)
+const KeySize = 32
+
+// EncryptedEnvProvider resolves references naming environment variables that hold
+// AES-256-GCM ciphertext, base64 encoded as nonce followed by sealed data
+type EncryptedEnvProvider struct {
+	aead cipher.AEAD
+}
+
+// NewEncryptedEnvProvider creates a provider decrypting with a 32-byte key
+func NewEncryptedEnvProvider(key []byte) (*EncryptedEnvProvider, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedEnvProvider{aead: aead}, nil
+}
+
+// Resolve decrypts the environment variable named by ref
+func (p *EncryptedEnvProvider) Resolve(ref string) (string, error) {
+	ciphertext := os.Getenv(ref)
+	if ciphertext == "" {
+		return "", fmt.Errorf("environment variable %s is not set", ref)
+	}
+	return p.Decrypt(ciphertext)
+}
+
+// Decrypt decrypts a value produced by Encrypt with the same key
+func (p *EncryptedEnvProvider) Decrypt(ciphertext string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(ciphertext))
+	if err != nil {
+		return "", fmt.Errorf("ciphertext is not valid base64: %w", err)
+	}
+
+	nonceSize := p.aead.NonceSize()
+	if len(data) < nonceSize {
+		return "", errors.New("ciphertext is too short")
+	}
+
+	plaintext, err := p.aead.Open(nil, data[:nonceSize], data[nonceSize:], nil)
+	if err != nil {
+		return "", errors.New("failed to decrypt secret: wrong key or corrupted ciphertext")
+	}
+	return string(plaintext), nil
+}
+
+// Encrypt encrypts plaintext for the env-encrypted provider, returning base64
+func Encrypt(key []byte, plaintext string) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// GenerateKey returns a random key, base64 encoded for SECRETS_KEY
+func GenerateKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// DecodeKey decodes a base64 or hex encoded 32-byte key
+func DecodeKey(encoded string) ([]byte, error) {
+	encoded = strings.TrimSpace(encoded)
+	if key, err := base64.StdEncoding.DecodeString(encoded); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	if key, err := hex.DecodeString(encoded); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	return nil, fmt.Errorf("secrets key must be %d bytes, base64 or hex encoded", KeySize)
+}
+
+// newAEAD creates the AES-256-GCM cipher for a key
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("secrets key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,108 @@
+package secrets
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Redacted replaces secret values in logs, status output and configuration dumps
+const Redacted = "REDACTED"
+
+// minTokenLength is the length from which a URL path segment of letters, digits,
+// '-' and '_' is treated as an embedded access token
+const minTokenLength = 20
+
+// credentialParams are query parameters treated as credentials
+var credentialParams = map[string]bool{
+	"api-key":      true,
+	"api_key":      true,
+	"apikey":       true,
+	"key":          true,
+	"token":        true,
+	"access_token": true,
+	"secret":       true,
+	"password":     true,
+}
+
+// RedactURL masks the credentials a URL may carry: the user info password,
+// credential query parameters and token-like path segments, as used by RPC
+// providers that put the access token in the path. Values that do not parse as
+// URLs are masked entirely.
+func RedactURL(value string) string {
+	if value == "" {
+		return value
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Scheme == "" {
+		return Redacted
+	}
+
+	if parsed.User != nil {
+		if _, hasPassword := parsed.User.Password(); hasPassword {
+			parsed.User = url.UserPassword(parsed.User.Username(), Redacted)
+		}
+	}
+
+	query := parsed.Query()
+	changed := false
+	for name := range query {
+		if credentialParams[strings.ToLower(name)] {
+			query.Set(name, Redacted)
+			changed = true
+		}
+	}
+	if changed {
+		parsed.RawQuery = query.Encode()
+	}
+
+	segments := strings.Split(parsed.Path, "/")
+	for i, segment := range segments {
+		if isToken(segment) {
+			segments[i] = Redacted
+		}
+	}
+	parsed.Path = strings.Join(segments, "/")
+	parsed.RawPath = ""
+
+	return parsed.String()
+}
+
+// HasCredentials reports whether a URL carries any of the credentials RedactURL
+// masks: a user info password, a credential query parameter or a token-like path
+// segment
+func HasCredentials(value string) bool {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Scheme == "" {
+		return false
+	}
+
+	if parsed.User != nil {
+		if _, hasPassword := parsed.User.Password(); hasPassword {
+			return true
+		}
+	}
+	for name, values := range parsed.Query() {
+		if credentialParams[strings.ToLower(name)] && len(values) > 0 && values[0] != "" {
+			return true
+		}
+	}
+	for _, segment := range strings.Split(parsed.Path, "/") {
+		if isToken(segment) {
+			return true
+		}
+	}
+	return false
+}
+
+// isToken reports whether a path segment looks like an access token
+func isToken(segment string) bool {
+	if len(segment) < minTokenLength {
+		return false
+	}
+	for _, r := range segment {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
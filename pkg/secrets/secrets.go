@@ -0,0 +1,162 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ReferencePrefix marks a configuration value as a secret reference of the form
+// secret:<provider>:<reference>, e.g. secret:file:/run/secrets/rpc_endpoint
+const ReferencePrefix = "secret:"
+
+// Built-in provider names
+const (
+	ProviderFile         = "file"          // Reference is a file path
+	ProviderEnvEncrypted = "env-encrypted" // Reference names an environment variable holding ciphertext
+)
+
+// Key environment variables for the env-encrypted provider
+const (
+	KeyEnv     = "SECRETS_KEY"      // Base64 or hex encoded 32-byte AES-256 key
+	KeyFileEnv = "SECRETS_KEY_FILE" // File containing the key
+)
+
+// ErrUnknownProvider is returned for references to providers that are not registered
+var ErrUnknownProvider = errors.New("unknown secret provider")
+
+// Provider resolves secret references to their plaintext values
+type Provider interface {
+	Resolve(ref string) (string, error)
+}
+
+// ProviderFunc adapts a function to the Provider interface
+type ProviderFunc func(ref string) (string, error)
+
+// Resolve calls f(ref)
+func (f ProviderFunc) Resolve(ref string) (string, error) {
+	return f(ref)
+}
+
+// Resolver dispatches secret references to registered providers
+type Resolver struct {
+	providers map[string]Provider
+	mutex     sync.RWMutex
+}
+
+// NewResolver creates a resolver with no providers
+func NewResolver() *Resolver {
+	return &Resolver{providers: make(map[string]Provider)}
+}
+
+// NewResolverFromEnv creates a resolver with the file provider and, when a key is
+// configured through SECRETS_KEY or SECRETS_KEY_FILE, the env-encrypted provider
+func NewResolverFromEnv() (*Resolver, error) {
+	resolver := NewResolver()
+	resolver.Register(ProviderFile, FileProvider{})
+
+	key, err := keyFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if key != nil {
+		provider, err := NewEncryptedEnvProvider(key)
+		if err != nil {
+			return nil, err
+		}
+		resolver.Register(ProviderEnvEncrypted, provider)
+	}
+
+	return resolver, nil
+}
+
+// Register adds or replaces the provider for a name
+func (r *Resolver) Register(name string, provider Provider) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.providers[name] = provider
+}
+
+// Providers returns the registered provider names, sorted
+func (r *Resolver) Providers() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsReference reports whether value is a secret reference
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, ReferencePrefix)
+}
+
+// Resolve returns the plaintext of a secret reference. Values that are not
+// references are returned unchanged.
+func (r *Resolver) Resolve(value string) (string, error) {
+	if !IsReference(value) {
+		return value, nil
+	}
+
+	name, ref, found := strings.Cut(strings.TrimPrefix(value, ReferencePrefix), ":")
+	if !found || ref == "" {
+		return "", fmt.Errorf("malformed secret reference (expected %s<provider>:<reference>)", ReferencePrefix)
+	}
+
+	r.mutex.RLock()
+	provider, exists := r.providers[name]
+	r.mutex.RUnlock()
+	if !exists {
+		return "", fmt.Errorf("%w %q", ErrUnknownProvider, name)
+	}
+
+	secret, err := provider.Resolve(ref)
+	if err != nil {
+		return "", fmt.Errorf("secret provider %s: %w", name, err)
+	}
+	return secret, nil
+}
+
+// FileProvider reads secrets from files, such as Docker or Kubernetes secret mounts.
+// Trailing newlines are removed.
+type FileProvider struct{}
+
+// Resolve reads the file at path
+func (FileProvider) Resolve(path string) (string, error) {
+	return ReadFile(path)
+}
+
+// ReadFile reads a secret from a file, removing trailing newlines
+func ReadFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// keyFromEnv loads the env-encrypted key, or returns nil when none is configured
+func keyFromEnv() ([]byte, error) {
+	encoded := os.Getenv(KeyEnv)
+	if path := os.Getenv(KeyFileEnv); path != "" {
+		if encoded != "" {
+			return nil, fmt.Errorf("only one of %s and %s may be set", KeyEnv, KeyFileEnv)
+		}
+		var err error
+		if encoded, err = ReadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if encoded == "" {
+		return nil, nil
+	}
+	return DecodeKey(encoded)
+}
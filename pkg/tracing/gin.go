@@ -0,0 +1,58 @@
+package tracing
+
+import (
+	"net/http"
+
+	"solana-balance-api/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware creates a Gin middleware that starts a server span for every request,
+// continuing the caller's trace when a W3C traceparent header is present. It must
+// run before logger.LoggingMiddleware so request logs carry the trace ID.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unknown"
+		}
+
+		ctx, span := Tracer().Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", c.Request.URL.Path),
+				attribute.String("client.address", c.ClientIP()),
+				attribute.String("user_agent.original", c.Request.UserAgent()),
+			),
+		)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		// Link the trace to the correlation ID assigned by the logging middleware
+		if correlationID := logger.GetCorrelationIDFromContext(c.Request.Context()); correlationID != "" {
+			span.SetAttributes(attribute.String("correlation_id", correlationID))
+		}
+		if keyID := c.GetString("api_key_id"); keyID != "" {
+			span.SetAttributes(attribute.String("api_key_id", keyID))
+		}
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package tracing
+
+import (
+	"net/http"
+
+	"solana-balance-api/pkg/logger"
+)
+
+// Transport wraps an http.RoundTripper and propagates the trace context and
+// correlation ID of each request's context to the upstream service. Every outbound
+// client (Solana RPC, webhook deliveries) should use it.
+type Transport struct {
+	Base http.RoundTripper
+}
+
+// NewTransport creates a propagating transport around base, or around
+// http.DefaultTransport when base is nil
+func NewTransport(base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{Base: base}
+}
+
+// RoundTrip injects trace and correlation headers into a copy of the request and sends it
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers must not modify the caller's request
+	outbound := req.Clone(req.Context())
+	InjectHeaders(req.Context(), outbound.Header)
+	if correlationID := logger.GetCorrelationIDFromContext(req.Context()); correlationID != "" {
+		outbound.Header.Set(logger.CorrelationIDHeader, correlationID)
+	}
+	return t.Base.RoundTrip(outbound)
+}
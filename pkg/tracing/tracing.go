@@ -0,0 +1,188 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation name of every span created by the service
+const TracerName = "solana-balance-api"
+
+// Exporters
+const (
+	ExporterOTLP   = "otlp"   // OTLP over HTTP
+	ExporterMemory = "memory" // In-memory, for tests
+	ExporterNone   = "none"   // Spans are created for propagation but never exported
+)
+
+// Config holds tracing configuration
+type Config struct {
+	Enabled     bool
+	Exporter    string            // One of ExporterOTLP, ExporterMemory or ExporterNone
+	Endpoint    string            // OTLP/HTTP collector host:port
+	URLPath     string            // OTLP/HTTP traces path; empty uses /v1/traces
+	Insecure    bool              // Use plain HTTP to reach the collector
+	Headers     map[string]string // Extra headers sent to the collector
+	SampleRatio float64           // Fraction of new traces sampled; incoming sampled flags are honoured
+	ServiceName string
+}
+
+// Provider owns the tracer provider installed as the global OpenTelemetry provider
+type Provider struct {
+	provider *sdktrace.TracerProvider
+	memory   *tracetest.InMemoryExporter
+}
+
+var propagatorOnce sync.Once
+
+// installPropagator installs the W3C trace context and baggage propagators. They
+// are installed even when tracing is disabled so incoming traceparent headers still
+// link logs to the caller's trace.
+func installPropagator() {
+	propagatorOnce.Do(func() {
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{},
+			propagation.Baggage{},
+		))
+	})
+}
+
+// Setup installs the global tracer provider described by cfg. When tracing is
+// disabled the returned provider is a no-op and only propagation is configured.
+func Setup(ctx context.Context, cfg Config) (*Provider, error) {
+	installPropagator()
+
+	if !cfg.Enabled || cfg.Exporter == ExporterNone {
+		return &Provider{}, nil
+	}
+
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = TracerName
+	}
+	res := resource.NewSchemaless(
+		attribute.String("service.name", serviceName),
+		attribute.String("service.version", "1.0.0"),
+	)
+
+	ratio := cfg.SampleRatio
+	if ratio < 0 || ratio > 1 {
+		ratio = 1
+	}
+	sampler := sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+
+	switch cfg.Exporter {
+	case ExporterMemory:
+		return NewInMemoryProvider(), nil
+
+	case ExporterOTLP, "":
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+		if cfg.URLPath != "" {
+			opts = append(opts, otlptracehttp.WithURLPath(cfg.URLPath))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+		}
+
+		exporter, err := otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+		}
+
+		provider := sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(exporter),
+			sdktrace.WithSampler(sampler),
+			sdktrace.WithResource(res),
+		)
+		otel.SetTracerProvider(provider)
+		return &Provider{provider: provider}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+}
+
+// NewInMemoryProvider installs a global tracer provider that samples every span and
+// records finished spans in memory, synchronously. Intended for tests.
+func NewInMemoryProvider() *Provider {
+	installPropagator()
+
+	memory := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(memory),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	otel.SetTracerProvider(provider)
+
+	return &Provider{provider: provider, memory: memory}
+}
+
+// Spans returns the finished spans recorded by an in-memory provider
+func (p *Provider) Spans() tracetest.SpanStubs {
+	if p.memory == nil {
+		return nil
+	}
+	return p.memory.GetSpans()
+}
+
+// Reset discards the spans recorded by an in-memory provider
+func (p *Provider) Reset() {
+	if p.memory != nil {
+		p.memory.Reset()
+	}
+}
+
+// Shutdown exports pending spans and stops the provider
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p.provider == nil {
+		return nil
+	}
+	return p.provider.Shutdown(ctx)
+}
+
+// Tracer returns the service tracer from the global provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// Start starts a span as a child of any span in ctx
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// RecordError records err on the span and marks the span as failed
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// TraceID returns the hex trace ID of the span in ctx, or "" when there is none
+func TraceID(ctx context.Context) string {
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		return spanContext.TraceID().String()
+	}
+	return ""
+}
+
+// InjectHeaders writes the trace context of ctx into outbound request headers
+func InjectHeaders(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
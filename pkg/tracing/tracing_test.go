@@ -0,0 +1,94 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"solana-balance-api/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	provider := NewInMemoryProvider()
+	defer provider.Shutdown(context.Background())
+
+	var handlerTraceID string
+	engine := gin.New()
+	engine.Use(Middleware())
+	engine.Use(logger.LoggingMiddleware())
+	engine.GET("/wallets/:address", func(c *gin.Context) {
+		handlerTraceID = TraceID(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+	engine.GET("/fail", func(c *gin.Context) {
+		c.Status(http.StatusBadGateway)
+	})
+
+	t.Run("ContinuesIncomingTrace", func(t *testing.T) {
+		provider.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/wallets/abc", nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+
+		spans := provider.Spans()
+		require.Len(t, spans, 1)
+		span := spans[0]
+		assert.Equal(t, "GET /wallets/:address", span.Name)
+		assert.Equal(t, trace.SpanKindServer, span.SpanKind)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String())
+		assert.Equal(t, "00f067aa0ba902b7", span.Parent.SpanID().String())
+		assert.Equal(t, span.SpanContext.TraceID().String(), handlerTraceID)
+
+		// The span is linked to the correlation ID returned to the client
+		attrs := make(map[string]string)
+		for _, kv := range span.Attributes {
+			attrs[string(kv.Key)] = kv.Value.Emit()
+		}
+		assert.Equal(t, w.Header().Get("X-Correlation-ID"), attrs["correlation_id"])
+		assert.Equal(t, "200", attrs["http.response.status_code"])
+	})
+
+	t.Run("NewTraceAndServerError", func(t *testing.T) {
+		provider.Reset()
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+		spans := provider.Spans()
+		require.Len(t, spans, 1)
+		assert.False(t, spans[0].Parent.IsValid())
+		assert.Equal(t, "Error", spans[0].Status.Code.String())
+	})
+}
+
+func TestTransport(t *testing.T) {
+	provider := NewInMemoryProvider()
+	defer provider.Shutdown(context.Background())
+
+	var received http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+	}))
+	defer upstream.Close()
+
+	ctx, span := Start(logger.ContextWithCorrelationID(context.Background(), "corr-1"), "outbound")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, upstream.URL, nil)
+	require.NoError(t, err)
+
+	client := &http.Client{Transport: NewTransport(nil)}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	span.End()
+
+	assert.Contains(t, received.Get("traceparent"), span.SpanContext().TraceID().String())
+	assert.Equal(t, "corr-1", received.Get(logger.CorrelationIDHeader))
+	assert.Empty(t, req.Header.Get("traceparent"), "caller's request must not be modified")
+}
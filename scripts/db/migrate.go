@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"solana-balance-api/internal/config"
+	"solana-balance-api/internal/models"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -82,6 +83,36 @@ func (mm *MigrationManager) initializeMigrations() {
 			Up:          mm.migration003Up,
 			Down:        mm.migration003Down,
 		},
+		{
+			Version:     4,
+			Description: "Add default scopes to existing API keys",
+			Up:          mm.migration004Up,
+			Down:        mm.migration004Down,
+		},
+		{
+			Version:     5,
+			Description: "Add indexes for API key expiry and rotation",
+			Up:          mm.migration005Up,
+			Down:        mm.migration005Down,
+		},
+		{
+			Version:     6,
+			Description: "Create organizations and organization usage collections",
+			Up:          mm.migration006Up,
+			Down:        mm.migration006Down,
+		},
+		{
+			Version:     7,
+			Description: "Create API key usage collection with hourly bucket indexes",
+			Up:          mm.migration007Up,
+			Down:        mm.migration007Down,
+		},
+		{
+			Version:     8,
+			Description: "Create audit log collection with hash chain indexes",
+			Up:          mm.migration008Up,
+			Down:        mm.migration008Down,
+		},
 	}
 }
 
@@ -218,6 +249,305 @@ func (mm *MigrationManager) migration003Down(db *mongo.Database) error {
 	return nil
 }
 
+// migration004Up grants the default scopes to API keys created before scoped permissions
+func (mm *MigrationManager) migration004Up(db *mongo.Database) error {
+	collection := db.Collection(mm.config.APIKeyCollection)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Keys without a scopes field (or with an empty one) get the defaults
+	filter := bson.M{"$or": []bson.M{
+		{"scopes": bson.M{"$exists": false}},
+		{"scopes": bson.M{"$size": 0}},
+	}}
+	update := bson.M{"$set": bson.M{"scopes": models.DefaultScopes}}
+
+	result, err := collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to add default scopes: %w", err)
+	}
+
+	log.Printf("Migration 004: Added default scopes %v to %d API keys", models.DefaultScopes, result.ModifiedCount)
+	return nil
+}
+
+// migration004Down removes scopes that still equal the defaults
+func (mm *MigrationManager) migration004Down(db *mongo.Database) error {
+	collection := db.Collection(mm.config.APIKeyCollection)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Keys whose scopes were customized after the migration are left untouched
+	filter := bson.M{"scopes": models.DefaultScopes}
+	update := bson.M{"$unset": bson.M{"scopes": ""}}
+
+	result, err := collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to remove default scopes: %w", err)
+	}
+
+	log.Printf("Migration 004 rollback: Removed default scopes from %d API keys", result.ModifiedCount)
+	return nil
+}
+
+// migration005Up adds indexes used by rotated-secret lookups and the expiry sweeper
+func (mm *MigrationManager) migration005Up(db *mongo.Database) error {
+	collection := db.Collection(mm.config.APIKeyCollection)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Sparse index on previous_key for lookups during rotation grace windows
+	previousKeyIndexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "previous_key", Value: 1}},
+		Options: options.Index().SetSparse(true),
+	}
+
+	_, err := collection.Indexes().CreateOne(ctx, previousKeyIndexModel)
+	if err != nil {
+		return fmt.Errorf("failed to create previous_key index: %w", err)
+	}
+
+	// Compound index on active and expires_at for the expiry sweeper
+	expiryIndexModel := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "active", Value: 1},
+			{Key: "expires_at", Value: 1},
+		},
+	}
+
+	_, err = collection.Indexes().CreateOne(ctx, expiryIndexModel)
+	if err != nil {
+		return fmt.Errorf("failed to create expiry index: %w", err)
+	}
+
+	log.Println("Migration 005: Added API key expiry and rotation indexes")
+	return nil
+}
+
+// migration005Down removes the expiry and rotation indexes
+func (mm *MigrationManager) migration005Down(db *mongo.Database) error {
+	collection := db.Collection(mm.config.APIKeyCollection)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := collection.Indexes().DropOne(ctx, "previous_key_1")
+	if err != nil {
+		log.Printf("Warning: failed to drop previous_key index: %v", err)
+	}
+
+	_, err = collection.Indexes().DropOne(ctx, "active_1_expires_at_1")
+	if err != nil {
+		log.Printf("Warning: failed to drop expiry index: %v", err)
+	}
+
+	log.Println("Migration 005 rollback: Removed API key expiry and rotation indexes")
+	return nil
+}
+
+// migration006Up creates the organization collections and an organization for every
+// tenant ID already referenced by API keys
+func (mm *MigrationManager) migration006Up(db *mongo.Database) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	organizations := db.Collection(mm.config.OrganizationCollection)
+	usage := db.Collection(mm.config.OrganizationUsageCollection)
+	apiKeys := db.Collection(mm.config.APIKeyCollection)
+
+	// Create unique index on tenant_id
+	_, err := organizations.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "tenant_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create unique index on organization tenant_id: %w", err)
+	}
+
+	// Create unique index on tenant_id and period for usage rollups
+	_, err = usage.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "tenant_id", Value: 1},
+			{Key: "period", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create usage index: %w", err)
+	}
+
+	// Create sparse index on tenant_id for listing an organization's keys
+	_, err = apiKeys.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "tenant_id", Value: 1}},
+		Options: options.Index().SetSparse(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create API key tenant_id index: %w", err)
+	}
+
+	// Create organizations for tenant IDs already assigned to keys
+	tenantIDs, err := apiKeys.Distinct(ctx, "tenant_id", bson.M{"tenant_id": bson.M{"$nin": bson.A{nil, ""}}})
+	if err != nil {
+		return fmt.Errorf("failed to list existing tenant IDs: %w", err)
+	}
+
+	now := time.Now()
+	created := 0
+	for _, value := range tenantIDs {
+		tenantID, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		result, err := organizations.UpdateOne(ctx,
+			bson.M{"tenant_id": tenantID},
+			bson.M{"$setOnInsert": models.Organization{
+				TenantID:  tenantID,
+				Name:      tenantID,
+				Active:    true,
+				RateTier:  models.RateTierStandard,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create organization %s: %w", tenantID, err)
+		}
+		if result.UpsertedCount > 0 {
+			created++
+		}
+	}
+
+	log.Printf("Migration 006: Created organization collections and %d organizations for existing tenants", created)
+	return nil
+}
+
+// migration006Down drops the organization collections
+func (mm *MigrationManager) migration006Down(db *mongo.Database) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := db.Collection(mm.config.OrganizationCollection).Drop(ctx); err != nil {
+		return fmt.Errorf("failed to drop organizations collection: %w", err)
+	}
+
+	if err := db.Collection(mm.config.OrganizationUsageCollection).Drop(ctx); err != nil {
+		return fmt.Errorf("failed to drop organization usage collection: %w", err)
+	}
+
+	_, err := db.Collection(mm.config.APIKeyCollection).Indexes().DropOne(ctx, "tenant_id_1")
+	if err != nil {
+		log.Printf("Warning: failed to drop API key tenant_id index: %v", err)
+	}
+
+	log.Println("Migration 006 rollback: Dropped organization collections")
+	return nil
+}
+
+// migration007Up creates the per-key usage collection with hourly bucket indexes
+func (mm *MigrationManager) migration007Up(db *mongo.Database) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	usage := db.Collection(mm.config.UsageCollection)
+
+	// Create unique index on key_id and bucket so flushes upsert one document per hour
+	_, err := usage.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "key_id", Value: 1},
+			{Key: "bucket", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create usage key_id/bucket index: %w", err)
+	}
+
+	// Create index on tenant_id and bucket for organization reports
+	_, err = usage.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "tenant_id", Value: 1},
+			{Key: "bucket", Value: 1},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create usage tenant_id/bucket index: %w", err)
+	}
+
+	// Create index on bucket for reports across all keys
+	_, err = usage.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "bucket", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create usage bucket index: %w", err)
+	}
+
+	log.Println("Migration 007: Created API key usage collection indexes")
+	return nil
+}
+
+// migration007Down drops the per-key usage collection
+func (mm *MigrationManager) migration007Down(db *mongo.Database) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := db.Collection(mm.config.UsageCollection).Drop(ctx); err != nil {
+		return fmt.Errorf("failed to drop API key usage collection: %w", err)
+	}
+
+	log.Println("Migration 007 rollback: Dropped API key usage collection")
+	return nil
+}
+
+// migration008Up creates the audit log indexes. The unique sequence index keeps
+// concurrent writers from forking the hash chain.
+func (mm *MigrationManager) migration008Up(db *mongo.Database) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	auditLog := db.Collection(mm.config.AuditCollection)
+
+	_, err := auditLog.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "sequence", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create audit log sequence index: %w", err)
+	}
+
+	_, err = auditLog.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "type", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "actor_key_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create audit log query indexes: %w", err)
+	}
+
+	log.Println("Migration 008: Created audit log indexes")
+	return nil
+}
+
+// migration008Down removes the audit log query indexes. The audit records and the
+// sequence index are kept: the log is append-only.
+func (mm *MigrationManager) migration008Down(db *mongo.Database) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	auditLog := db.Collection(mm.config.AuditCollection)
+	for _, name := range []string{"type_1_timestamp_-1", "actor_key_id_1_timestamp_-1"} {
+		if _, err := auditLog.Indexes().DropOne(ctx, name); err != nil {
+			log.Printf("Warning: failed to drop audit log index %s: %v", name, err)
+		}
+	}
+
+	log.Println("Migration 008 rollback: Removed audit log query indexes")
+	return nil
+}
+
 // GetCurrentVersion returns the current migration version
 func (mm *MigrationManager) GetCurrentVersion() (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -343,7 +673,10 @@ func (mm *MigrationManager) Close() error {
 
 func RunMigrations() {
 	// Load configuration
-	cfg := config.LoadConfig()
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
 
 	// Create migration manager
 	manager, err := NewMigrationManager(&cfg.MongoDB)